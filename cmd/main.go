@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"github.com/joho/godotenv"
 	"log"
 	"os"
@@ -38,9 +39,36 @@ func main() {
 	if err != nil {
 		log.Fatalf("error %s", err.Error())
 	}
-	repos := repository.NewRepository(db)
+	if err := repository.Migrate(db); err != nil {
+		log.Fatalf("error %s", err.Error())
+	}
+	blobs, err := repository.NewBlobStore(repository.BlobConfig{
+		Store:     os.Getenv("mediaStore"),
+		Dir:       os.Getenv("mediaDir"),
+		Endpoint:  os.Getenv("s3Endpoint"),
+		Region:    os.Getenv("s3Region"),
+		Bucket:    os.Getenv("s3Bucket"),
+		AccessKey: os.Getenv("s3AccessKey"),
+		SecretKey: os.Getenv("s3SecretKey"),
+	})
+	if err != nil {
+		log.Fatalf("error %s", err.Error())
+	}
+	repos := repository.NewRepository(db, blobs)
 	services := service.NewService(repos)
+	go service.RunTrashPurge(context.Background(), services.Trash)
+	go service.RunScheduler(context.Background(), services.Post)
 	handlers := handler.NewHandler(services)
+	oauth, err := handler.LoadOAuthRegistry()
+	if err != nil {
+		log.Fatalf("error %s", err.Error())
+	}
+	handlers.UseOAuthProviders(oauth)
+	ipExtractor, err := handler.NewIPExtractor(os.Getenv("trustedProxies"))
+	if err != nil {
+		log.Fatalf("error %s", err.Error())
+	}
+	handlers.UseIPExtractor(ipExtractor)
 
 	server := new(service.Server)
 	if err := server.Run(os.Getenv("PORT"), handlers.InitRoutes()); err != nil {
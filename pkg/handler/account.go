@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// VerifyEmail godoc
+// @Summary      Verify user email
+// @Description  confirm the email with the token from the verification email
+// @Tags         auth
+// @Produce      json
+// @Param        token	query    string  true  "Verification token"
+// @Success      200 	{object} MessageResponse "email verified"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid or expired token"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/verify-email [get]
+func (h *Handler) VerifyEmail(c echo.Context) error {
+	err := h.services.Account.VerifyEmail(c.QueryParam("token"))
+	if errors.Is(err, service.ErrInvalidUserToken) {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "email verified"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  send a new verification email to the signed in user
+// @Tags         auth
+// @Produce      json
+// @Success      200 	{object} MessageResponse "verification email sent"
+// @Failure 	 400 	{object} ErrorResponse	 "email is already verified"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/resend-verification [post]
+func (h *Handler) ResendVerification(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	err := h.services.Account.SendVerification(userId)
+	if errors.Is(err, service.ErrNoEmail) || errors.Is(err, service.ErrEmailVerified) {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "verification email sent"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  mail a password reset link if an account uses the email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        email	body     ForgotPasswordRequest  true  "Account email"
+// @Success      200 	{object} MessageResponse "password reset email sent if the account exists"
+// @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/forgot-password [post]
+func (h *Handler) ForgotPassword(c echo.Context) error {
+	var input ForgotPasswordRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	if err := h.services.Account.ForgotPassword(input.Email); err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "password reset email sent if the account exists"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6,max=128"`
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  set a new password with the token from the reset email
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input	body     ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200 	{object} MessageResponse "password changed"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid or expired token"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/reset-password [post]
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var input ResetPasswordRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	err := h.services.Account.ResetPassword(input.Token, input.Password)
+	if errors.Is(err, service.ErrInvalidUserToken) || errors.Is(err, service.ErrPasswordTooShort) {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "password changed"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_VerifyEmail(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAccount)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().VerifyEmail("token").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"email verified"}` + "\n",
+		},
+		{
+			name: "Invalid token",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().VerifyEmail("token").Return(service.ErrInvalidUserToken)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid or expired token"}` + "\n",
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().VerifyEmail("token").Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			testCase.mockBehavior(account)
+
+			handler := NewHandler(&service.Service{Account: account})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodGet, "/auth/verify-email?token=token", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.VerifyEmail(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ResendVerification(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAccount)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().SendVerification(3).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"verification email sent"}` + "\n",
+		},
+		{
+			name: "Already verified",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().SendVerification(3).Return(service.ErrEmailVerified)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"email is already verified"}` + "\n",
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().SendVerification(3).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			testCase.mockBehavior(account)
+
+			handler := NewHandler(&service.Service{Account: account})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/auth/resend-verification", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+
+			if assert.NoError(t, handler.ResendVerification(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ForgotPassword(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAccount)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().ForgotPassword("test@example.com").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"password reset email sent if the account exists"}` + "\n",
+		},
+		{
+			name:      "Empty email",
+			inputBody: `{}`,
+			mockBehavior: func(s *mockService.MockAccount) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"email","rule":"required","message":"email is required"}]}` + "\n",
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"email":"test@example.com"}`,
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().ForgotPassword("test@example.com").Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			testCase.mockBehavior(account)
+
+			handler := NewHandler(&service.Service{Account: account})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/auth/forgot-password",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.ForgotPassword(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ResetPassword(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAccount)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"token":"token","password":"new password"}`,
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().ResetPassword("token", "new password").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"password changed"}` + "\n",
+		},
+		{
+			name:      "Used token",
+			inputBody: `{"token":"token","password":"new password"}`,
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().ResetPassword("token", "new password").Return(service.ErrInvalidUserToken)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid or expired token"}` + "\n",
+		},
+		{
+			name:                 "Short password",
+			inputBody:            `{"token":"token","password":"pass"}`,
+			mockBehavior:         func(s *mockService.MockAccount) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"password","rule":"min","param":"6","message":"password must be at least 6 symbols"}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			testCase.mockBehavior(account)
+
+			handler := NewHandler(&service.Service{Account: account})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/auth/reset-password",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.ResetPassword(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_requireVerifiedEmail(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAccount)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Verified",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().CheckVerified(testPrincipal).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "3" + "\n",
+		},
+		{
+			name: "Not verified",
+			mockBehavior: func(s *mockService.MockAccount) {
+				s.EXPECT().CheckVerified(testPrincipal).Return(service.ErrEmailNotVerified)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"email is not verified"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			account := mockService.NewMockAccount(c)
+			testCase.mockBehavior(account)
+
+			handler := NewHandler(&service.Service{Account: account})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			e.POST("/protected", func(c echo.Context) error {
+				return c.JSON(200, testPrincipal.Id)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(principalCtx, testPrincipal)
+					return next(c)
+				}
+			}, handler.requireVerifiedEmail)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/protected", nil)
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
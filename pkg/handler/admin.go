@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// UpdateUserRole godoc
+// @Summary      Change the role of a user
+// @Description  set user role (user, moderator or admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id     path     int          true  "User ID"
+// @Param        role	body     RoleRequest  true  "New role"
+// @Success      200 	{object} MessageResponse "Role of user with id # updated"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid role"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 404 	{object} ErrorResponse	 "user not found"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/admin/users/{id}/role [put]
+func (h *Handler) UpdateUserRole(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	var input RoleRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	err := h.services.Authorization.UpdateRole(id, input.Role)
+	if errors.Is(err, service.ErrInvalidRole) {
+		NewErrorResponse(c, http.StatusBadRequest, "invalid role")
+		return nil
+	}
+	if errors.Is(err, service.ErrUserNotFound) {
+		NewErrorResponse(c, http.StatusNotFound, "user not found")
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("Role of user with id %d updated", id),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// UnlockUser godoc
+// @Summary      Unlock a user account
+// @Description  clear failed sign-in attempts of a user
+// @Tags         admin
+// @Produce      json
+// @Param        id     path     int          true  "User ID"
+// @Success      200 	{object} MessageResponse "User with id # unlocked"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 404 	{object} ErrorResponse	 "user not found"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/admin/users/{id}/unlock [post]
+func (h *Handler) UnlockUser(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	err := h.services.Authorization.UnlockUser(id)
+	if errors.Is(err, service.ErrUserNotFound) {
+		NewErrorResponse(c, http.StatusNotFound, "user not found")
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("User with id %d unlocked", id),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// RotateSigningKey godoc
+// @Summary      Rotate the token signing key
+// @Description  start signing tokens with a new key, tokens of the old keys stay valid until they expire
+// @Tags         admin
+// @Produce      json
+// @Success      200 	{object} MessageResponse "signing key rotated"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/admin/keys/rotate [post]
+func (h *Handler) RotateSigningKey(c echo.Context) error {
+	if err := h.services.Authorization.RotateSigningKey(); err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "signing key rotated"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetDeletedPosts godoc
+// @Summary      List deleted posts
+// @Description  get the posts in the trash, they are purged after the retention period
+// @Tags         admin
+// @Produce      json
+// @Param        sort    query    string false "Sort fields: deleted_at, id; prefix - for descending" default(-deleted_at)
+// @Param        limit   query    int    false "Page size"
+// @Param        cursor  query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success      200 	{object} GetPostsResponse
+// @Failure 	 400 	{object} ErrorResponse	 "invalid cursor"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid sort"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/admin/trash/posts [get]
+func (h *Handler) GetDeletedPosts(c echo.Context) error {
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+
+	posts, next, err := h.services.Trash.GetDeletedPosts(page)
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, GetPostsResponse{Posts: posts, NextCursor: next})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetDeletedComments godoc
+// @Summary      List deleted comments
+// @Description  get the comments in the trash, they are purged after the retention period
+// @Tags         admin
+// @Produce      json
+// @Param        sort    query    string false "Sort fields: deleted_at, id; prefix - for descending" default(-deleted_at)
+// @Param        limit   query    int    false "Page size"
+// @Param        cursor  query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success      200 	{object} GetCommentsResponse
+// @Failure 	 400 	{object} ErrorResponse	 "invalid cursor"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid sort"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/admin/trash/comments [get]
+func (h *Handler) GetDeletedComments(c echo.Context) error {
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+
+	comments, next, err := h.services.Trash.GetDeletedComments(page)
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, GetCommentsResponse{Comments: comments, NextCursor: next})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestHandler_UpdateUserRole(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization, id int, role string)
+
+	testTable := []struct {
+		name                 string
+		inputParam           string
+		inputBody            string
+		role                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:       "ok",
+			inputParam: "3",
+			inputBody:  `{"role":"moderator"}`,
+			role:       "moderator",
+			mockBehavior: func(s *mockService.MockAuthorization, id int, role string) {
+				s.EXPECT().UpdateRole(id, role).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"Role of user with id 3 updated"}` + "\n",
+		},
+		{
+			name:       "Invalid role",
+			inputParam: "3",
+			inputBody:  `{"role":"owner"}`,
+			role:       "owner",
+			mockBehavior: func(s *mockService.MockAuthorization, id int, role string) {
+				s.EXPECT().UpdateRole(id, role).Return(service.ErrInvalidRole)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid role"}` + "\n",
+		},
+		{
+			name:       "User not found",
+			inputParam: "3",
+			inputBody:  `{"role":"admin"}`,
+			role:       "admin",
+			mockBehavior: func(s *mockService.MockAuthorization, id int, role string) {
+				s.EXPECT().UpdateRole(id, role).Return(service.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"user not found"}` + "\n",
+		},
+		{
+			name:       "Service Error",
+			inputParam: "3",
+			inputBody:  `{"role":"admin"}`,
+			role:       "admin",
+			mockBehavior: func(s *mockService.MockAuthorization, id int, role string) {
+				s.EXPECT().UpdateRole(id, role).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"server error"}` + "\n",
+		},
+		{
+			name:       "Wrong id",
+			inputParam: "id",
+			inputBody:  `{"role":"admin"}`,
+			mockBehavior: func(s *mockService.MockAuthorization, id int, role string) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"id is not integer"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, 3, testCase.role)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/users/:id/role",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues(testCase.inputParam)
+
+			if assert.NoError(t, handler.UpdateUserRole(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_UnlockUser(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization, id int)
+
+	testTable := []struct {
+		name                 string
+		inputParam           string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:       "ok",
+			inputParam: "3",
+			mockBehavior: func(s *mockService.MockAuthorization, id int) {
+				s.EXPECT().UnlockUser(id).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"User with id 3 unlocked"}` + "\n",
+		},
+		{
+			name:       "User not found",
+			inputParam: "3",
+			mockBehavior: func(s *mockService.MockAuthorization, id int) {
+				s.EXPECT().UnlockUser(id).Return(service.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"user not found"}` + "\n",
+		},
+		{
+			name:       "Service Error",
+			inputParam: "3",
+			mockBehavior: func(s *mockService.MockAuthorization, id int) {
+				s.EXPECT().UnlockUser(id).Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"server error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, 3)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/:id/unlock", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues(testCase.inputParam)
+
+			if assert.NoError(t, handler.UnlockUser(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetDeletedPosts(t *testing.T) {
+	type mockBehavior func(s *mockService.MockTrash)
+
+	deletedAt := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "ok",
+			query: "?limit=1",
+			mockBehavior: func(s *mockService.MockTrash) {
+				s.EXPECT().GetDeletedPosts(service.PageRequest{Limit: 1}).Return([]models.Post{
+					{Id: 4, UserId: 12, Title: "title", Anons: "anons", DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+				}, "abc", nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"posts":[{"id":4,"user_id":12,"title":"title","anons":"anons","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":"2022-03-01T00:00:00Z"}],` +
+				`"next_cursor":"abc"}` + "\n",
+		},
+		{
+			name:  "Invalid sort",
+			query: "?sort=title",
+			mockBehavior: func(s *mockService.MockTrash) {
+				s.EXPECT().GetDeletedPosts(service.PageRequest{Sort: "title"}).Return(nil, "", service.ErrInvalidSort)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort"}` + "\n",
+		},
+		{
+			name:  "Service Error",
+			query: "",
+			mockBehavior: func(s *mockService.MockTrash) {
+				s.EXPECT().GetDeletedPosts(service.PageRequest{}).Return(nil, "", errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			trash := mockService.NewMockTrash(c)
+			testCase.mockBehavior(trash)
+
+			services := &service.Service{Trash: trash}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/trash/posts"+testCase.query, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.GetDeletedPosts(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// CreateApiKey godoc
+// @Summary      Create an API key
+// @Description  create a personal API key for machine clients, the key is returned only once
+// @Tags         keys
+// @Accept       json
+// @Produce      json
+// @Param        key	body     ApiKeyRequest   true  "Name, scopes and optional expiration time"
+// @Success      200 	{object} CreateApiKeyResponse "created key"
+// @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/keys [post]
+func (h *Handler) CreateApiKey(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	var input ApiKeyRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	key, apiKey, err := h.services.ApiKey.CreateApiKey(userId, input.Name, input.Scopes, input.ExpiresAt)
+	if errors.Is(err, service.ErrApiKeyName) || errors.Is(err, service.ErrInvalidScope) ||
+		errors.Is(err, service.ErrInvalidExpiresAt) {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, CreateApiKeyResponse{Key: key, ApiKeyResponse: NewApiKeyResponse(apiKey)})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetApiKeys godoc
+// @Summary      List API keys
+// @Description  get API keys of the user, including revoked ones
+// @Tags         keys
+// @Produce      json
+// @Success      200 	{object} GetApiKeysResponse "keys of the user"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/keys [get]
+func (h *Handler) GetApiKeys(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	keys, err := h.services.ApiKey.GetApiKeys(userId)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	response := GetApiKeysResponse{Keys: make([]ApiKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, NewApiKeyResponse(key))
+	}
+	errRes := c.JSON(http.StatusOK, response)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// RevokeApiKey godoc
+// @Summary      Revoke an API key
+// @Description  revoke an API key of the user
+// @Tags         keys
+// @Produce      json
+// @Param        id     path     int          true  "Key ID"
+// @Success      200 	{object} MessageResponse "API key with id # revoked"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 403 	{object} ErrorResponse	 "permission denied"
+// @Failure 	 404 	{object} ErrorResponse	 "api key not found"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/keys/{id} [delete]
+func (h *Handler) RevokeApiKey(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	err := h.services.ApiKey.RevokeApiKey(userId, id)
+	if errors.Is(err, service.ErrApiKeyNotFound) {
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("API key with id %d revoked", id),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
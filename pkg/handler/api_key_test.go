@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestHandler_CreateApiKey(t *testing.T) {
+	type mockBehavior func(s *mockService.MockApiKey)
+
+	createdAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"name":"ci","scopes":["posts:write"]}`,
+			mockBehavior: func(s *mockService.MockApiKey) {
+				s.EXPECT().CreateApiKey(3, "ci", []string{service.ScopePostsWrite}, nil).Return("pat_secret",
+					models.ApiKey{Id: 1, UserId: 3, Name: "ci", Prefix: "pat_secret", Scopes: "posts:write", CreatedAt: createdAt}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"key":"pat_secret","id":1,"name":"ci","prefix":"pat_secret","scopes":["posts:write"],` +
+				`"expires_at":null,"last_used_at":null,"revoked_at":null,"created_at":"2022-01-01T00:00:00Z"}` + "\n",
+		},
+		{
+			name:      "Invalid scope",
+			inputBody: `{"name":"ci","scopes":["everything"]}`,
+			mockBehavior: func(s *mockService.MockApiKey) {
+				s.EXPECT().CreateApiKey(3, "ci", []string{"everything"}, nil).Return("", models.ApiKey{}, service.ErrInvalidScope)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid scope"}` + "\n",
+		},
+		{
+			name:                 "Error request data",
+			inputBody:            "error",
+			mockBehavior:         func(s *mockService.MockApiKey) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"name":"ci","scopes":["posts:write"]}`,
+			mockBehavior: func(s *mockService.MockApiKey) {
+				s.EXPECT().CreateApiKey(3, "ci", []string{service.ScopePostsWrite}, nil).
+					Return("", models.ApiKey{}, errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			keys := mockService.NewMockApiKey(c)
+			testCase.mockBehavior(keys)
+
+			services := &service.Service{ApiKey: keys}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/keys", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+
+			if assert.NoError(t, handler.CreateApiKey(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetApiKeys(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	usedAt := time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)
+	keys := mockService.NewMockApiKey(c)
+	keys.EXPECT().GetApiKeys(3).Return([]models.ApiKey{
+		{Id: 1, Name: "ci", Prefix: "pat_abcdef", Scopes: "posts:write,comments:write", LastUsedAt: &usedAt},
+	}, nil)
+
+	handler := NewHandler(&service.Service{ApiKey: keys})
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	req := httptest.NewRequest(http.MethodGet, "/api/keys", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Set(userCtx, 3)
+
+	if assert.NoError(t, handler.GetApiKeys(ctx)) {
+		assert.Equal(t, 200, rec.Code)
+		assert.Equal(t, `{"keys":[{"id":1,"name":"ci","prefix":"pat_abcdef","scopes":["posts:write","comments:write"],`+
+			`"expires_at":null,"last_used_at":"2022-01-02T00:00:00Z","revoked_at":null,"created_at":"0001-01-01T00:00:00Z"}]}`+"\n",
+			rec.Body.String())
+	}
+}
+
+func TestHandler_RevokeApiKey(t *testing.T) {
+	type mockBehavior func(s *mockService.MockApiKey)
+
+	testTable := []struct {
+		name                 string
+		inputParam           string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:       "ok",
+			inputParam: "1",
+			mockBehavior: func(s *mockService.MockApiKey) {
+				s.EXPECT().RevokeApiKey(3, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"API key with id 1 revoked"}` + "\n",
+		},
+		{
+			name:       "Not found",
+			inputParam: "1",
+			mockBehavior: func(s *mockService.MockApiKey) {
+				s.EXPECT().RevokeApiKey(3, 1).Return(service.ErrApiKeyNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"api key not found"}` + "\n",
+		},
+		{
+			name:                 "Wrong id",
+			inputParam:           "id",
+			mockBehavior:         func(s *mockService.MockApiKey) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"id is not integer"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			keys := mockService.NewMockApiKey(c)
+			testCase.mockBehavior(keys)
+
+			handler := NewHandler(&service.Service{ApiKey: keys})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodDelete, "/api/keys/:id", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues(testCase.inputParam)
+
+			if assert.NoError(t, handler.RevokeApiKey(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
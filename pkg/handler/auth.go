@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
+	"math"
 	"net/http"
+	"strconv"
 	"test/pkg/repository/models"
+	"test/pkg/service"
 )
 
 // SignUp godoc
@@ -16,39 +20,43 @@ import (
 // @Success      200 	{object} IdResponse		 "result is id of user"
 // @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
 // @Failure 	 404 	{object} ErrorResponse	 "user id not found"
+// @Failure 	 409 	{object} ErrorResponse	 "email is already used by another account"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
 // @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
 // @Router       /auth/sign-up [post]
 func (h *Handler) SignUp(c echo.Context) error {
-	var input models.User
-
-	if errReq := c.Bind(&input); errReq != nil {
-		NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
+	var input UserResponse
+	if errReq := GetRequest(c, &input); errReq != nil {
 		return nil
 	}
-	{
-		//username is not empty
-		if len(input.Username) == 0 {
-			NewErrorResponse(c, http.StatusBadRequest, "You must enter a username")
-			return nil
-		}
 
-		//name is not empty
-		if len(input.Name) == 0 {
-			NewErrorResponse(c, http.StatusBadRequest, "You must enter a name")
+	//email is optional, but must be unused
+	if input.Email != "" {
+		errEmail := h.services.Account.CheckEmail(input.Email)
+		if errors.Is(errEmail, service.ErrEmailTaken) {
+			NewErrorResponse(c, http.StatusConflict, errEmail.Error())
 			return nil
 		}
-
-		// password length
-		if len(input.Password) < 6 {
-			NewErrorResponse(c, http.StatusBadRequest, "Password must be at least 6 symbols")
+		if errEmail != nil {
+			NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
 			return nil
 		}
 	}
-	id, errCreate := h.services.Authorization.CreateUser(input)
+	id, errCreate := h.services.Authorization.CreateUser(models.User{
+		Name:     input.Name,
+		Username: input.Username,
+		Password: input.Password,
+		Email:    input.Email,
+	})
 	if errCreate != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
 		return nil
 	}
+	if input.Email != "" {
+		if errSend := h.services.Account.SendVerification(id); errSend != nil {
+			c.Logger().Errorf("sending verification email to user %d failed: %s", id, errSend.Error())
+		}
+	}
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
 		"id": id})
 	if errRes != nil {
@@ -58,8 +66,8 @@ func (h *Handler) SignUp(c echo.Context) error {
 }
 
 type SignInInput struct {
-	Username string `json:"username" form:"username"  binding:"required"`
-	Password string `json:"password" form:"password"  binding:"required"`
+	Username string `json:"username" form:"username" validate:"required"`
+	Password string `json:"password" form:"password" validate:"required"`
 }
 
 // SignIn godoc
@@ -70,29 +78,120 @@ type SignInInput struct {
 // @Produce      json
 // @Param        user	body     SignInInput   true  "Get user token"
 // @Success      200 	{object} TokenResponse   "result is user token"
+// @Success      200 	{object} TwoFactorChallengeResponse "two-factor code is required"
 // @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
-// @Failure 	 400 	{object} ErrorResponse	 "incorrect password"
-// @Failure 	 404 	{object} ErrorResponse	 "user not found"
+// @Failure 	 401 	{object} ErrorResponse	 "invalid username or password"
+// @Failure 	 429 	{object} ErrorResponse	 "too many sign-in attempts"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
 // @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
 // @Router       /auth/sign-in [post]
 func (h *Handler) SignIn(c echo.Context) error {
 	var input SignInInput
-	if err := c.Bind(&input); err != nil {
-		NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
+	if err := GetRequest(c, &input); err != nil {
 		return nil
 	}
-	errCheck := h.services.Authorization.CheckUser(input.Username)
-	if errCheck != nil {
-		NewErrorResponse(c, http.StatusNotFound, "user not found")
+	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password, clientInfo(c))
+	if writeTooManyAttempts(c, err) {
+		return nil
+	}
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		NewErrorResponse(c, http.StatusUnauthorized, "invalid username or password")
 		return nil
 	}
-	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
 	if err != nil {
-		NewErrorResponse(c, http.StatusBadRequest, "incorrect password")
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
 		return nil
 	}
-	errRes := c.JSON(http.StatusOK, map[string]interface{}{
-		"token": token})
+	return writeSignIn(c, token)
+}
+
+func clientInfo(c echo.Context) service.ClientInfo {
+	return service.ClientInfo{IP: c.RealIP(), UserAgent: c.Request().UserAgent()}
+}
+
+// writeSignIn responds with the tokens or, when a second factor is required, the challenge.
+func writeSignIn(c echo.Context, tokens service.Tokens) error {
+	if tokens.ChallengeToken != "" {
+		return c.JSON(http.StatusOK, TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    tokens.ChallengeToken,
+			ExpiresIn:         tokens.ExpiresIn,
+		})
+	}
+	return c.JSON(http.StatusOK, NewTokenResponse(tokens))
+}
+
+// writeTooManyAttempts responds with 429 and Retry-After if err is a *service.TooManyAttemptsError.
+func writeTooManyAttempts(c echo.Context, err error) bool {
+	var tooMany *service.TooManyAttemptsError
+	if !errors.As(err, &tooMany) {
+		return false
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tooMany.RetryAfter.Seconds()))))
+	NewErrorResponse(c, http.StatusTooManyRequests, "too many sign-in attempts")
+	return true
+}
+
+// Refresh godoc
+// @Summary      Refresh user tokens
+// @Description  exchange a refresh token for a new token pair
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token	body     RefreshRequest  true  "Refresh token"
+// @Success      200 	{object} TokenResponse   "result is new user tokens"
+// @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
+// @Failure 	 401 	{object} ErrorResponse	 "invalid refresh token"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/refresh [post]
+func (h *Handler) Refresh(c echo.Context) error {
+	var input RefreshRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	tokens, err := h.services.Authorization.RefreshToken(input.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+		NewErrorResponse(c, http.StatusUnauthorized, "invalid refresh token")
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, NewTokenResponse(tokens))
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// Logout godoc
+// @Summary      Revoke user tokens
+// @Description  revoke the access token and the family of the given refresh token
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        token	body     RefreshRequest   false  "Refresh token"
+// @Success      200 	{object} MessageResponse  "logged out"
+// @Failure 	 401 	{object} ErrorResponse	  "empty auth header"
+// @Failure 	 500 	{object} ErrorResponse	  "something went wrong"
+// @Router       /auth/logout [post]
+func (h *Handler) Logout(c echo.Context) error {
+	claims, ok := c.Get(claimsCtx).(*service.TokenClaims)
+	if !ok {
+		NewErrorResponse(c, http.StatusUnauthorized, "token claims not found")
+		return nil
+	}
+	var input RefreshRequest
+	_ = c.Bind(&input)
+
+	err := h.services.Authorization.Logout(claims, input.RefreshToken)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "logged out"})
 	if errRes != nil {
 		return errRes
 	}
@@ -12,6 +12,7 @@ import (
 	"test/pkg/service"
 	mockService "test/pkg/service/mocks"
 	"testing"
+	"time"
 )
 
 func TestHandler_SignUp(t *testing.T) {
@@ -62,8 +63,8 @@ func TestHandler_SignUp(t *testing.T) {
 			},
 			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
 			},
-			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"You must enter a username"}` + "\n",
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"username","rule":"required","message":"username is required"}]}` + "\n",
 		},
 		{
 			name:      "Wrong Input Name",
@@ -71,16 +72,47 @@ func TestHandler_SignUp(t *testing.T) {
 			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
 				//r.EXPECT().CreateUser(user).Return(0, errors.New("invalid input body"))
 			},
-			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"You must enter a name"}` + "\n",
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"name","rule":"required","message":"name is required"}]}` + "\n",
 		},
 		{
 			name:      "Wrong Input Password",
 			inputBody: `{"username": "username", "name": "Test Name"}`,
 			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
 			},
-			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"Password must be at least 6 symbols"}` + "\n",
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"password","rule":"required","message":"password is required"}]}` + "\n",
+		},
+		{
+			name:      "ok with email",
+			inputBody: `{"name": "Test","username":"test username","password":"password","email":"test@example.com"}`,
+			inputUser: models.User{
+				Name:     "Test",
+				Username: "test username",
+				Password: "password",
+				Email:    "test@example.com",
+			},
+			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
+				s.EXPECT().CreateUser(user).Return(1, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}` + "\n",
+		},
+		{
+			name:      "Invalid email",
+			inputBody: `{"name": "Test","username":"test username","password":"password","email":"test"}`,
+			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"email","rule":"email","message":"email must be a valid email"}]}` + "\n",
+		},
+		{
+			name:      "Email is taken",
+			inputBody: `{"name": "Test","username":"test username","password":"password","email":"taken@example.com"}`,
+			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"email is already used by another account"}` + "\n",
 		},
 		{
 			name:      "Service Error",
@@ -109,11 +141,17 @@ func TestHandler_SignUp(t *testing.T) {
 			auth := mockService.NewMockAuthorization(c)
 			testCase.mockBehavior(auth, testCase.inputUser)
 
-			services := &service.Service{Authorization: auth}
+			account := mockService.NewMockAccount(c)
+			account.EXPECT().CheckEmail("test@example.com").Return(nil).AnyTimes()
+			account.EXPECT().CheckEmail("taken@example.com").Return(service.ErrEmailTaken).AnyTimes()
+			account.EXPECT().SendVerification(1).Return(nil).AnyTimes()
+
+			services := &service.Service{Authorization: auth, Account: account}
 			handler := NewHandler(services)
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodPost, "/sign-up",
@@ -132,6 +170,9 @@ func TestHandler_SignUp(t *testing.T) {
 
 }
 
+// testClient is the client info of requests made by httptest.NewRequest.
+var testClient = service.ClientInfo{IP: "192.0.2.1"}
+
 func TestHandler_SignIn(t *testing.T) {
 	type mockBehavior func(s *mockService.MockAuthorization, user SignInInput)
 
@@ -141,6 +182,7 @@ func TestHandler_SignIn(t *testing.T) {
 		inputUser            SignInInput
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		expectedRetryAfter   string
 		expectedResponseBody string
 	}{
 		{
@@ -151,11 +193,14 @@ func TestHandler_SignIn(t *testing.T) {
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
-				s.EXPECT().CheckUser(user.Username).Return(nil)
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("token", nil)
+				s.EXPECT().GenerateToken(user.Username, user.Password, testClient).Return(service.Tokens{
+					AccessToken:  "token",
+					RefreshToken: "refresh",
+					ExpiresIn:    900,
+				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"token":"token"}` + "\n",
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh","expires_in":900}` + "\n",
 		},
 		{
 			name:      "Error request data",
@@ -170,31 +215,61 @@ func TestHandler_SignIn(t *testing.T) {
 			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
 		},
 		{
-			name:      "Incorrect username",
+			name:      "Two-factor required",
 			inputBody: `{"username":"test username","password":"password"}`,
 			inputUser: SignInInput{
 				Username: "test username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
-				s.EXPECT().CheckUser(user.Username).Return(errors.New("user not found"))
+				s.EXPECT().GenerateToken(user.Username, user.Password, testClient).Return(service.Tokens{
+					ChallengeToken: "challenge",
+					ExpiresIn:      300,
+				}, nil)
 			},
-			expectedStatusCode:   404,
-			expectedResponseBody: `{"message":"user not found"}` + "\n",
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"two_factor_required":true,"challenge_token":"challenge","expires_in":300}` + "\n",
 		},
 		{
-			name:      "incorrect password",
+			name:      "Incorrect username or password",
 			inputBody: `{"username":"test username","password":"password"}`,
 			inputUser: SignInInput{
 				Username: "test username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
-				s.EXPECT().CheckUser(user.Username).Return(nil)
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("", errors.New("incorrect password"))
+				s.EXPECT().GenerateToken(user.Username, user.Password, testClient).Return(service.Tokens{}, service.ErrInvalidCredentials)
 			},
-			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect password"}` + "\n",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid username or password"}` + "\n",
+		},
+		{
+			name:      "Too many attempts",
+			inputBody: `{"username":"test username","password":"password"}`,
+			inputUser: SignInInput{
+				Username: "test username",
+				Password: "password",
+			},
+			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
+				s.EXPECT().GenerateToken(user.Username, user.Password, testClient).Return(service.Tokens{},
+					&service.TooManyAttemptsError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:   429,
+			expectedRetryAfter:   "2",
+			expectedResponseBody: `{"message":"too many sign-in attempts"}` + "\n",
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"username":"test username","password":"password"}`,
+			inputUser: SignInInput{
+				Username: "test username",
+				Password: "password",
+			},
+			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
+				s.EXPECT().GenerateToken(user.Username, user.Password, testClient).Return(service.Tokens{}, errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
 		},
 	}
 
@@ -214,6 +289,7 @@ func TestHandler_SignIn(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodPost, "/sign-in",
@@ -225,9 +301,153 @@ func TestHandler_SignIn(t *testing.T) {
 			//Проверка результатов
 			if assert.NoError(t, handler.SignIn(ctx)) {
 				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedRetryAfter, rec.Header().Get("Retry-After"))
 				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 			}
 		})
 	}
 
 }
+
+func TestHandler_Refresh(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization, refreshToken string)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		refreshToken         string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "ok",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mockService.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(refreshToken).Return(service.Tokens{
+					AccessToken:  "token",
+					RefreshToken: "new refresh",
+					ExpiresIn:    900,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"new refresh","expires_in":900}` + "\n",
+		},
+		{
+			name:      "Empty token",
+			inputBody: `{}`,
+			mockBehavior: func(s *mockService.MockAuthorization, refreshToken string) {
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"refresh_token","rule":"required","message":"refresh_token is required"}]}` + "\n",
+		},
+		{
+			name:         "Reused token",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mockService.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(refreshToken).Return(service.Tokens{}, service.ErrRefreshTokenReused)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid refresh token"}` + "\n",
+		},
+		{
+			name:         "Service Error",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mockService.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(refreshToken).Return(service.Tokens{}, errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, testCase.refreshToken)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.Refresh(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Logout(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization, claims *service.TokenClaims)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"refresh_token":"refresh"}`,
+			mockBehavior: func(s *mockService.MockAuthorization, claims *service.TokenClaims) {
+				s.EXPECT().Logout(claims, "refresh").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"logged out"}` + "\n",
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{}`,
+			mockBehavior: func(s *mockService.MockAuthorization, claims *service.TokenClaims) {
+				s.EXPECT().Logout(claims, "").Return(errors.New("something went wrong"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			claims := &service.TokenClaims{UserId: 1}
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, claims)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/logout",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(claimsCtx, claims)
+
+			if assert.NoError(t, handler.Logout(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
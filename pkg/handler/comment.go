@@ -2,20 +2,43 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"test/pkg/repository/models"
+	"test/pkg/service"
 )
 
 // GetComments godoc
 // @Summary     Find all comments
-// @Description Get all comments
+// @Description Get all comments with the counts of their reactions and the reactions of the viewer.
+// @Description A flat list has the comments at any depth with their depth and path, a tree nests the replies
+// @Description under the listed comments; load more replies of a comment with parent and its replies_cursor.
+// @Description Deleted comments that still have replies are shown as "[deleted]".
 // @Tags        comments
 // @Produce     json
 // @Param       postId  path     int true "Post ID"
+// @Param       view         query    string false "flat or tree" default(flat)
+// @Param       parent       query    int    false "List the replies under this comment"
+// @Param       depth        query    int    false "Levels of replies nested in a tree" default(2)
+// @Param       replies      query    int    false "Replies nested under a comment in a tree" default(3)
+// @Param       author       query    int    false "Author ID"
+// @Param       created_from query    string false "Created at or after, RFC 3339 time or date"
+// @Param       created_to   query    string false "Created before, RFC 3339 time or date (inclusive)"
+// @Param       body         query    string false "Body contains"
+// @Param       format       query    string false "Format of the bodies: markdown or html" default(markdown)
+// @Param       sort         query    string false "Sort fields: created_at, id; prefix - for descending" default(created_at)
+// @Param       limit        query    int    false "Page size"
+// @Param       cursor       query    string false "Cursor of the page, next_cursor of the previous one"
 // @Success     200 {object} GetCommentsResponse
 // @Failure 	400 {object} ErrorResponse	 "postId is not integer"
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	400 {object} ErrorResponse	 "invalid sort"
+// @Failure 	400 {object} ErrorResponse	 "format must be html or markdown"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	404 {object} ErrorResponse	 "comment not found"
+// @Failure 	422 {object} ValidationErrorResponse "validation failed"
 // @Failure 	500 {object} ErrorResponse	 "something went wrong"
 // @Router      /api/posts/{postId}/comments [get]
 
@@ -25,17 +48,46 @@ func (h *Handler) GetComments(c echo.Context) error {
 		return errParams
 	}
 
-	comments, err := h.services.Comment.Get(postId)
+	filter, errFilter := GetCommentFilter(c)
+	if errFilter != nil {
+		return nil
+	}
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+	format, errFormat := GetFormat(c)
+	if errFormat != nil {
+		return nil
+	}
+	var input CommentThreadRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	thread := service.ThreadRequest{Parent: input.Parent, Tree: input.View == "tree", Depth: input.Depth, Replies: input.Replies}
+
+	comments, next, err := h.services.Comment.Get(GetViewerId(c), postId, thread, filter, page)
+	if writeCommentError(c, err) {
+		return nil
+	}
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	reactions, err := h.services.Reaction.GetReactionSummaries(GetViewerId(c), models.ReactionTargetComment, threadIds(comments, nil))
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
 		return nil
 	}
+	setCommentReactions(comments, reactions)
+	h.services.Content.PrepareComments(comments, format)
 	_, errEnCd := json.Marshal(comments)
 	if errEnCd != nil {
 		return errEnCd
 	}
 	errRes := c.JSON(http.StatusOK, GetCommentsResponse{
-		Comments: comments,
+		Comments:   comments,
+		NextCursor: next,
 	})
 	if errRes != nil {
 		return errRes
@@ -49,12 +101,16 @@ func (h *Handler) GetComments(c echo.Context) error {
 // @Tags         comments
 // @Accept       json
 // @Produce      json
-// @Param        post	body     CommentRequest   true  "Add comment"
+// @Param        post	body     CommentRequest   true  "Add comment, parent_id to reply to a comment"
 // @Success      200 	{object} IdResponse		 "result is id of comment"
 // @Failure 	 400 	{object} ErrorResponse	 "postId not integer"
 // @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
 // @Failure 	 400 	{object} ErrorResponse	 "user id is of valid type"
+// @Failure 	 400 	{object} ErrorResponse	 "replies are nested too deep"
 // @Failure 	 404 	{object} ErrorResponse	 "user id not found"
+// @Failure 	 404 	{object} ErrorResponse	 "post not found"
+// @Failure 	 404 	{object} ErrorResponse	 "parent comment not found"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
 // @Failure 	 500 	{object} ErrorResponse	 "server error"
 // @Router       /api/posts/{postId}/comments [post]
 
@@ -69,16 +125,20 @@ func (h *Handler) PostComment(c echo.Context) error {
 		return nil
 	}
 
-	var comment models.Comment
-	errReq := GetRequest(c, &comment)
+	var input CommentRequest
+	errReq := GetRequest(c, &input)
 	if errReq != nil {
-		return errReq
+		return nil
 	}
+	comment := models.Comment{Body: input.Body, ParentId: input.ParentId}
 
 	comment.UserId = userId
 	comment.PostId = postId
 
 	id, err := h.services.Comment.Create(comment)
+	if writeCommentError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "server error")
 		return nil
@@ -105,10 +165,18 @@ func (h *Handler) PostComment(c echo.Context) error {
 // @Failure 	400 {object} ErrorResponse	 "incorrect request data"
 // @Failure 	400 {object} ErrorResponse	 "id is not integer"
 // @Failure 	400 {object} ErrorResponse	 "postId is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to update comment #"
+// @Failure 	404 {object} ErrorResponse	 "comment not found"
+// @Failure 	422 {object} ValidationErrorResponse "validation failed"
 // @Failure 	500 {object} ErrorResponse	 "server error"
 // @Router       /api/posts/{postId}/comments/{id} [put]
 
 func (h *Handler) UpdateComment(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
 	id, errParamId := GetParam(c, ParamId)
 	if errParamId != nil {
 		return errParamId
@@ -119,13 +187,17 @@ func (h *Handler) UpdateComment(c echo.Context) error {
 		return errParams
 	}
 
-	var comment models.Comment
-	errReq := GetRequest(c, &comment)
+	var input CommentRequest
+	errReq := GetRequest(c, &input)
 	if errReq != nil {
-		return errReq
+		return nil
 	}
+	comment := models.Comment{Body: input.Body}
 
-	err := h.services.Comment.Update(postId, id, comment)
+	err := h.services.Comment.Update(principal, postId, id, comment)
+	if writeCommentError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "server error")
 		return nil
@@ -150,10 +222,17 @@ func (h *Handler) UpdateComment(c echo.Context) error {
 // @Success     200 {object}  MessageResponse	"comment with id # deleted"
 // @Failure 	400 {object} ErrorResponse	 "id is not integer"
 // @Failure 	400 {object} ErrorResponse	 "postId is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to delete comment #"
+// @Failure 	404 {object} ErrorResponse	 "comment not found"
 // @Failure 	500 {object} ErrorResponse	 "server error"
 // @Router       /api/posts/{postId}/comments/{id} [delete]
 
 func (h *Handler) DeleteComment(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
 	id, errParamId := GetParam(c, ParamId)
 	if errParamId != nil {
 		return errParamId
@@ -164,7 +243,10 @@ func (h *Handler) DeleteComment(c echo.Context) error {
 		return errParams
 	}
 
-	err := h.services.Comment.Delete(postId, id)
+	err := h.services.Comment.Delete(principal, postId, id)
+	if writeCommentError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "server error")
 		return nil
@@ -177,3 +259,84 @@ func (h *Handler) DeleteComment(c echo.Context) error {
 	}
 	return nil
 }
+
+// RestoreComment godoc
+// @Summary      Restore a deleted comment
+// @Description  Move a comment of the user back from the trash, admins may restore any comment
+// @Tags         comments
+// @Produce      json
+// @Param       id  path     int true "Comment ID"
+// @Param       postId  path     int true "Post ID"
+// @Success     200 {object}  MessageResponse	"Comment with id # restored"
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	400 {object} ErrorResponse	 "postId is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to restore comment #"
+// @Failure 	404 {object} ErrorResponse	 "comment not found"
+// @Failure 	500 {object} ErrorResponse	 "server error"
+// @Router       /api/posts/{postId}/comments/{id}/restore [post]
+func (h *Handler) RestoreComment(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParamId := GetParam(c, ParamId)
+	if errParamId != nil {
+		return nil
+	}
+
+	postId, errParams := GetParam(c, ParamPostId)
+	if errParams != nil {
+		return nil
+	}
+
+	err := h.services.Comment.Restore(principal, postId, id)
+	if writeCommentError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("Comment with id %d restored", id),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// writeCommentError writes the response for ownership and lookup errors
+// of the comment service and reports whether it did.
+func writeCommentError(c echo.Context, err error) bool {
+	var forbidden *service.ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		NewErrorResponse(c, http.StatusForbidden, forbidden.Error())
+	case errors.Is(err, service.ErrCommentNotFound), errors.Is(err, service.ErrParentNotFound),
+		errors.Is(err, service.ErrPostNotFound):
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrReplyTooDeep):
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// threadIds appends the ids of the comments and of the replies nested under them.
+func threadIds(comments []models.Comment, ids []int) []int {
+	for _, comment := range comments {
+		ids = threadIds(comment.Replies, append(ids, comment.Id))
+	}
+	return ids
+}
+
+func setCommentReactions(comments []models.Comment, reactions map[int]models.ReactionSummary) {
+	for i := range comments {
+		summary := reactions[comments[i].Id]
+		comments[i].Reactions = &summary
+		setCommentReactions(comments[i].Replies, reactions)
+	}
+}
@@ -5,21 +5,26 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
 	"test/pkg/repository/models"
 	"test/pkg/service"
 	mockService "test/pkg/service/mocks"
 	"testing"
+	"time"
 )
 
 func TestHandler_GetComments(t *testing.T) {
-	type mockBehavior func(s *mockService.MockComment, postId int)
+	type mockBehavior func(s *mockService.MockComment, r *mockService.MockReaction, postId int)
 
 	testTable := []struct {
 		name                 string
 		paramId              int
+		query                string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
@@ -27,7 +32,7 @@ func TestHandler_GetComments(t *testing.T) {
 		{
 			name:    "ok",
 			paramId: 51,
-			mockBehavior: func(s *mockService.MockComment, postId int) {
+			mockBehavior: func(s *mockService.MockComment, r *mockService.MockReaction, postId int) {
 				ret := []models.Comment{
 					{
 						Id:     1,
@@ -42,16 +47,77 @@ func TestHandler_GetComments(t *testing.T) {
 						Body:   "anons2",
 					},
 				}
-				s.EXPECT().Get(postId).Return(ret, nil)
+				s.EXPECT().Get(0, postId, service.ThreadRequest{}, service.CommentFilter{}, service.PageRequest{}).Return(ret, "", nil)
+				r.EXPECT().GetReactionSummaries(0, models.ReactionTargetComment, []int{1, 2}).Return(map[int]models.ReactionSummary{
+					1: {Counts: map[string]int{"like": 2}, Mine: []string{}},
+					2: {Counts: map[string]int{}, Mine: []string{}},
+				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"comments":[{"id":1,"post_id":51,"user_id":20,"body":"anons1"},{"id":2,"post_id":51,"user_id":31,"body":"anons2"}]}` + "\n",
+			expectedResponseBody: `{"comments":[{"id":1,"post_id":51,"parent_id":0,"user_id":20,"body":"anons1","depth":0,"path":"","reactions":{"counts":{"like":2},"mine":[]},"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null},{"id":2,"post_id":51,"parent_id":0,"user_id":31,"body":"anons2","depth":0,"path":"","reactions":{"counts":{},"mine":[]},"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}]}` + "\n",
+		},
+		{
+			name:    "Tree",
+			paramId: 51,
+			query:   "?view=tree&depth=1&replies=1",
+			mockBehavior: func(s *mockService.MockComment, r *mockService.MockReaction, postId int) {
+				ret := []models.Comment{
+					{
+						Id:            1,
+						PostId:        51,
+						Body:          models.DeletedCommentBody,
+						ReplyCount:    2,
+						RepliesCursor: "next",
+						Replies:       []models.Comment{{Id: 3, PostId: 51, ParentId: 1, UserId: 20, Body: "reply", Depth: 1, Path: "1/"}},
+						DeletedAt:     gorm.DeletedAt{Time: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					},
+				}
+				s.EXPECT().Get(0, postId, service.ThreadRequest{Tree: true, Depth: 1, Replies: 1}, service.CommentFilter{}, service.PageRequest{}).
+					Return(ret, "", nil)
+				r.EXPECT().GetReactionSummaries(0, models.ReactionTargetComment, []int{1, 3}).Return(map[int]models.ReactionSummary{
+					1: {Counts: map[string]int{}, Mine: []string{}},
+					3: {Counts: map[string]int{"like": 1}, Mine: []string{}},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"comments":[{"id":1,"post_id":51,"parent_id":0,"user_id":0,"body":"[deleted]","depth":0,"path":"","reactions":{"counts":{},"mine":[]},` +
+				`"reply_count":2,"replies":[{"id":3,"post_id":51,"parent_id":1,"user_id":20,"body":"reply","depth":1,"path":"1/","reactions":{"counts":{"like":1},"mine":[]},` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}],"replies_cursor":"next",` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":"2022-03-01T00:00:00Z"}]}` + "\n",
+		},
+		{
+			name:    "Parent not found",
+			paramId: 51,
+			query:   "?parent=9",
+			mockBehavior: func(s *mockService.MockComment, r *mockService.MockReaction, postId int) {
+				s.EXPECT().Get(0, postId, service.ThreadRequest{Parent: 9}, service.CommentFilter{}, service.PageRequest{}).
+					Return(nil, "", service.ErrCommentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found"}` + "\n",
+		},
+		{
+			name:                 "Unknown view",
+			paramId:              51,
+			query:                "?view=graph",
+			mockBehavior:         func(s *mockService.MockComment, r *mockService.MockReaction, postId int) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"view","rule":"oneof","param":"flat tree","message":"view must be one of: flat tree"}]}` + "\n",
+		},
+		{
+			name:    "Post not found",
+			paramId: 51,
+			mockBehavior: func(s *mockService.MockComment, r *mockService.MockReaction, postId int) {
+				s.EXPECT().Get(0, postId, service.ThreadRequest{}, service.CommentFilter{}, service.PageRequest{}).Return(nil, "", service.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}` + "\n",
 		},
 		{
 			name:    "Server error",
 			paramId: 51,
-			mockBehavior: func(s *mockService.MockComment, postId int) {
-				s.EXPECT().Get(postId).Return([]models.Comment{}, errors.New("something went wrong"))
+			mockBehavior: func(s *mockService.MockComment, r *mockService.MockReaction, postId int) {
+				s.EXPECT().Get(0, postId, service.ThreadRequest{}, service.CommentFilter{}, service.PageRequest{}).Return(nil, "", errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
@@ -67,16 +133,18 @@ func TestHandler_GetComments(t *testing.T) {
 			defer c.Finish()
 
 			comment := mockService.NewMockComment(c)
-			testCase.mockBehavior(comment, testCase.paramId)
+			reaction := mockService.NewMockReaction(c)
+			testCase.mockBehavior(comment, reaction, testCase.paramId)
 
-			services := &service.Service{Comment: comment}
+			services := &service.Service{Comment: comment, Reaction: reaction, Content: service.NewContentService()}
 			handler := NewHandler(services)
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
-			req := httptest.NewRequest(http.MethodGet, "/api/posts/:postId/comments", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/posts/:postId/comments"+testCase.query, nil)
 			//req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
@@ -133,6 +201,43 @@ func TestHandler_PostComment(t *testing.T) {
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}` + "\n",
 		},
+		{
+			name:      "Reply to a missing comment",
+			inputBody: `{"body":"test body","parent_id":8}`,
+			inputComment: models.Comment{
+				UserId:   3,
+				PostId:   3,
+				ParentId: 8,
+				Body:     "test body",
+			},
+			mockBehavior: func(s *mockService.MockComment, comment models.Comment) {
+				s.EXPECT().Create(comment).Return(0, service.ErrParentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"parent comment not found"}` + "\n",
+		},
+		{
+			name:      "Reply too deep",
+			inputBody: `{"body":"test body","parent_id":8}`,
+			inputComment: models.Comment{
+				UserId:   3,
+				PostId:   3,
+				ParentId: 8,
+				Body:     "test body",
+			},
+			mockBehavior: func(s *mockService.MockComment, comment models.Comment) {
+				s.EXPECT().Create(comment).Return(0, service.ErrReplyTooDeep)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"replies are nested too deep"}` + "\n",
+		},
+		{
+			name:                 "Empty body",
+			inputBody:            `{"body":""}`,
+			mockBehavior:         func(s *mockService.MockComment, comment models.Comment) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"body","rule":"required","message":"body is required"}]}` + "\n",
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -151,6 +256,7 @@ func TestHandler_PostComment(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodPost, "/api/posts/:postId/comments",
@@ -195,7 +301,7 @@ func TestHandler_UpdateComment(t *testing.T) {
 				Body: "test body",
 			},
 			mockBehavior: func(s *mockService.MockComment, postId int, id int, comment models.Comment) {
-				s.EXPECT().Update(postId, id, comment).Return(nil)
+				s.EXPECT().Update(testPrincipal, postId, id, comment).Return(nil)
 			},
 			expectedStatusCode:   202,
 			expectedResponseBody: `{"message":"Comment with id 4 updated."}` + "\n",
@@ -209,7 +315,7 @@ func TestHandler_UpdateComment(t *testing.T) {
 				Body: "test body",
 			},
 			mockBehavior: func(s *mockService.MockComment, postId int, id int, comment models.Comment) {
-				s.EXPECT().Update(postId, id, comment).Return(errors.New("server error"))
+				s.EXPECT().Update(testPrincipal, postId, id, comment).Return(errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}` + "\n",
@@ -232,6 +338,7 @@ func TestHandler_UpdateComment(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodPut, "/api/posts/:postId/comments/:id",
@@ -240,6 +347,7 @@ func TestHandler_UpdateComment(t *testing.T) {
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 			ctx.Set(userCtx, 3)
+			ctx.Set(principalCtx, testPrincipal)
 			ctx.SetPath("/api/posts/:postId/comments/:id")
 			ctx.SetParamNames("postId", "id")
 			ctx.SetParamValues("3", "4")
@@ -270,7 +378,7 @@ func TestHandler_DeleteComment(t *testing.T) {
 			postId:    3,
 			commentId: 4,
 			mockBehavior: func(s *mockService.MockComment, postId int, id int) {
-				s.EXPECT().Delete(postId, id).Return(nil)
+				s.EXPECT().Delete(testPrincipal, postId, id).Return(nil)
 			},
 			expectedStatusCode:   202,
 			expectedResponseBody: `{"message":"Comment with id 4 deleted."}` + "\n",
@@ -281,7 +389,7 @@ func TestHandler_DeleteComment(t *testing.T) {
 			commentId: 4,
 
 			mockBehavior: func(s *mockService.MockComment, postId int, id int) {
-				s.EXPECT().Delete(postId, id).Return(errors.New("server error"))
+				s.EXPECT().Delete(testPrincipal, postId, id).Return(errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}` + "\n",
@@ -304,12 +412,14 @@ func TestHandler_DeleteComment(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodDelete, "/api/posts/:postId/comments/:id", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testPrincipal)
 			ctx.SetPath("/api/posts/:postId/comments/:id")
 			ctx.SetParamNames("postId", "id")
 			ctx.SetParamValues("3", "4")
@@ -323,3 +433,114 @@ func TestHandler_DeleteComment(t *testing.T) {
 	}
 
 }
+
+func TestHandler_CommentOwnership(t *testing.T) {
+	type mockBehavior func(r *mockRepository.MockComment)
+
+	owner := service.Principal{Id: 20, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+	other := service.Principal{Id: 21, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+	moderator := service.Principal{Id: 22, Roles: []string{service.RoleModerator}, Scopes: []string{service.ScopeAll}}
+	stored := models.Comment{Id: 4, PostId: 3, UserId: 20, Body: "body"}
+
+	testTable := []struct {
+		name                 string
+		method               string
+		principal            service.Principal
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Owner updates",
+			method:    http.MethodPut,
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockComment) {
+				r.EXPECT().GetById(3, 4).Return(stored, nil)
+				r.EXPECT().Update(3, 4, models.Comment{Body: "new body"}).Return(nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"message":"Comment with id 4 updated."}` + "\n",
+		},
+		{
+			name:      "Other user updates",
+			method:    http.MethodPut,
+			principal: other,
+			mockBehavior: func(r *mockRepository.MockComment) {
+				r.EXPECT().GetById(3, 4).Return(stored, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to update comment 4"}` + "\n",
+		},
+		{
+			name:      "Other user deletes",
+			method:    http.MethodDelete,
+			principal: other,
+			mockBehavior: func(r *mockRepository.MockComment) {
+				r.EXPECT().GetById(3, 4).Return(stored, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to delete comment 4"}` + "\n",
+		},
+		{
+			name:      "Moderator deletes",
+			method:    http.MethodDelete,
+			principal: moderator,
+			mockBehavior: func(r *mockRepository.MockComment) {
+				r.EXPECT().GetById(3, 4).Return(stored, nil)
+				r.EXPECT().Delete(3, 4, 22).Return(nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"message":"Comment with id 4 deleted."}` + "\n",
+		},
+		{
+			name:      "Not found",
+			method:    http.MethodPut,
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockComment) {
+				r.EXPECT().GetById(3, 4).Return(models.Comment{}, gorm.ErrRecordNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repos := mockRepository.NewMockComment(c)
+			testCase.mockBehavior(repos)
+
+			posts := mockRepository.NewMockPost(c)
+			posts.EXPECT().GetById(3).Return(models.Post{Id: 3, Status: models.PostStatusPublished}, nil).AnyTimes()
+
+			services := &service.Service{Comment: service.NewCommentService(repos, posts, repository.NewMemorySearchIndex())}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+
+			req := httptest.NewRequest(testCase.method, "/api/posts/:postId/comments/:id",
+				strings.NewReader(`{"body":"new body"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testCase.principal)
+			ctx.SetPath("/api/posts/:postId/comments/:id")
+			ctx.SetParamNames("postId", "id")
+			ctx.SetParamValues("3", "4")
+
+			var err error
+			if testCase.method == http.MethodPut {
+				err = handler.UpdateComment(ctx)
+			} else {
+				err = handler.DeleteComment(ctx)
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,51 +1,183 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/labstack/echo/v4"
 	_ "github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"net"
+	"net/http"
+	"strings"
 	"test/pkg/service"
 )
 
 type Handler struct {
-	services *service.Service
+	services    *service.Service
+	oauth       *OAuthRegistry
+	ipExtractor echo.IPExtractor
 }
 
 func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+	oauth, _ := NewOAuthRegistry([]byte(randomString(32)), http.DefaultClient)
+	return &Handler{services: services, oauth: oauth, ipExtractor: echo.ExtractIPDirect()}
+}
+
+// UseOAuthProviders replaces the registry of external login providers.
+func (h *Handler) UseOAuthProviders(registry *OAuthRegistry) {
+	h.oauth = registry
+}
+
+// UseIPExtractor replaces how the address of the client is found, the address of the connection by default.
+func (h *Handler) UseIPExtractor(extractor echo.IPExtractor) {
+	h.ipExtractor = extractor
+}
+
+// NewIPExtractor reads the client address from X-Forwarded-For when the request comes through one of
+// the trusted proxies, a comma separated list of addresses and CIDR ranges. Without proxies the address
+// of the connection is used, the header could be set by anyone.
+func NewIPExtractor(trustedProxies string) (echo.IPExtractor, error) {
+	var ranges []echo.TrustOption
+	for _, proxy := range strings.Split(trustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * len(ip)
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			ranges = append(ranges, echo.TrustIPRange(&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}))
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		ranges = append(ranges, echo.TrustIPRange(ipRange))
+	}
+	if len(ranges) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	// only the listed proxies are trusted, not every private address as by default
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	return echo.ExtractIPFromXFFHeader(append(options, ranges...)...), nil
 }
 
 func (h *Handler) InitRoutes() *echo.Echo {
 	router := echo.New()
+	router.Validator = NewValidator()
+	router.IPExtractor = h.ipExtractor
 	router.GET("/swagger/server/*", echoSwagger.WrapHandler)
 
 	auth := router.Group("/auth")
 	{
 		auth.POST("/sign-up", h.SignUp)
 		auth.POST("/sign-in", h.SignIn)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/logout", h.Logout, h.userIdentify)
+		auth.GET("/verify-email", h.VerifyEmail)
+		auth.POST("/resend-verification", h.ResendVerification, h.userIdentify)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
+		auth.POST("/2fa/verify", h.VerifyTwoFactor)
 	}
 
-	google := router.Group("/google")
-	google.GET("/login", h.GoogleLogin)
-	google.GET("/callback", h.GoogleCallback)
+	twoFactor := auth.Group("/2fa", h.userIdentify, h.requirePermission(service.PermTwoFactorManage))
+	{
+		twoFactor.POST("/enroll", h.EnrollTwoFactor)
+		twoFactor.POST("/confirm", h.ConfirmTwoFactor)
+		twoFactor.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+		twoFactor.POST("/disable", h.DisableTwoFactor)
+	}
+
+	router.GET("/.well-known/jwks.json", h.JWKS)
+
+	oauth := router.Group("/oauth/:provider")
+	oauth.GET("/login", h.OAuthLogin)
+	oauth.GET("/callback", h.OAuthCallback)
 
 	api := router.Group("/api")
+	api.GET("/search", h.Search)
+	api.GET("/reactions", h.GetReactionKinds)
+
 	post := api.Group("/posts")
 	{
-		post.GET("", h.GetPosts)
-		post.GET("/user/:id", h.GetUserPosts)
-		post.GET("/:id", h.GetPostById)
-		post.POST("", h.PostPost, h.userIdentify)
-		post.PUT("/:id", h.UpdatePost, h.userIdentify)
-		post.DELETE("/:id", h.DeletePost, h.userIdentify)
+		post.GET("", h.GetPosts, h.optionalIdentify)
+		post.GET("/user/:id", h.GetUserPosts, h.optionalIdentify)
+		post.GET("/:id", h.GetPostById, h.optionalIdentify)
+		post.POST("", h.PostPost, h.userIdentify, h.requirePermission(service.PermPostCreate), h.requireVerifiedEmail)
+		post.PUT("/:id", h.UpdatePost, h.userIdentify, h.requirePermission(service.PermPostUpdate))
+		post.PUT("/:id/status", h.UpdatePostStatus, h.userIdentify, h.requirePermission(service.PermPostUpdate))
+		post.DELETE("/:id", h.DeletePost, h.userIdentify, h.requirePermission(service.PermPostDelete))
+		post.POST("/:id/restore", h.RestorePost, h.userIdentify, h.requirePermission(service.PermPostDelete))
+		post.GET("/:id/revisions", h.GetRevisions, h.userIdentify)
+		post.GET("/:id/revisions/diff", h.DiffRevisions, h.userIdentify)
+		post.GET("/:id/revisions/:number", h.GetRevision, h.userIdentify)
+		post.POST("/:id/revisions/:number/revert", h.RevertPost, h.userIdentify, h.requirePermission(service.PermPostUpdate))
+		post.GET("/:id/reactions", h.GetPostReactions, h.optionalIdentify)
+		post.POST("/:id/reactions", h.ReactToPost, h.userIdentify, h.requirePermission(service.PermReactionCreate))
+		post.DELETE("/:id/reactions/:kind", h.UnreactToPost, h.userIdentify, h.requirePermission(service.PermReactionCreate))
+		post.GET("/:id/media", h.GetPostMedia, h.optionalIdentify)
+		post.POST("/:id/media", h.UploadMedia, h.userIdentify, h.requirePermission(service.PermPostUpdate))
+		post.GET("/:id/media/:mediaId", h.ServeMedia, h.optionalIdentify)
+		post.DELETE("/:id/media/:mediaId", h.DeleteMedia, h.userIdentify, h.requirePermission(service.PermPostUpdate))
 	}
 
 	comment := post.Group("/:postId/comments", h.userIdentify)
 	{
 		comment.GET("", h.GetComments)
-		comment.POST("", h.PostComment)
-		comment.PUT("/:id", h.UpdateComment)
-		comment.DELETE("/:id", h.DeleteComment)
+		comment.POST("", h.PostComment, h.requirePermission(service.PermCommentCreate), h.requireVerifiedEmail)
+		comment.PUT("/:id", h.UpdateComment, h.requirePermission(service.PermCommentUpdate))
+		comment.DELETE("/:id", h.DeleteComment, h.requirePermission(service.PermCommentDelete))
+		comment.POST("/:id/restore", h.RestoreComment, h.requirePermission(service.PermCommentDelete))
+		comment.GET("/:id/reactions", h.GetCommentReactions)
+		comment.POST("/:id/reactions", h.ReactToComment, h.requirePermission(service.PermReactionCreate))
+		comment.DELETE("/:id/reactions/:kind", h.UnreactToComment, h.requirePermission(service.PermReactionCreate))
+	}
+
+	tags := api.Group("/tags")
+	{
+		tags.GET("", h.GetTags)
+		tags.GET("/suggest", h.SuggestTags)
+		tags.GET("/:name/posts", h.GetTagPosts, h.optionalIdentify)
+	}
+
+	moderation := api.Group("/moderation", h.userIdentify)
+	{
+		moderation.DELETE("/posts/:id", h.DeletePost, h.requirePermission(service.PermPostDeleteAny))
+		moderation.DELETE("/posts/:postId/comments/:id", h.DeleteComment, h.requirePermission(service.PermCommentDeleteAny))
+	}
+
+	keys := api.Group("/keys", h.userIdentify, h.requirePermission(service.PermApiKeyManage))
+	{
+		keys.POST("", h.CreateApiKey)
+		keys.GET("", h.GetApiKeys)
+		keys.DELETE("/:id", h.RevokeApiKey)
+	}
+
+	api.GET("/users/:id", h.GetUserProfile)
+
+	me := api.Group("/users/me", h.userIdentify)
+	{
+		me.GET("", h.GetMe)
+		me.PATCH("", h.UpdateMe, h.requirePermission(service.PermAccountUpdate))
+		me.POST("/password", h.ChangePassword, h.requirePermission(service.PermAccountUpdate))
+		me.DELETE("", h.DeleteMe, h.requirePermission(service.PermAccountDelete))
+		me.GET("/sessions", h.GetSessions, h.requirePermission(service.PermSessionManage))
+		me.DELETE("/sessions", h.RevokeOtherSessions, h.requirePermission(service.PermSessionManage))
+		me.DELETE("/sessions/:sessionId", h.RevokeSession, h.requirePermission(service.PermSessionManage))
+	}
+
+	admin := api.Group("/admin", h.userIdentify)
+	{
+		admin.PUT("/users/:id/role", h.UpdateUserRole, h.requirePermission(service.PermUserRoleUpdate))
+		admin.POST("/users/:id/unlock", h.UnlockUser, h.requirePermission(service.PermUserUnlock))
+		admin.POST("/keys/rotate", h.RotateSigningKey, h.requirePermission(service.PermKeyRotate))
+		admin.GET("/trash/posts", h.GetDeletedPosts, h.requirePermission(service.PermTrashRead))
+		admin.GET("/trash/comments", h.GetDeletedComments, h.requirePermission(service.PermTrashRead))
+		admin.PUT("/tags/:id", h.RenameTag, h.requirePermission(service.PermTagManage))
+		admin.POST("/tags/:id/merge", h.MergeTags, h.requirePermission(service.PermTagManage))
 	}
 	return router
 }
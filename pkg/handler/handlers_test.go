@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewIPExtractor(t *testing.T) {
+	testTable := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		forwardedFor   string
+		expectedIP     string
+	}{
+		{
+			name:         "No proxies",
+			remoteAddr:   "203.0.113.5:4000",
+			forwardedFor: "198.51.100.7",
+			expectedIP:   "203.0.113.5",
+		},
+		{
+			name:         "Private address is not a proxy",
+			remoteAddr:   "10.0.0.2:4000",
+			forwardedFor: "198.51.100.7",
+			expectedIP:   "10.0.0.2",
+		},
+		{
+			name:           "Trusted proxy",
+			trustedProxies: "10.0.0.0/24, 192.0.2.1",
+			remoteAddr:     "10.0.0.2:4000",
+			forwardedFor:   "198.51.100.7",
+			expectedIP:     "198.51.100.7",
+		},
+		{
+			name:           "Address forged before the proxy",
+			trustedProxies: "10.0.0.0/24",
+			remoteAddr:     "10.0.0.2:4000",
+			forwardedFor:   "192.0.2.9, 198.51.100.7",
+			expectedIP:     "198.51.100.7",
+		},
+		{
+			name:           "Untrusted proxy",
+			trustedProxies: "192.0.2.1",
+			remoteAddr:     "10.0.0.2:4000",
+			forwardedFor:   "198.51.100.7",
+			expectedIP:     "10.0.0.2",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			extractor, err := NewIPExtractor(testCase.trustedProxies)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/sign-in", nil)
+			req.RemoteAddr = testCase.remoteAddr
+			req.Header.Set("X-Forwarded-For", testCase.forwardedFor)
+			assert.Equal(t, testCase.expectedIP, extractor(req))
+		})
+	}
+
+	_, err := NewIPExtractor("10.0.0.0/33")
+	assert.Error(t, err)
+}
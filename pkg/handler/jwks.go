@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// JWKS godoc
+// @Summary      Public keys of access tokens
+// @Description  JSON Web Key Set to verify access tokens without the signing key
+// @Tags         auth
+// @Produce      json
+// @Success      200 	{object} service.JWKSet  "key set"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c echo.Context) error {
+	set, err := h.services.Authorization.JWKS()
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	// verifiers refetch the set when they see an unknown kid
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	errRes := c.JSON(http.StatusOK, set)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
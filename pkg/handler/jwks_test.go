@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_JWKS(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().JWKS().Return(service.JWKSet{Keys: []service.JWK{
+					{Kty: "RSA", Use: "sig", Alg: "RS256", Kid: "kid", N: "n", E: "AQAB"},
+				}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"keys":[{"kty":"RSA","use":"sig","alg":"RS256","kid":"kid","n":"n","e":"AQAB"}]}` + "\n",
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().JWKS().Return(service.JWKSet{}, errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.JWKS(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
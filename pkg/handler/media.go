@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"mime"
+	"net/http"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+)
+
+const (
+	mediaFormField = "file"
+	// multipartOverhead leaves room for the boundaries and the part headers of an upload
+	multipartOverhead = 64 << 10
+	// shared caches keep media briefly and check them again with the ETag,
+	// a media deleted or a post unpublished stops being served soon
+	publicMediaCache  = "public, max-age=300, must-revalidate"
+	privateMediaCache = "private, no-cache"
+)
+
+// GetPostMedia godoc
+// @Summary     List the media of a post
+// @Description Get the images and files attached to a post in the order they were uploaded
+// @Tags        media
+// @Produce     json
+// @Param       id  path     int true "Post ID"
+// @Success     200 {object} GetMediaResponse
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/posts/{id}/media [get]
+func (h *Handler) GetPostMedia(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	media, err := h.services.Media.GetMedia(GetViewerId(c), id)
+	if writeMediaError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, GetMediaResponse{Media: media})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// UploadMedia godoc
+// @Summary      Attach a file to a post
+// @Description  upload an image (jpeg, png, gif, webp) or a file (pdf, zip, text) to a post of the user;
+// @Description  the type is sniffed from the content, the size and the total of the user's uploads are limited
+// @Tags         media
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path     int   true  "Post ID"
+// @Param        file  formData file  true  "File"
+// @Success      200 	{object} models.Media
+// @Failure 	 400 	{object} ErrorResponse	 "file is required"
+// @Failure 	 403 	{object} ErrorResponse	 "user is not allowed to update post #"
+// @Failure 	 404 	{object} ErrorResponse	 "post not found"
+// @Failure 	 413 	{object} ErrorResponse	 "file is too large"
+// @Failure 	 413 	{object} ErrorResponse	 "upload quota exceeded"
+// @Failure 	 415 	{object} ErrorResponse	 "file type is not allowed"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/posts/{id}/media [post]
+func (h *Handler) UploadMedia(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.services.Media.MaxUploadSize()+multipartOverhead)
+	file, err := c.FormFile(mediaFormField)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		NewErrorResponse(c, http.StatusRequestEntityTooLarge, service.ErrMediaTooLarge.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "file is required")
+		return nil
+	}
+	content, err := file.Open()
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	defer content.Close()
+
+	media, err := h.services.Media.Upload(c.Request().Context(), principal, id, service.MediaUpload{Name: file.Filename, Size: file.Size, Content: content})
+	if writeMediaError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, media)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// ServeMedia godoc
+// @Summary     Download a media of a post
+// @Description Get the content of an image or a file; ranges and conditional requests are supported.
+// @Description Media of published posts may be cached for a few minutes, then revalidated by the ETag.
+// @Tags        media
+// @Produce     octet-stream
+// @Param       id      path     int true "Post ID"
+// @Param       mediaId path     int true "Media ID"
+// @Success     200 {file}   file
+// @Success     206 {file}   file "the requested range"
+// @Success     304 "not modified"
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	404 {object} ErrorResponse	 "media not found"
+// @Failure 	416 "range not satisfiable"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/posts/{id}/media/{mediaId} [get]
+func (h *Handler) ServeMedia(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	mediaId, errParams := GetParam(c, ParamMediaId)
+	if errParams != nil {
+		return nil
+	}
+
+	media, err := h.services.Media.OpenMedia(c.Request().Context(), GetViewerId(c), id, mediaId)
+	if writeMediaError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	defer media.Content.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, media.ContentType)
+	header.Set("ETag", `"`+media.Checksum+`"`)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if media.Public {
+		header.Set("Cache-Control", publicMediaCache)
+	} else {
+		header.Set("Cache-Control", privateMediaCache)
+	}
+	disposition := "attachment"
+	if media.Kind == models.MediaKindImage {
+		disposition = "inline"
+	}
+	header.Set(echo.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": media.Name}))
+	http.ServeContent(c.Response(), c.Request(), media.Name, media.CreatedAt, media.Content)
+	return nil
+}
+
+// DeleteMedia godoc
+// @Summary      Remove a media from a post
+// @Description  delete an image or a file of a post of the user together with its content
+// @Tags         media
+// @Produce      json
+// @Param        id      path     int  true  "Post ID"
+// @Param        mediaId path     int  true  "Media ID"
+// @Success      200 	{object} MessageResponse "media deleted"
+// @Failure 	 400 	{object} ErrorResponse	 "id is not integer"
+// @Failure 	 403 	{object} ErrorResponse	 "user is not allowed to update post #"
+// @Failure 	 404 	{object} ErrorResponse	 "post not found"
+// @Failure 	 404 	{object} ErrorResponse	 "media not found"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/posts/{id}/media/{mediaId} [delete]
+func (h *Handler) DeleteMedia(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	mediaId, errParams := GetParam(c, ParamMediaId)
+	if errParams != nil {
+		return nil
+	}
+
+	err := h.services.Media.DeleteMedia(c.Request().Context(), principal, id, mediaId)
+	if writeMediaError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "media deleted"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// writeMediaError writes the response for the errors of the media service
+// and reports whether it did.
+func writeMediaError(c echo.Context, err error) bool {
+	var forbidden *service.ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		NewErrorResponse(c, http.StatusForbidden, forbidden.Error())
+	case errors.Is(err, service.ErrPostNotFound), errors.Is(err, service.ErrMediaNotFound):
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrMediaTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+		NewErrorResponse(c, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, service.ErrMediaType):
+		NewErrorResponse(c, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+	"time"
+)
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func multipartBody(t *testing.T, field, name, content string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if field != "" {
+		part, err := writer.CreateFormFile(field, name)
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func TestHandler_UploadMedia(t *testing.T) {
+	type mockBehavior func(s *mockService.MockMedia)
+
+	testTable := []struct {
+		name                 string
+		field                string
+		content              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "Ok",
+			field:   "file",
+			content: "notes",
+			mockBehavior: func(s *mockService.MockMedia) {
+				s.EXPECT().Upload(gomock.Any(), testPrincipal, 1, gomock.Any()).DoAndReturn(
+					func(_ context.Context, actor service.Principal, postId int, upload service.MediaUpload) (models.Media, error) {
+						content, _ := io.ReadAll(upload.Content)
+						assert.Equal(t, "notes", string(content))
+						assert.Equal(t, "notes.txt", upload.Name)
+						assert.Equal(t, int64(5), upload.Size)
+						return models.Media{Id: 7, PostId: 1, UserId: 3, Name: "notes.txt", Kind: models.MediaKindFile,
+							ContentType: "text/plain; charset=utf-8", Size: 5, Checksum: "abc", Url: "/api/posts/1/media/7"}, nil
+					})
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":7,"post_id":1,"user_id":3,"name":"notes.txt","kind":"file","content_type":"text/plain; charset=utf-8",` +
+				`"size":5,"checksum":"abc","url":"/api/posts/1/media/7","created_at":"0001-01-01T00:00:00Z"}` + "\n",
+		},
+		{
+			name:                 "No file",
+			mockBehavior:         func(s *mockService.MockMedia) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"file is required"}` + "\n",
+		},
+		{
+			name:                 "Body too large",
+			field:                "file",
+			content:              strings.Repeat("a", multipartOverhead+100),
+			mockBehavior:         func(s *mockService.MockMedia) {},
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"message":"file is too large"}` + "\n",
+		},
+		{
+			name:    "Type not allowed",
+			field:   "file",
+			content: "<html></html>",
+			mockBehavior: func(s *mockService.MockMedia) {
+				s.EXPECT().Upload(gomock.Any(), testPrincipal, 1, gomock.Any()).Return(models.Media{}, service.ErrMediaType)
+			},
+			expectedStatusCode:   415,
+			expectedResponseBody: `{"message":"file type is not allowed"}` + "\n",
+		},
+		{
+			name:    "Quota exceeded",
+			field:   "file",
+			content: "notes",
+			mockBehavior: func(s *mockService.MockMedia) {
+				s.EXPECT().Upload(gomock.Any(), testPrincipal, 1, gomock.Any()).Return(models.Media{}, service.ErrQuotaExceeded)
+			},
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"message":"upload quota exceeded"}` + "\n",
+		},
+		{
+			name:    "Forbidden",
+			field:   "file",
+			content: "notes",
+			mockBehavior: func(s *mockService.MockMedia) {
+				s.EXPECT().Upload(gomock.Any(), testPrincipal, 1, gomock.Any()).
+					Return(models.Media{}, &service.ForbiddenError{Action: "update", Resource: "post", Id: 1})
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to update post 1"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			media := mockService.NewMockMedia(c)
+			media.EXPECT().MaxUploadSize().Return(int64(10))
+			testCase.mockBehavior(media)
+			handler := NewHandler(&service.Service{Media: media})
+
+			e := echo.New()
+			body, contentType := multipartBody(t, testCase.field, "notes.txt", testCase.content)
+			req := httptest.NewRequest(http.MethodPost, "/api/posts/1/media", body)
+			req.Header.Set(echo.HeaderContentType, contentType)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+			ctx.Set(principalCtx, testPrincipal)
+
+			if assert.NoError(t, handler.UploadMedia(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ServeMedia(t *testing.T) {
+	createdAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	content := func(public bool) service.MediaContent {
+		return service.MediaContent{
+			Media: models.Media{Id: 7, PostId: 1, Name: "report.pdf", Kind: models.MediaKindFile, ContentType: "application/pdf",
+				Size: 10, Checksum: "abc", CreatedAt: createdAt},
+			Content: nopSeekCloser{strings.NewReader("%PDF-1.7\n%")},
+			Public:  public,
+		}
+	}
+
+	testTable := []struct {
+		name                 string
+		header               http.Header
+		public               bool
+		err                  error
+		expectedStatusCode   int
+		expectedHeader       map[string]string
+		expectedResponseBody string
+	}{
+		{
+			name:               "Ok",
+			header:             http.Header{},
+			public:             true,
+			expectedStatusCode: 200,
+			expectedHeader: map[string]string{
+				"Content-Type":           "application/pdf",
+				"Content-Length":         "10",
+				"Content-Disposition":    `attachment; filename=report.pdf`,
+				"Cache-Control":          "public, max-age=300, must-revalidate",
+				"Etag":                   `"abc"`,
+				"Last-Modified":          "Tue, 01 Mar 2022 12:00:00 GMT",
+				"Accept-Ranges":          "bytes",
+				"X-Content-Type-Options": "nosniff",
+			},
+			expectedResponseBody: "%PDF-1.7\n%",
+		},
+		{
+			name:               "Range",
+			header:             http.Header{"Range": {"bytes=1-3"}},
+			public:             true,
+			expectedStatusCode: 206,
+			expectedHeader: map[string]string{
+				"Content-Range":  "bytes 1-3/10",
+				"Content-Length": "3",
+			},
+			expectedResponseBody: "PDF",
+		},
+		{
+			name:                 "Not modified",
+			header:               http.Header{"If-None-Match": {`"abc"`}},
+			public:               true,
+			expectedStatusCode:   304,
+			expectedResponseBody: "",
+		},
+		{
+			name:                 "Range not satisfiable",
+			header:               http.Header{"Range": {"bytes=20-30"}},
+			public:               true,
+			expectedStatusCode:   416,
+			expectedHeader:       map[string]string{"Content-Range": "bytes */10"},
+			expectedResponseBody: "invalid range: failed to overlap\n",
+		},
+		{
+			name:                 "Draft",
+			header:               http.Header{},
+			expectedStatusCode:   200,
+			expectedHeader:       map[string]string{"Cache-Control": "private, no-cache"},
+			expectedResponseBody: "%PDF-1.7\n%",
+		},
+		{
+			name:                 "Not found",
+			header:               http.Header{},
+			err:                  service.ErrMediaNotFound,
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"media not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			media := mockService.NewMockMedia(c)
+			if testCase.err != nil {
+				media.EXPECT().OpenMedia(gomock.Any(), 0, 1, 7).Return(service.MediaContent{}, testCase.err)
+			} else {
+				media.EXPECT().OpenMedia(gomock.Any(), 0, 1, 7).Return(content(testCase.public), nil)
+			}
+			handler := NewHandler(&service.Service{Media: media})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/posts/1/media/7", nil)
+			req.Header = testCase.header
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId, ParamMediaId)
+			ctx.SetParamValues("1", "7")
+
+			if assert.NoError(t, handler.ServeMedia(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				for name, value := range testCase.expectedHeader {
+					assert.Equal(t, value, rec.Header().Get(name), name)
+				}
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
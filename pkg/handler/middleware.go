@@ -4,19 +4,25 @@ import (
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 	"net/http"
 	"strconv"
 	"strings"
-	"test/pkg/repository/models"
+	"test/pkg/service"
+	"time"
 )
 
 const (
 	authorizationHeader = "Authorization"
 	userCtx             = "userId"
+	claimsCtx           = "tokenClaims"
+	principalCtx        = "principal"
 	ParamId             = "id"
 	ParamPostId         = "postId"
+	ParamSessionId      = "sessionId"
+	ParamNumber         = "number"
+	ParamName           = "name"
+	ParamKind           = "kind"
+	ParamMediaId        = "mediaId"
 )
 
 func (h *Handler) userIdentify(next echo.HandlerFunc) echo.HandlerFunc {
@@ -33,17 +39,116 @@ func (h *Handler) userIdentify(next echo.HandlerFunc) echo.HandlerFunc {
 			return nil
 		}
 
-		userId, err := h.services.Authorization.ParseToken(headerParts[1])
+		if strings.HasPrefix(headerParts[1], service.ApiKeyPrefix) {
+			return h.apiKeyIdentify(c, next, headerParts[1])
+		}
+
+		claims, err := h.services.Authorization.ParseToken(headerParts[1])
 
 		if err != nil {
 			NewErrorResponse(c, http.StatusUnauthorized, err.Error())
 			return nil
 		}
-		c.Set(userCtx, userId)
+
+		revoked, errRevoked := h.services.Authorization.IsTokenRevoked(claims)
+		if errRevoked != nil {
+			NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+			return nil
+		}
+		if revoked {
+			NewErrorResponse(c, http.StatusUnauthorized, "token is revoked")
+			return nil
+		}
+		c.Set(userCtx, claims.UserId)
+		c.Set(claimsCtx, claims)
+		c.Set(principalCtx, claims.Principal())
+		return next(c)
+	}
+}
+
+// apiKeyIdentify authenticates requests made with an API key instead of a JWT.
+// Such requests have no token claims in the context.
+func (h *Handler) apiKeyIdentify(c echo.Context, next echo.HandlerFunc, key string) error {
+	principal, err := h.services.ApiKey.AuthenticateApiKey(key)
+	if errors.Is(err, service.ErrInvalidApiKey) {
+		NewErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	c.Set(userCtx, principal.Id)
+	c.Set(principalCtx, principal)
+	return next(c)
+}
+
+// requirePermission rejects requests of principals that lack the permission.
+// It must run after userIdentify.
+func (h *Handler) requirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, errPrincipal := GetPrincipal(c)
+			if errPrincipal != nil {
+				return nil
+			}
+			if !principal.Can(permission) {
+				NewErrorResponse(c, http.StatusForbidden, "permission denied")
+				return nil
+			}
+			return next(c)
+		}
+	}
+}
+
+// requireVerifiedEmail rejects principals whose email is not verified
+// when the account service requires it. It must run after userIdentify.
+func (h *Handler) requireVerifiedEmail(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		principal, errPrincipal := GetPrincipal(c)
+		if errPrincipal != nil {
+			return nil
+		}
+		err := h.services.Account.CheckVerified(principal)
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			NewErrorResponse(c, http.StatusForbidden, err.Error())
+			return nil
+		}
+		if err != nil {
+			NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+			return nil
+		}
 		return next(c)
 	}
 }
 
+// optionalIdentify identifies the user like userIdentify when the request
+// has an Authorization header and lets anonymous requests through.
+func (h *Handler) optionalIdentify(next echo.HandlerFunc) echo.HandlerFunc {
+	identified := h.userIdentify(next)
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(authorizationHeader) == "" {
+			return next(c)
+		}
+		return identified(c)
+	}
+}
+
+// GetViewerId returns the id of the identified user, 0 for anonymous requests.
+func GetViewerId(c echo.Context) int {
+	principal, _ := c.Get(principalCtx).(service.Principal)
+	return principal.Id
+}
+
+func GetPrincipal(c echo.Context) (service.Principal, error) {
+	principal, ok := c.Get(principalCtx).(service.Principal)
+	if !ok {
+		NewErrorResponse(c, http.StatusUnauthorized, "principal not found")
+		return service.Principal{}, errors.New("principal not found")
+	}
+	return principal, nil
+}
+
 func GetUserId(c echo.Context) (int, error) {
 	id := c.Get(userCtx)
 	if id == 0 {
@@ -67,56 +172,117 @@ func GetParam(c echo.Context, name string) (int, error) {
 	return param, nil
 }
 
-func GetRequest(c echo.Context, i interface{}) error {
-	if err := c.Bind(&i); err != nil {
-		NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return err
+// GetPageRequest reads the limit, cursor and sort query parameters of a list endpoint.
+func GetPageRequest(c echo.Context) (service.PageRequest, error) {
+	page := service.PageRequest{Cursor: c.QueryParam("cursor"), Sort: c.QueryParam("sort")}
+	if raw := c.QueryParam("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			NewErrorResponse(c, http.StatusBadRequest, "limit must be a positive integer")
+			return page, errors.New("limit must be a positive integer")
+		}
+		page.Limit = limit
 	}
-	return nil
+	return page, nil
+}
+
+// GetFormat reads the format of the post bodies and the comments, Markdown by default.
+func GetFormat(c echo.Context) (string, error) {
+	switch format := c.QueryParam("format"); format {
+	case "":
+		return service.FormatMarkdown, nil
+	case service.FormatMarkdown, service.FormatHTML:
+		return format, nil
+	}
+	NewErrorResponse(c, http.StatusBadRequest, "format must be html or markdown")
+	return "", errors.New("format must be html or markdown")
+}
+
+// GetPostFilter reads the author, created_from, created_to, title, tag and status query parameters.
+// Posts that are not published are listed only to their author.
+func GetPostFilter(c echo.Context) (service.PostFilter, error) {
+	filter := service.PostFilter{ViewerId: GetViewerId(c)}
+	var err error
+	if filter.UserId, err = getQueryId(c, "author"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedFrom, filter.CreatedTo, err = getCreatedRange(c); err != nil {
+		return filter, err
+	}
+	filter.Title = c.QueryParam("title")
+	filter.Tag = c.QueryParam("tag")
+	filter.Status = c.QueryParam("status")
+	return filter, nil
 }
 
-func SetupConfig() *oauth2.Config {
-	googleOauthConfig := &oauth2.Config{
-		ClientID:     "64460222459-j6mfme4oj54o4vasuifrlcip3l5ohdkk.apps.googleusercontent.com",
-		ClientSecret: "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",
-		Endpoint:     google.Endpoint,
-		RedirectURL:  "http://localhost:8080/google/callback",
-		Scopes: []string{
-			"https://www.googleapis.com/auth/userinfo.email",   //See your primary Google Account email address
-			"https://www.googleapis.com/auth/userinfo.profile", //See your personal info, including any personal info you've made publicly available
-		},
+// GetCommentFilter reads the author, created_from, created_to and body query parameters.
+func GetCommentFilter(c echo.Context) (service.CommentFilter, error) {
+	var filter service.CommentFilter
+	var err error
+	if filter.UserId, err = getQueryId(c, "author"); err != nil {
+		return filter, err
 	}
-	return googleOauthConfig
+	if filter.CreatedFrom, filter.CreatedTo, err = getCreatedRange(c); err != nil {
+		return filter, err
+	}
+	filter.Body = c.QueryParam("body")
+	return filter, nil
 }
 
-func (h *Handler) GoogleSignUp(c echo.Context, input models.User) error {
-	id, err := h.services.Authorization.CreateUser(input)
+func getQueryId(c echo.Context, name string) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil || id <= 0 {
+		NewErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("%s must be a positive integer", name))
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return id, nil
+}
 
+// getCreatedRange reads created_from and created_to as RFC 3339 times or dates.
+// A date in created_to includes the whole day.
+func getCreatedRange(c echo.Context) (*time.Time, *time.Time, error) {
+	from, err := getQueryTime(c, "created_from", 0)
 	if err != nil {
-		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
-		return nil
+		return nil, nil, err
 	}
-	errRes := c.JSON(http.StatusOK, map[string]interface{}{
-		"id": id,
-	})
-	if errRes != nil {
-		return errRes
+	to, err := getQueryTime(c, "created_to", 24*time.Hour)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+	return from, to, nil
 }
 
-func (h *Handler) GoogleSignIn(c echo.Context, input models.User) error {
-	// if username is required, to login and to generate token
-	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
+func getQueryTime(c echo.Context, name string, dayEnd time.Duration) (*time.Time, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return nil, nil
+	}
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &at, nil
+	}
+	day, err := time.Parse("2006-01-02", raw)
 	if err != nil {
-		NewErrorResponse(c, http.StatusInternalServerError, err.Error())
-		return nil
+		NewErrorResponse(c, http.StatusBadRequest, fmt.Sprintf("%s must be a date or an RFC 3339 time", name))
+		return nil, err
 	}
-	errRes := c.JSON(http.StatusOK, map[string]interface{}{
-		"token": token,
-	})
-	if errRes != nil {
-		return errRes
+	day = day.Add(dayEnd)
+	return &day, nil
+}
+
+// GetRequest binds the request body into i and validates it.
+// On failure the error response is already written.
+func GetRequest(c echo.Context, i interface{}) error {
+	if err := c.Bind(i); err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
+		return err
+	}
+	if err := c.Validate(i); err != nil {
+		writeValidationError(c, err)
+		return err
 	}
 	return nil
 }
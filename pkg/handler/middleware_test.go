@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,8 @@ import (
 	"testing"
 )
 
+var testPrincipal = service.Principal{Id: 3, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+
 func TestHandler_userIdentify(t *testing.T) {
 	type mockBehavior func(s *mockService.MockAuthorization, token string)
 
@@ -33,11 +36,50 @@ func TestHandler_userIdentify(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(s *mockService.MockAuthorization, token string) {
-				s.EXPECT().ParseToken(token).Return(1, nil).AnyTimes()
+				s.EXPECT().ParseToken(token).Return(&service.TokenClaims{
+					StandardClaims: jwt.StandardClaims{Id: "jti"},
+					UserId:         1,
+				}, nil).AnyTimes()
+				s.EXPECT().IsTokenRevoked(&service.TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 1}).Return(false, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: "1" + "\n",
 		},
+		{
+			name:        "Empty header",
+			headerName:  "Authorization",
+			headerValue: "",
+			mockBehavior: func(s *mockService.MockAuthorization, token string) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"empty auth header"}` + "\n",
+		},
+		{
+			name:        "Invalid token",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(s *mockService.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(nil, errors.New("token is expired"))
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"token is expired"}` + "\n",
+		},
+		{
+			name:        "Revoked token",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(s *mockService.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(&service.TokenClaims{
+					StandardClaims: jwt.StandardClaims{Id: "jti"},
+					UserId:         1,
+				}, nil)
+				s.EXPECT().IsTokenRevoked(&service.TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 1}).Return(true, nil)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"token is revoked"}` + "\n",
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -72,107 +114,192 @@ func TestHandler_userIdentify(t *testing.T) {
 	}
 }
 
-func TestHandler_GoogleSignUp(t *testing.T) {
-	type mockBehavior func(s *mockService.MockAuthorization, user models.User)
+func TestHandler_userIdentifyApiKey(t *testing.T) {
+	type mockBehavior func(s *mockService.MockApiKey, key string)
 
 	testTable := []struct {
 		name                 string
-		inputBody            string
-		inputUser            models.User
+		key                  string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
 		{
-			name:      "ok",
-			inputBody: `{"name": "Test","username":"test username","password":"password"}`,
-			inputUser: models.User{
-				Name:     "Test",
-				Username: "test username",
-				Password: "password",
-			},
-			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
-				s.EXPECT().CreateUser(user).Return(1, nil)
+			name: "OK",
+			key:  "pat_key",
+			mockBehavior: func(s *mockService.MockApiKey, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(service.Principal{
+					Id:     1,
+					Roles:  []string{service.RoleUser},
+					Scopes: []string{service.ScopePostsWrite},
+				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"id":1}` + "\n",
+			expectedResponseBody: `{"id":1,"roles":["user"],"scopes":["posts:write"]}` + "\n",
 		},
 		{
-			name:      "Service Error",
-			inputBody: `{"name": "Test","username":"test username","password":"password"}`,
-			inputUser: models.User{},
-			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
-				s.EXPECT().CreateUser(user).Return(0, errors.New("something went wrong"))
+			name: "Invalid key",
+			key:  "pat_key",
+			mockBehavior: func(s *mockService.MockApiKey, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(service.Principal{}, service.ErrInvalidApiKey)
 			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid api key"}` + "\n",
 		},
 	}
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-
-			//Начальные значения
-			//настраиваем логику оболочек (подключаем все уровни)
 			c := gomock.NewController(t)
 			defer c.Finish()
 
-			auth := mockService.NewMockAuthorization(c)
-			testCase.mockBehavior(auth, testCase.inputUser)
+			keys := mockService.NewMockApiKey(c)
+			testCase.mockBehavior(keys, testCase.key)
 
-			services := &service.Service{Authorization: auth}
+			services := &service.Service{Authorization: mockService.NewMockAuthorization(c), ApiKey: keys}
 			handler := NewHandler(services)
 
-			//Тестовый сервер
 			e := echo.New()
-
-			//Тестовый запрос
-			req := httptest.NewRequest(http.MethodPost, "/sign-up",
-				strings.NewReader(testCase.inputBody))
-			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			e.GET("/protected", func(c echo.Context) error {
+				principal, _ := GetPrincipal(c)
+				return c.JSON(200, principal)
+			}, handler.userIdentify)
 			rec := httptest.NewRecorder()
-			ctx := e.NewContext(req, rec)
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+testCase.key)
 
-			//Проверка результатов
-			if assert.NoError(t, handler.GoogleSignUp(ctx, testCase.inputUser)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
-			}
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
+}
+
+func TestHandler_requirePermission(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		principal            service.Principal
+		permission           string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Moderator deletes any post",
+			principal:            service.Principal{Id: 1, Roles: []string{service.RoleModerator}, Scopes: []string{service.ScopeAll}},
+			permission:           service.PermPostDeleteAny,
+			expectedStatusCode:   200,
+			expectedResponseBody: "1" + "\n",
+		},
+		{
+			name:                 "User can't delete any post",
+			principal:            service.Principal{Id: 1, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}},
+			permission:           service.PermPostDeleteAny,
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"permission denied"}` + "\n",
+		},
+		{
+			name:                 "Moderator can't change roles",
+			principal:            service.Principal{Id: 1, Roles: []string{service.RoleModerator}, Scopes: []string{service.ScopeAll}},
+			permission:           service.PermUserRoleUpdate,
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"permission denied"}` + "\n",
+		},
+		{
+			name:                 "Admin changes roles",
+			principal:            service.Principal{Id: 1, Roles: []string{service.RoleAdmin}, Scopes: []string{service.ScopeAll}},
+			permission:           service.PermUserRoleUpdate,
+			expectedStatusCode:   200,
+			expectedResponseBody: "1" + "\n",
+		},
+		{
+			name:                 "API key can't manage API keys",
+			principal:            service.Principal{Id: 1, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAdmin}},
+			permission:           service.PermApiKeyManage,
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"permission denied"}` + "\n",
+		},
+		{
+			name:                 "Scope doesn't allow permission",
+			principal:            service.Principal{Id: 1, Roles: []string{service.RoleAdmin}, Scopes: []string{service.ScopePostsWrite}},
+			permission:           service.PermCommentCreate,
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"permission denied"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := NewHandler(&service.Service{})
+
+			e := echo.New()
+			e.GET("/protected", func(c echo.Context) error {
+				principal, _ := GetPrincipal(c)
+				return c.JSON(200, principal.Id)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(principalCtx, testCase.principal)
+					return next(c)
+				}
+			}, handler.requirePermission(testCase.permission))
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
 
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
 }
 
-func TestHandler_GoogleSignIn(t *testing.T) {
-	type mockBehavior func(s *mockService.MockAuthorization, user models.User)
+func TestHandler_OAuthSignIn(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization, user service.OAuthUser)
 
 	testTable := []struct {
 		name                 string
-		inputBody            string
-		inputUser            models.User
+		inputUser            service.OAuthUser
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
 		{
-			name:      "ok",
-			inputBody: `{"username":"test username","password":"password"}`,
-			inputUser: models.User{
-				Username: "test username",
-				Password: "password",
+			name: "ok",
+			inputUser: service.OAuthUser{
+				Provider:      "google",
+				Subject:       "1234",
+				Email:         "test@example.com",
+				EmailVerified: true,
+				Name:          "Test",
 			},
-			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("token", nil)
+			mockBehavior: func(s *mockService.MockAuthorization, user service.OAuthUser) {
+				s.EXPECT().OAuthSignIn(user, testClient).Return(service.Tokens{
+					AccessToken:  "token",
+					RefreshToken: "refresh",
+					ExpiresIn:    900,
+				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"token":"token"}` + "\n",
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh","expires_in":900}` + "\n",
+		},
+		{
+			name: "Username is taken",
+			inputUser: service.OAuthUser{
+				Provider: "google",
+				Subject:  "1234",
+				Email:    "test@example.com",
+			},
+			mockBehavior: func(s *mockService.MockAuthorization, user service.OAuthUser) {
+				s.EXPECT().OAuthSignIn(user, testClient).Return(service.Tokens{}, service.ErrIdentityConflict)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"username is already taken by another account"}` + "\n",
 		},
 		{
 			name:      "Service Error",
-			inputBody: `{"name": "Test","username":"test username","password":"password"}`,
-			inputUser: models.User{},
-			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("", errors.New("something went wrong"))
+			inputUser: service.OAuthUser{},
+			mockBehavior: func(s *mockService.MockAuthorization, user service.OAuthUser) {
+				s.EXPECT().OAuthSignIn(user, testClient).Return(service.Tokens{}, errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
@@ -197,14 +324,12 @@ func TestHandler_GoogleSignIn(t *testing.T) {
 			e := echo.New()
 
 			//Тестовый запрос
-			req := httptest.NewRequest(http.MethodPost, "/sign-in",
-				strings.NewReader(testCase.inputBody))
-			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req := httptest.NewRequest(http.MethodGet, "/oauth/google/callback", nil)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 
 			//Проверка результатов
-			if assert.NoError(t, handler.GoogleSignIn(ctx, testCase.inputUser)) {
+			if assert.NoError(t, handler.OAuthSignIn(ctx, testCase.inputUser)) {
 				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
 				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 			}
@@ -248,6 +373,7 @@ func Test_GetRequest(t *testing.T) {
 	}
 
 	e := echo.New()
+	e.Validator = NewValidator()
 	req := httptest.NewRequest(http.MethodPost, "/example",
 		strings.NewReader(inputBody))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
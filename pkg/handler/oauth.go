@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/oauth2"
+	"net/http"
+	"test/pkg/service"
+)
+
+const (
+	ParamProvider    = "provider"
+	oauthStateCookie = "oauth_state"
+	oauthCookiePath  = "/oauth"
+)
+
+// OAuthLogin godoc
+// @Summary      Sign in with an external provider
+// @Description  redirect to the consent page of the provider
+// @Tags         auth
+// @Param        provider  path  string  true  "Provider name"
+// @Success      303
+// @Failure 	 404 	{object} ErrorResponse	 "unknown oauth provider"
+// @Failure 	 500 	{object} ErrorResponse	 "error with oauth provider"
+// @Router       /oauth/{provider}/login [get]
+func (h *Handler) OAuthLogin(c echo.Context) error {
+	provider, errProvider := h.oauth.Get(c.Param(ParamProvider))
+	if errProvider != nil {
+		NewErrorResponse(c, http.StatusNotFound, errProvider.Error())
+		return nil
+	}
+	config, errConfig := provider.Config(c.Request().Context())
+	if errConfig != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "error with oauth provider")
+		return nil
+	}
+
+	state := newOAuthState(provider.Name())
+	cookie, err := state.cookie(oauthStateCookie, oauthCookiePath, h.oauth.stateKey)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "error with oauth provider")
+		return nil
+	}
+	c.SetCookie(cookie)
+
+	url := config.AuthCodeURL(state.State,
+		oauth2.SetAuthURLParam("code_challenge", state.challenge()),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+	return c.Redirect(http.StatusSeeOther, url)
+}
+
+// OAuthCallback godoc
+// @Summary      External provider sign in callback
+// @Description  finish sign in with the provider and get user tokens
+// @Tags         auth
+// @Produce      json
+// @Param        provider  path  string  true  "Provider name"
+// @Param        state	query    string  true  "OAuth state"
+// @Param        code	query    string  true  "Authorization code"
+// @Success      200 	{object} TokenResponse   "result is user tokens"
+// @Failure 	 400 	{object} ErrorResponse	 "wrong state"
+// @Failure 	 404 	{object} ErrorResponse	 "unknown oauth provider"
+// @Failure 	 409 	{object} ErrorResponse	 "username is already taken by another account"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /oauth/{provider}/callback [get]
+func (h *Handler) OAuthCallback(c echo.Context) error {
+	provider, errProvider := h.oauth.Get(c.Param(ParamProvider))
+	if errProvider != nil {
+		NewErrorResponse(c, http.StatusNotFound, errProvider.Error())
+		return nil
+	}
+
+	cookie, errCookie := c.Cookie(oauthStateCookie)
+	if errCookie != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "wrong state")
+		return nil
+	}
+	c.SetCookie(expiredCookie(oauthStateCookie, oauthCookiePath))
+
+	state, errState := readOAuthState(cookie, provider.Name(), c.QueryParam("state"), h.oauth.stateKey)
+	if errState != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "wrong state")
+		return nil
+	}
+
+	ctx := c.Request().Context()
+	token, err := provider.Exchange(ctx, c.QueryParam("code"), state.Verifier)
+	if err != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "wrong token")
+		return nil
+	}
+
+	claims, errInfo := provider.UserInfo(ctx, token)
+	if errInfo != nil {
+		NewErrorResponse(c, http.StatusBadRequest, "wrong response")
+		return nil
+	}
+
+	return h.OAuthSignIn(c, service.OAuthUser{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+}
+
+func (h *Handler) OAuthSignIn(c echo.Context, input service.OAuthUser) error {
+	tokens, err := h.services.Authorization.OAuthSignIn(input, clientInfo(c))
+	if errors.Is(err, service.ErrIdentityConflict) {
+		NewErrorResponse(c, http.StatusConflict, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	return writeSignIn(c, tokens)
+}
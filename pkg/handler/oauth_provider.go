@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	OAuthTypeOIDC   = "oidc"
+	OAuthTypeOAuth2 = "oauth2"
+)
+
+var errUnknownProvider = errors.New("unknown oauth provider")
+
+// OAuthProviderConfig describes a login provider. OIDC providers only need
+// the issuer, the endpoints are read from its discovery document.
+// Plain OAuth2 providers (GitHub and alike) list the endpoints explicitly.
+type OAuthProviderConfig struct {
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	Issuer       string       `json:"issuer"`
+	ClientId     string       `json:"client_id"`
+	ClientSecret string       `json:"client_secret"`
+	RedirectURL  string       `json:"redirect_url"`
+	Scopes       []string     `json:"scopes"`
+	AuthURL      string       `json:"auth_url"`
+	TokenURL     string       `json:"token_url"`
+	UserInfoURL  string       `json:"userinfo_url"`
+	Claims       ClaimMapping `json:"claims"`
+}
+
+// ClaimMapping names the userinfo fields that hold the user data.
+// Nested fields are separated by dots.
+type ClaimMapping struct {
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified string `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+var oidcClaims = ClaimMapping{
+	Subject:       "sub",
+	Email:         "email",
+	EmailVerified: "email_verified",
+	Name:          "name",
+}
+
+type OAuthProvider struct {
+	config OAuthProviderConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	oauth       *oauth2.Config
+	userInfoURL string
+}
+
+type OAuthRegistry struct {
+	providers map[string]*OAuthProvider
+	stateKey  []byte
+	client    *http.Client
+}
+
+// NewOAuthRegistry creates the providers of the configs.
+// The client is used for discovery, token exchange and userinfo calls.
+func NewOAuthRegistry(stateKey []byte, client *http.Client, configs ...OAuthProviderConfig) (*OAuthRegistry, error) {
+	registry := &OAuthRegistry{
+		providers: map[string]*OAuthProvider{},
+		stateKey:  stateKey,
+		client:    client,
+	}
+	for _, config := range configs {
+		if err := registry.Register(config); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// LoadOAuthRegistry reads the providers from the JSON file named by the
+// oauthProvidersFile environment variable. ${VAR} references in the file are
+// replaced with environment variables, so secrets can stay out of it.
+// Google is also registered from googleClientId, googleClientSecret and googleRedirectUrl.
+func LoadOAuthRegistry() (*OAuthRegistry, error) {
+	var configs []OAuthProviderConfig
+	if path := os.Getenv("oauthProvidersFile"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(os.ExpandEnv(string(data))), &configs); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if clientId := os.Getenv("googleClientId"); clientId != "" {
+		configs = append(configs, OAuthProviderConfig{
+			Name:         "google",
+			Type:         OAuthTypeOIDC,
+			Issuer:       "https://accounts.google.com",
+			ClientId:     clientId,
+			ClientSecret: os.Getenv("googleClientSecret"),
+			RedirectURL:  os.Getenv("googleRedirectUrl"),
+			Scopes:       []string{"openid", "email", "profile"},
+		})
+	}
+
+	stateKey := []byte(os.Getenv("oauthStateKey"))
+	if len(stateKey) == 0 {
+		stateKey = []byte(randomString(32))
+	}
+	return NewOAuthRegistry(stateKey, &http.Client{Timeout: 10 * time.Second}, configs...)
+}
+
+func (r *OAuthRegistry) Register(config OAuthProviderConfig) error {
+	if config.Name == "" {
+		return errors.New("oauth provider without name")
+	}
+	if _, ok := r.providers[config.Name]; ok {
+		return fmt.Errorf("oauth provider %s is registered twice", config.Name)
+	}
+	switch config.Type {
+	case OAuthTypeOIDC:
+		if config.Issuer == "" {
+			return fmt.Errorf("oidc provider %s without issuer", config.Name)
+		}
+		if config.Claims == (ClaimMapping{}) {
+			config.Claims = oidcClaims
+		}
+		if len(config.Scopes) == 0 {
+			config.Scopes = []string{"openid", "email", "profile"}
+		}
+	case OAuthTypeOAuth2:
+		if config.AuthURL == "" || config.TokenURL == "" || config.UserInfoURL == "" {
+			return fmt.Errorf("oauth2 provider %s needs auth_url, token_url and userinfo_url", config.Name)
+		}
+		if config.Claims.Subject == "" {
+			return fmt.Errorf("oauth2 provider %s needs a subject claim", config.Name)
+		}
+	default:
+		return fmt.Errorf("oauth provider %s has unknown type %q", config.Name, config.Type)
+	}
+	r.providers[config.Name] = &OAuthProvider{config: config, client: r.client}
+	return nil
+}
+
+func (r *OAuthRegistry) Get(name string) (*OAuthProvider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, errUnknownProvider
+	}
+	return provider, nil
+}
+
+func (p *OAuthProvider) Name() string {
+	return p.config.Name
+}
+
+// Config returns the OAuth2 configuration. The discovery document
+// of OIDC providers is fetched on first use and cached.
+func (p *OAuthProvider) Config(ctx context.Context) (*oauth2.Config, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p.oauth, nil
+	}
+
+	authURL, tokenURL, userInfoURL := p.config.AuthURL, p.config.TokenURL, p.config.UserInfoURL
+	if p.config.Type == OAuthTypeOIDC {
+		discovery, err := p.discover(ctx)
+		if err != nil {
+			return nil, err
+		}
+		authURL, tokenURL = discovery.AuthorizationEndpoint, discovery.TokenEndpoint
+		if userInfoURL == "" {
+			userInfoURL = discovery.UserInfoEndpoint
+		}
+	}
+
+	p.userInfoURL = userInfoURL
+	p.oauth = &oauth2.Config{
+		ClientID:     p.config.ClientId,
+		ClientSecret: p.config.ClientSecret,
+		Endpoint:     oauth2.Endpoint{AuthURL: authURL, TokenURL: tokenURL},
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+	}
+	return p.oauth, nil
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+func (p *OAuthProvider) discover(ctx context.Context) (oidcDiscovery, error) {
+	var discovery oidcDiscovery
+	issuer := strings.TrimSuffix(p.config.Issuer, "/")
+	err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", nil, &discovery)
+	if err != nil {
+		return discovery, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return discovery, fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserInfoEndpoint == "" {
+		return discovery, errors.New("discovery document misses endpoints")
+	}
+	return discovery, nil
+}
+
+// Exchange trades the authorization code for a token.
+func (p *OAuthProvider) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	config, err := p.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	return config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+}
+
+// UserInfo fetches the userinfo document and maps it with the claim mapping.
+func (p *OAuthProvider) UserInfo(ctx context.Context, token *oauth2.Token) (OAuthClaims, error) {
+	if _, err := p.Config(ctx); err != nil {
+		return OAuthClaims{}, err
+	}
+	var claims map[string]interface{}
+	if err := p.getJSON(ctx, p.userInfoURL, token, &claims); err != nil {
+		return OAuthClaims{}, err
+	}
+	mapping := p.config.Claims
+	result := OAuthClaims{
+		Subject: claimString(claims, mapping.Subject),
+		Email:   claimString(claims, mapping.Email),
+		Name:    claimString(claims, mapping.Name),
+	}
+	if mapping.EmailVerified != "" {
+		result.EmailVerified = claimString(claims, mapping.EmailVerified) == "true"
+	}
+	if result.Subject == "" {
+		return result, errors.New("userinfo has no subject")
+	}
+	return result, nil
+}
+
+type OAuthClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+func (p *OAuthProvider) getJSON(ctx context.Context, url string, token *oauth2.Token, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != nil {
+		token.SetAuthHeader(req)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, res.StatusCode)
+	}
+	decoder := json.NewDecoder(res.Body)
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// claimString returns the claim at the dotted path as a string.
+func claimString(claims map[string]interface{}, path string) string {
+	if path == "" {
+		return ""
+	}
+	var value interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return ""
+	}
+}
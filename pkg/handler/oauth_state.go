@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+var errInvalidState = errors.New("invalid oauth state")
+
+// oauthState is kept in a signed cookie between the login redirect and the callback.
+type oauthState struct {
+	Provider  string `json:"provider"`
+	State     string `json:"state"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func newOAuthState(provider string) oauthState {
+	return oauthState{
+		Provider:  provider,
+		State:     randomString(32),
+		Verifier:  randomString(32),
+		ExpiresAt: time.Now().Add(oauthStateTTL).Unix(),
+	}
+}
+
+// challenge is the PKCE S256 code challenge of the verifier.
+func (s oauthState) challenge() string {
+	sum := sha256.Sum256([]byte(s.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (s oauthState) cookie(name, path string, key []byte) (*http.Cookie, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(payload)
+	return &http.Cookie{
+		Name:     name,
+		Value:    value + "." + signState(value, key),
+		Path:     path,
+		MaxAge:   int(oauthStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}, nil
+}
+
+// readOAuthState checks the signature and expiry of the cookie
+// and that it was issued for the provider and the state it returned.
+func readOAuthState(cookie *http.Cookie, provider, state string, key []byte) (oauthState, error) {
+	var s oauthState
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(signState(parts[0], key))) {
+		return s, errInvalidState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return s, errInvalidState
+	}
+	if err = json.Unmarshal(payload, &s); err != nil {
+		return s, errInvalidState
+	}
+	if time.Now().Unix() > s.ExpiresAt || s.Provider != provider || subtle.ConstantTimeCompare([]byte(s.State), []byte(state)) != 1 {
+		return s, errInvalidState
+	}
+	return s, nil
+}
+
+func expiredCookie(name, path string) *http.Cookie {
+	return &http.Cookie{Name: name, Path: path, MaxAge: -1, HttpOnly: true, Secure: true}
+}
+
+func signState(value string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+// fakeProvider is an in-process OAuth2 server. With discovery enabled it
+// behaves like an OIDC provider, otherwise like GitHub.
+type fakeProvider struct {
+	server    *httptest.Server
+	challenge string
+	userInfo  map[string]interface{}
+}
+
+func newFakeProvider(t *testing.T, discovery bool, userInfo map[string]interface{}) *fakeProvider {
+	p := &fakeProvider{userInfo: userInfo}
+	mux := http.NewServeMux()
+	if discovery {
+		mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 p.server.URL,
+				"authorization_endpoint": p.server.URL + "/authorize",
+				"token_endpoint":         p.server.URL + "/token",
+				"userinfo_endpoint":      p.server.URL + "/userinfo",
+			})
+		})
+	}
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "provider token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer provider token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(p.userInfo)
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func TestHandler_OAuthCallback(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		discovery            bool
+		config               func(url string) OAuthProviderConfig
+		userInfo             map[string]interface{}
+		changeState          bool
+		dropCookie           bool
+		code                 string
+		expectedUser         *service.OAuthUser
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OIDC provider",
+			discovery: true,
+			config: func(url string) OAuthProviderConfig {
+				return OAuthProviderConfig{Name: "fake", Type: OAuthTypeOIDC, Issuer: url, ClientId: "client"}
+			},
+			userInfo: map[string]interface{}{
+				"sub": "1234", "email": "test@example.com", "email_verified": true, "name": "Test",
+			},
+			code: "code",
+			expectedUser: &service.OAuthUser{
+				Provider: "fake", Subject: "1234", Email: "test@example.com", EmailVerified: true, Name: "Test",
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh","expires_in":900}` + "\n",
+		},
+		{
+			name: "GitHub-style provider",
+			config: func(url string) OAuthProviderConfig {
+				return OAuthProviderConfig{
+					Name:        "fake",
+					Type:        OAuthTypeOAuth2,
+					ClientId:    "client",
+					AuthURL:     url + "/authorize",
+					TokenURL:    url + "/token",
+					UserInfoURL: url + "/userinfo",
+					Claims:      ClaimMapping{Subject: "id", Email: "email", Name: "profile.name"},
+				}
+			},
+			userInfo: map[string]interface{}{
+				"id": 98765432101, "email": "test@example.com", "profile": map[string]string{"name": "Test"},
+			},
+			code: "code",
+			expectedUser: &service.OAuthUser{
+				Provider: "fake", Subject: "98765432101", Email: "test@example.com", Name: "Test",
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh","expires_in":900}` + "\n",
+		},
+		{
+			name:      "Wrong state",
+			discovery: true,
+			config: func(url string) OAuthProviderConfig {
+				return OAuthProviderConfig{Name: "fake", Type: OAuthTypeOIDC, Issuer: url}
+			},
+			changeState:          true,
+			code:                 "code",
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"wrong state"}` + "\n",
+		},
+		{
+			name:      "No state cookie",
+			discovery: true,
+			config: func(url string) OAuthProviderConfig {
+				return OAuthProviderConfig{Name: "fake", Type: OAuthTypeOIDC, Issuer: url}
+			},
+			dropCookie:           true,
+			code:                 "code",
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"wrong state"}` + "\n",
+		},
+		{
+			name:      "Wrong code",
+			discovery: true,
+			config: func(url string) OAuthProviderConfig {
+				return OAuthProviderConfig{Name: "fake", Type: OAuthTypeOIDC, Issuer: url}
+			},
+			code:                 "other code",
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"wrong token"}` + "\n",
+		},
+		{
+			name:      "No subject",
+			discovery: true,
+			config: func(url string) OAuthProviderConfig {
+				return OAuthProviderConfig{Name: "fake", Type: OAuthTypeOIDC, Issuer: url}
+			},
+			userInfo:             map[string]interface{}{"email": "test@example.com"},
+			code:                 "code",
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"wrong response"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			provider := newFakeProvider(t, testCase.discovery, testCase.userInfo)
+			defer provider.server.Close()
+
+			auth := mockService.NewMockAuthorization(c)
+			if testCase.expectedUser != nil {
+				auth.EXPECT().OAuthSignIn(*testCase.expectedUser, testClient).
+					Return(service.Tokens{AccessToken: "token", RefreshToken: "refresh", ExpiresIn: 900}, nil)
+			}
+
+			registry, err := NewOAuthRegistry([]byte("key"), provider.server.Client(), testCase.config(provider.server.URL))
+			assert.NoError(t, err)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+			handler.UseOAuthProviders(registry)
+			e := handler.InitRoutes()
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/oauth/fake/login", nil))
+			assert.Equal(t, http.StatusSeeOther, rec.Code)
+
+			location, err := url.Parse(rec.Header().Get(echo.HeaderLocation))
+			assert.NoError(t, err)
+			assert.Equal(t, provider.server.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+			assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+			provider.challenge = location.Query().Get("code_challenge")
+			state := location.Query().Get("state")
+			if testCase.changeState {
+				state = "other state"
+			}
+
+			req := httptest.NewRequest(http.MethodGet,
+				"/oauth/fake/callback?state="+url.QueryEscape(state)+"&code="+url.QueryEscape(testCase.code), nil)
+			if !testCase.dropCookie {
+				for _, cookie := range rec.Result().Cookies() {
+					req.AddCookie(cookie)
+				}
+			}
+			rec = httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
+
+func TestHandler_OAuthUnknownProvider(t *testing.T) {
+	handler := NewHandler(&service.Service{})
+	e := handler.InitRoutes()
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/oauth/unknown/login", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, `{"message":"unknown oauth provider"}`+"\n", rec.Body.String())
+}
+
+func TestOAuthRegistry_Register(t *testing.T) {
+	testTable := []struct {
+		name    string
+		config  OAuthProviderConfig
+		wantErr bool
+	}{
+		{name: "OIDC", config: OAuthProviderConfig{Name: "oidc", Type: OAuthTypeOIDC, Issuer: "https://issuer"}},
+		{name: "OIDC without issuer", config: OAuthProviderConfig{Name: "oidc", Type: OAuthTypeOIDC}, wantErr: true},
+		{name: "OAuth2 without endpoints", config: OAuthProviderConfig{Name: "github", Type: OAuthTypeOAuth2}, wantErr: true},
+		{name: "Unknown type", config: OAuthProviderConfig{Name: "saml", Type: "saml"}, wantErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewOAuthRegistry([]byte("key"), http.DefaultClient, testCase.config)
+			assert.Equal(t, testCase.wantErr, err != nil)
+		})
+	}
+}
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"test/pkg/repository/models"
+	"test/pkg/service"
 )
 
 // GetPosts godoc
@@ -13,21 +15,47 @@ import (
 // @Description Get all posts
 // @Tags        posts
 // @Produce     json
+// @Param       author       query    int    false "Author ID"
+// @Param       created_from query    string false "Created at or after, RFC 3339 time or date"
+// @Param       created_to   query    string false "Created before, RFC 3339 time or date (inclusive)"
+// @Param       title        query    string false "Title contains"
+// @Param       tag          query    string false "Tag name"
+// @Param       status       query    string false "Status: draft, scheduled, published or archived; only own posts are listed unless published"
+// @Param       format       query    string false "Format of the bodies: markdown or html" default(markdown)
+// @Param       sort         query    string false "Sort fields: created_at, title, id; prefix - for descending" default(-created_at)
+// @Param       limit        query    int    false "Page size"
+// @Param       cursor       query    string false "Cursor of the page, next_cursor of the previous one"
 // @Success     200 {object} GetPostsResponse
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	400 {object} ErrorResponse	 "invalid sort"
+// @Failure 	400 {object} ErrorResponse	 "format must be html or markdown"
 // @Failure 	500 {object} ErrorResponse	 "something went wrong"
 // @Router      /api/posts [get]
 func (h *Handler) GetPosts(c echo.Context) error {
+	filter, errFilter := GetPostFilter(c)
+	if errFilter != nil {
+		return nil
+	}
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+	format, errFormat := GetFormat(c)
+	if errFormat != nil {
+		return nil
+	}
 
-	posts, err := h.services.Post.Get()
+	posts, next, err := h.services.Post.Get(filter, page)
 	if err != nil {
-		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		writePageError(c, err)
 		return nil
 	}
+	h.services.Content.PreparePosts(posts, format)
 	_, errEnCd := json.Marshal(&posts)
 	if errEnCd != nil {
 		return errEnCd
 	}
-	errRes := c.JSON(http.StatusOK, GetPostsResponse{Posts: posts})
+	errRes := c.JSON(http.StatusOK, GetPostsResponse{Posts: posts, NextCursor: next})
 	if errRes != nil {
 		return nil
 	}
@@ -40,8 +68,20 @@ func (h *Handler) GetPosts(c echo.Context) error {
 // @Tags        posts
 // @Produce     json
 // @Param       id  path     int true "User ID"
+// @Param       created_from query    string false "Created at or after, RFC 3339 time or date"
+// @Param       created_to   query    string false "Created before, RFC 3339 time or date (inclusive)"
+// @Param       title        query    string false "Title contains"
+// @Param       tag          query    string false "Tag name"
+// @Param       status       query    string false "Status: draft, scheduled, published or archived; only own posts are listed unless published"
+// @Param       format       query    string false "Format of the bodies: markdown or html" default(markdown)
+// @Param       sort         query    string false "Sort fields: created_at, title, id; prefix - for descending" default(-created_at)
+// @Param       limit        query    int    false "Page size"
+// @Param       cursor       query    string false "Cursor of the page, next_cursor of the previous one"
 // @Success     200 {object} GetPostsResponse
 // @Failure 	400 {object} ErrorResponse	 "ID is not integer"
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	400 {object} ErrorResponse	 "invalid sort"
+// @Failure 	400 {object} ErrorResponse	 "format must be html or markdown"
 // @Failure 	500 {object} ErrorResponse	 "wrong user ID"
 // @Router      /api/posts/user/{id} [get]
 func (h *Handler) GetUserPosts(c echo.Context) error {
@@ -50,17 +90,37 @@ func (h *Handler) GetUserPosts(c echo.Context) error {
 		return nil
 	}
 
-	posts, err := h.services.Post.GetByUserId(userId)
+	filter, errFilter := GetPostFilter(c)
+	if errFilter != nil {
+		return nil
+	}
+	filter.UserId = userId
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+	format, errFormat := GetFormat(c)
+	if errFormat != nil {
+		return nil
+	}
+
+	posts, next, err := h.services.Post.Get(filter, page)
+	if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSort) {
+		writePageError(c, err)
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "wrong user ID")
 		return nil
 	}
+	h.services.Content.PreparePosts(posts, format)
 	_, errEnCd := json.Marshal(posts)
 	if errEnCd != nil {
 		return errEnCd
 	}
 	errRes := c.JSON(http.StatusOK, GetPostsResponse{
-		Posts: posts,
+		Posts:      posts,
+		NextCursor: next,
 	})
 	if errRes != nil {
 		return nil
@@ -70,25 +130,47 @@ func (h *Handler) GetUserPosts(c echo.Context) error {
 
 // GetPostById godoc
 // @Summary     Find post by post ID
-// @Description Get post by post ID
+// @Description Get post by post ID with the counts of its reactions and the reactions of the viewer;
+// @Description posts that are not published are found only by their author
 // @Tags        posts
 // @Produce     json
 // @Param       id  path     int true "Post ID"
+// @Param       format  query    string false "Format of the body: markdown or html" default(markdown)
 // @Success     200 {object} test.Post
 // @Failure 	400 {object} ErrorResponse	 "ID is not integer"
+// @Failure 	400 {object} ErrorResponse	 "format must be html or markdown"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
 // @Failure 	500 {object} ErrorResponse	"ID is incorrect"
+// @Failure 	500 {object} ErrorResponse	"something went wrong"
 // @Router      /api/posts/{id} [get]
 func (h *Handler) GetPostById(c echo.Context) error {
 	id, errReq := GetParam(c, ParamId)
 	if errReq != nil {
 		return errReq
 	}
+	format, errFormat := GetFormat(c)
+	if errFormat != nil {
+		return nil
+	}
 
-	post, err := h.services.Post.GetById(id)
+	post, err := h.services.Post.GetById(GetViewerId(c), id)
+	if writePostError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "ID is incorrect.")
 		return nil
 	}
+	reactions, err := h.services.Reaction.GetReactionSummaries(GetViewerId(c), models.ReactionTargetPost, []int{post.Id})
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	summary := reactions[post.Id]
+	post.Reactions = &summary
+	posts := []models.Post{post}
+	h.services.Content.PreparePosts(posts, format)
+	post = posts[0]
 	_, errEnCd := json.Marshal(post)
 	if errEnCd != nil {
 		return errEnCd
@@ -106,10 +188,12 @@ func (h *Handler) GetPostById(c echo.Context) error {
 // @Tags         posts
 // @Accept       json
 // @Produce      json
-// @Param        post	body     PostRequest 		  true  "Add post"
+// @Param        post	body     CreatePostRequest 		  true  "Add post"
 // @Success      200 	{object} IdResponse		 "result is id of post"
+// @Failure 	 400 	{object} ErrorResponse	 "publish_at must be in the future"
 // @Failure 	 400 	{object} ErrorResponse	 "user id is of valid type"
 // @Failure 	 404 	{object} ErrorResponse	 "user id not found"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
 // @Failure 	 500 	{object} ErrorResponse	 "server error"
 // @Router       /api/posts [post]
 func (h *Handler) PostPost(c echo.Context) error {
@@ -118,14 +202,19 @@ func (h *Handler) PostPost(c echo.Context) error {
 		return errParams
 	}
 
-	var post models.Post
-	errReq := GetRequest(c, &post)
+	var input CreatePostRequest
+	errReq := GetRequest(c, &input)
 	if errReq != nil {
 		return nil
 	}
+	post := models.Post{Title: input.Title, Anons: input.Anons, Body: input.Body, Tags: input.Tags, Status: input.Status,
+		PublishAt: input.PublishAt}
 
 	post.UserId = userId
 	id, err := h.services.Post.Create(post)
+	if writePostError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "server error")
 		return nil
@@ -151,22 +240,33 @@ func (h *Handler) PostPost(c echo.Context) error {
 // @Failure 	400 {object} ErrorResponse	 "incorrect request data"
 // @Failure 	400 {object} ErrorResponse	 "user id is of valid type"
 // @Failure 	400 {object} ErrorResponse	 "id is not integer"
-// @Failure 	404 {object} ErrorResponse	 "user id not found"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to update post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	422 {object} ValidationErrorResponse "validation failed"
 // @Failure 	500 {object} ErrorResponse	 "server error"
 // @Router       /api/posts/{id} [put]
 func (h *Handler) UpdatePost(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
 	id, errParams := GetParam(c, ParamId)
 	if errParams != nil {
 		return errParams
 	}
 
-	var post models.Post
-	errReq := GetRequest(c, &post)
+	var input PostRequest
+	errReq := GetRequest(c, &input)
 	if errReq != nil {
 		return nil
 	}
+	post := models.Post{Title: input.Title, Anons: input.Anons, Body: input.Body, Tags: input.Tags}
 
-	err := h.services.Post.Update(id, post)
+	err := h.services.Post.Update(principal, id, post)
+	if writePostError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "server error")
 		return nil
@@ -181,6 +281,55 @@ func (h *Handler) UpdatePost(c echo.Context) error {
 	return nil
 }
 
+// UpdatePostStatus godoc
+// @Summary      Change the status of a post
+// @Description  Save a post as a draft, schedule, publish or archive it. Only published posts are public
+// @Tags         posts
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int                true  "Post ID"
+// @Param        status  body      PostStatusRequest  true  "New status"
+// @Success      200     {object}  MessageResponse	"Post with id # is published"
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	400 {object} ErrorResponse	 "publish_at must be in the future"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to update post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	422 {object} ValidationErrorResponse "validation failed"
+// @Failure 	500 {object} ErrorResponse	 "server error"
+// @Router       /api/posts/{id}/status [put]
+func (h *Handler) UpdatePostStatus(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	var input PostStatusRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	err := h.services.Post.SetStatus(principal, id, input.Status, input.PublishAt)
+	if writePostError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("Post with id %d is %s", id, input.Status),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
 // DeletePost godoc
 // @Summary      Delete a post
 // @Description  Delete by json post
@@ -190,16 +339,25 @@ func (h *Handler) UpdatePost(c echo.Context) error {
 // @Success     200 {object}  MessageResponse	"Post with id # deleted"
 // @Failure 	400 {object} ErrorResponse	 "user id is of valid type"
 // @Failure 	400 {object} ErrorResponse	 "id is not integer"
-// @Failure 	404 {object} ErrorResponse	 "user id not found"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to delete post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
 // @Failure 	500 {object} ErrorResponse	 "server error"
 // @Router       /api/posts/{id} [delete]
 func (h *Handler) DeletePost(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
 	id, errParams := GetParam(c, ParamId)
 	if errParams != nil {
 		return nil
 	}
 
-	err := h.services.Post.Delete(id)
+	err := h.services.Post.Delete(principal, id)
+	if writePostError(c, err) {
+		return nil
+	}
 	if err != nil {
 		NewErrorResponse(c, http.StatusInternalServerError, "server error")
 		return nil
@@ -212,3 +370,62 @@ func (h *Handler) DeletePost(c echo.Context) error {
 	}
 	return nil
 }
+
+// RestorePost godoc
+// @Summary      Restore a deleted post
+// @Description  Move a post of the user back from the trash, admins may restore any post
+// @Tags         posts
+// @Produce      json
+// @Param       id  path     int true "Post ID"
+// @Success     200 {object}  MessageResponse	"Post with id # restored"
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to restore post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	500 {object} ErrorResponse	 "server error"
+// @Router       /api/posts/{id}/restore [post]
+func (h *Handler) RestorePost(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	err := h.services.Post.Restore(principal, id)
+	if writePostError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("Post with id %d restored", id),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// writePostError writes the response for ownership and lookup errors
+// of the post service and reports whether it did.
+func writePostError(c echo.Context, err error) bool {
+	var forbidden *service.ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		NewErrorResponse(c, http.StatusForbidden, forbidden.Error())
+	case errors.Is(err, service.ErrPostNotFound):
+		NewErrorResponse(c, http.StatusNotFound, "post not found")
+	case errors.Is(err, service.ErrRevisionNotFound):
+		NewErrorResponse(c, http.StatusNotFound, "revision not found")
+	case errors.Is(err, service.ErrPublishAtPassed), errors.Is(err, service.ErrInvalidStatus):
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -1,17 +1,22 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
 	"test/pkg/repository/models"
 	"test/pkg/service"
 	mockService "test/pkg/service/mocks"
 	"testing"
+	"time"
 )
 
 func TestHandler_GetPosts(t *testing.T) {
@@ -19,6 +24,7 @@ func TestHandler_GetPosts(t *testing.T) {
 
 	testTable := []struct {
 		name                 string
+		query                string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
@@ -40,15 +46,77 @@ func TestHandler_GetPosts(t *testing.T) {
 						Anons:  "anons2",
 					},
 				}
-				s.EXPECT().Get().Return(ret, nil)
+				s.EXPECT().Get(service.PostFilter{}, service.PageRequest{}).Return(ret, "", nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"posts":[{"id":1,"user_id":12,"title":"title1","anons":"anons1"},{"id":2,"user_id":15,"title":"title2","anons":"anons2"}]}` + "\n",
+			expectedResponseBody: `{"posts":[{"id":1,"user_id":12,"title":"title1","anons":"anons1","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null},{"id":2,"user_id":15,"title":"title2","anons":"anons2","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}]}` + "\n",
+		},
+		{
+			name:  "Next page",
+			query: "?limit=1&cursor=abc",
+			mockBehavior: func(s *mockService.MockPost) {
+				s.EXPECT().Get(service.PostFilter{}, service.PageRequest{Limit: 1, Cursor: "abc"}).
+					Return([]models.Post{{Id: 1, UserId: 12, Title: "title1", Anons: "anons1"}}, "def", nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"posts":[{"id":1,"user_id":12,"title":"title1","anons":"anons1","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}],"next_cursor":"def"}` + "\n",
+		},
+		{
+			name:  "Filter and sort",
+			query: "?author=3&created_from=2022-01-01&created_to=2022-01-31&title=go&tag=news&sort=-created_at,title",
+			mockBehavior: func(s *mockService.MockPost) {
+				from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+				to := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+				s.EXPECT().Get(service.PostFilter{UserId: 3, CreatedFrom: &from, CreatedTo: &to, Title: "go", Tag: "news"},
+					service.PageRequest{Sort: "-created_at,title"}).Return([]models.Post{}, "", nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"posts":[]}` + "\n",
+		},
+		{
+			name:                 "Wrong author",
+			query:                "?author=me",
+			mockBehavior:         func(s *mockService.MockPost) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"author must be a positive integer"}` + "\n",
+		},
+		{
+			name:                 "Wrong date",
+			query:                "?created_from=yesterday",
+			mockBehavior:         func(s *mockService.MockPost) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"created_from must be a date or an RFC 3339 time"}` + "\n",
+		},
+		{
+			name:  "Wrong sort",
+			query: "?sort=password",
+			mockBehavior: func(s *mockService.MockPost) {
+				s.EXPECT().Get(service.PostFilter{}, service.PageRequest{Sort: "password"}).
+					Return(nil, "", fmt.Errorf("%w: unknown or repeated field %q", service.ErrInvalidSort, "password"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid sort: unknown or repeated field \"password\""}` + "\n",
+		},
+		{
+			name:                 "Wrong limit",
+			query:                "?limit=-1",
+			mockBehavior:         func(s *mockService.MockPost) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"limit must be a positive integer"}` + "\n",
+		},
+		{
+			name:  "Wrong cursor",
+			query: "?cursor=abc",
+			mockBehavior: func(s *mockService.MockPost) {
+				s.EXPECT().Get(service.PostFilter{}, service.PageRequest{Cursor: "abc"}).Return(nil, "", service.ErrInvalidCursor)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid cursor"}` + "\n",
 		},
 		{
 			name: "Server error",
 			mockBehavior: func(s *mockService.MockPost) {
-				s.EXPECT().Get().Return([]models.Post{}, errors.New("something went wrong"))
+				s.EXPECT().Get(service.PostFilter{}, service.PageRequest{}).Return(nil, "", errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
@@ -66,14 +134,15 @@ func TestHandler_GetPosts(t *testing.T) {
 			post := mockService.NewMockPost(c)
 			testCase.mockBehavior(post)
 
-			services := &service.Service{Post: post}
+			services := &service.Service{Post: post, Content: service.NewContentService()}
 			handler := NewHandler(services)
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
-			req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/posts"+testCase.query, nil)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 
@@ -115,16 +184,16 @@ func TestHandler_GetUserPosts(t *testing.T) {
 						Anons:  "anons2",
 					},
 				}
-				s.EXPECT().GetByUserId(userId).Return(ret, nil)
+				s.EXPECT().Get(service.PostFilter{UserId: userId}, service.PageRequest{}).Return(ret, "", nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"posts":[{"id":1,"user_id":12,"title":"title1","anons":"anons1"},{"id":2,"user_id":12,"title":"title2","anons":"anons2"}]}` + "\n",
+			expectedResponseBody: `{"posts":[{"id":1,"user_id":12,"title":"title1","anons":"anons1","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null},{"id":2,"user_id":12,"title":"title2","anons":"anons2","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}]}` + "\n",
 		},
 		{
 			name:       "error param",
 			inputParam: 12,
 			mockBehavior: func(s *mockService.MockPost, userId int) {
-				s.EXPECT().GetByUserId(userId).Return([]models.Post{}, errors.New("something went wrong"))
+				s.EXPECT().Get(service.PostFilter{UserId: userId}, service.PageRequest{}).Return(nil, "", errors.New("something went wrong"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"wrong user ID"}` + "\n",
@@ -142,11 +211,12 @@ func TestHandler_GetUserPosts(t *testing.T) {
 			post := mockService.NewMockPost(c)
 			testCase.mockBehavior(post, testCase.inputParam)
 
-			services := &service.Service{Post: post}
+			services := &service.Service{Post: post, Content: service.NewContentService()}
 			handler := NewHandler(services)
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodGet, "/api/posts/user/:id", nil)
@@ -168,11 +238,12 @@ func TestHandler_GetUserPosts(t *testing.T) {
 }
 
 func TestHandler_GetPostById(t *testing.T) {
-	type mockBehavior func(s *mockService.MockPost, id int)
+	type mockBehavior func(s *mockService.MockPost, r *mockService.MockReaction, id int)
 
 	testTable := []struct {
 		name                 string
 		inputParam           int
+		query                string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
@@ -180,23 +251,45 @@ func TestHandler_GetPostById(t *testing.T) {
 		{
 			name:       "ok",
 			inputParam: 1,
-			mockBehavior: func(s *mockService.MockPost, id int) {
+			mockBehavior: func(s *mockService.MockPost, r *mockService.MockReaction, id int) {
 				ret := models.Post{
 					Id:     1,
 					UserId: 12,
 					Title:  "title",
 					Anons:  "anons",
 				}
-				s.EXPECT().GetById(id).Return(ret, nil)
+				s.EXPECT().GetById(testPrincipal.Id, id).Return(ret, nil)
+				r.EXPECT().GetReactionSummaries(testPrincipal.Id, models.ReactionTargetPost, []int{1}).Return(map[int]models.ReactionSummary{
+					1: {Counts: map[string]int{"like": 3, "love": 1}, Mine: []string{"like"}},
+				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"id":1,"user_id":12,"title":"title","anons":"anons"}` + "\n",
+			expectedResponseBody: `{"id":1,"user_id":12,"title":"title","anons":"anons","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":null,"reactions":{"counts":{"like":3,"love":1},"mine":["like"]},"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}` + "\n",
+		},
+		{
+			name:       "html",
+			inputParam: 1,
+			query:      "?format=html",
+			mockBehavior: func(s *mockService.MockPost, r *mockService.MockReaction, id int) {
+				s.EXPECT().GetById(testPrincipal.Id, id).Return(models.Post{Id: 1, UserId: 12, Body: "# Hi\n\n<script>x()</script>*there*"}, nil)
+				r.EXPECT().GetReactionSummaries(testPrincipal.Id, models.ReactionTargetPost, []int{1}).Return(map[int]models.ReactionSummary{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"user_id":12,"title":"","anons":"","body":"\u003ch1\u003eHi\u003c/h1\u003e\n\n\u003cp\u003e\u003cem\u003ethere\u003c/em\u003e\u003c/p\u003e\n","word_count":3,"reading_time":1,"status":"","publish_at":null,"tags":null,"reactions":{"counts":null,"mine":null},"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}` + "\n",
+		},
+		{
+			name:                 "unknown format",
+			inputParam:           1,
+			query:                "?format=pdf",
+			mockBehavior:         func(s *mockService.MockPost, r *mockService.MockReaction, id int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"format must be html or markdown"}` + "\n",
 		},
 		{
 			name:       "error param",
 			inputParam: 1,
-			mockBehavior: func(s *mockService.MockPost, id int) {
-				s.EXPECT().GetById(id).Return(models.Post{}, errors.New("ID is incorrect."))
+			mockBehavior: func(s *mockService.MockPost, r *mockService.MockReaction, id int) {
+				s.EXPECT().GetById(testPrincipal.Id, id).Return(models.Post{}, errors.New("ID is incorrect."))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"ID is incorrect."}` + "\n",
@@ -212,19 +305,22 @@ func TestHandler_GetPostById(t *testing.T) {
 			defer c.Finish()
 
 			post := mockService.NewMockPost(c)
-			testCase.mockBehavior(post, testCase.inputParam)
+			reaction := mockService.NewMockReaction(c)
+			testCase.mockBehavior(post, reaction, testCase.inputParam)
 
-			services := &service.Service{Post: post}
+			services := &service.Service{Post: post, Reaction: reaction, Content: service.NewContentService()}
 			handler := NewHandler(services)
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
-			req := httptest.NewRequest(http.MethodGet, "/api/posts/:id", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/posts/1"+testCase.query, nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testPrincipal)
 			ctx.SetPath("/api/posts/:id")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues("1")
@@ -294,6 +390,16 @@ func TestHandler_PostPost(t *testing.T) {
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
 		},
+		{
+			name:               "Invalid fields",
+			paramUserId:        12,
+			inputBody:          `{"title":"","anons":"` + strings.Repeat("a", 5001) + `"}`,
+			mockBehavior:       func(s *mockService.MockPost, post models.Post) {},
+			expectedStatusCode: 422,
+			expectedResponseBody: `{"message":"validation failed","errors":[` +
+				`{"field":"title","rule":"required","message":"title is required"},` +
+				`{"field":"anons","rule":"max","param":"5000","message":"anons must be at most 5000 symbols"}]}` + "\n",
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -312,6 +418,7 @@ func TestHandler_PostPost(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodPost, "/api/posts",
@@ -352,7 +459,7 @@ func TestHandler_UpdatePost(t *testing.T) {
 				Anons: "new anons",
 			},
 			mockBehavior: func(s *mockService.MockPost, postId int, post models.Post) {
-				s.EXPECT().Update(postId, post).Return(nil)
+				s.EXPECT().Update(testPrincipal, postId, post).Return(nil)
 			},
 			expectedStatusCode:   202,
 			expectedResponseBody: `{"message":"Post with id 1 updated"}` + "\n",
@@ -366,7 +473,7 @@ func TestHandler_UpdatePost(t *testing.T) {
 				Anons: "test anons",
 			},
 			mockBehavior: func(s *mockService.MockPost, postId int, post models.Post) {
-				s.EXPECT().Update(postId, post).Return(errors.New("server error"))
+				s.EXPECT().Update(testPrincipal, postId, post).Return(errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}` + "\n",
@@ -402,6 +509,7 @@ func TestHandler_UpdatePost(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodPut, "/api/posts/:id",
@@ -409,6 +517,7 @@ func TestHandler_UpdatePost(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testPrincipal)
 			ctx.SetPath("/api/posts/:id")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues("1")
@@ -437,7 +546,7 @@ func TestHandler_DeletePost(t *testing.T) {
 			name:   "ok",
 			postId: 1,
 			mockBehavior: func(s *mockService.MockPost, postId int) {
-				s.EXPECT().Delete(postId).Return(nil)
+				s.EXPECT().Delete(testPrincipal, postId).Return(nil)
 			},
 			expectedStatusCode:   202,
 			expectedResponseBody: `{"message":"Post with id 1 deleted"}` + "\n",
@@ -446,7 +555,7 @@ func TestHandler_DeletePost(t *testing.T) {
 			name:   "server error",
 			postId: 1,
 			mockBehavior: func(s *mockService.MockPost, postId int) {
-				s.EXPECT().Delete(postId).Return(errors.New("server error"))
+				s.EXPECT().Delete(testPrincipal, postId).Return(errors.New("server error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"server error"}` + "\n",
@@ -469,12 +578,14 @@ func TestHandler_DeletePost(t *testing.T) {
 
 			//Тестовый сервер
 			e := echo.New()
+			e.Validator = NewValidator()
 
 			//Тестовый запрос
 			req := httptest.NewRequest(http.MethodDelete, "/api/posts/:id", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testPrincipal)
 			ctx.SetPath("/api/posts/:id")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues("1")
@@ -488,3 +599,315 @@ func TestHandler_DeletePost(t *testing.T) {
 	}
 
 }
+
+func TestHandler_PostOwnership(t *testing.T) {
+	type mockBehavior func(r *mockRepository.MockPost)
+
+	owner := service.Principal{Id: 12, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+	other := service.Principal{Id: 13, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+	moderator := service.Principal{Id: 14, Roles: []string{service.RoleModerator}, Scopes: []string{service.ScopeAll}}
+	stored := models.Post{Id: 1, UserId: 12, Title: "title", Anons: "anons"}
+
+	testTable := []struct {
+		name                 string
+		method               string
+		principal            service.Principal
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Owner updates",
+			method:    http.MethodPut,
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockPost) {
+				r.EXPECT().GetById(1).Return(stored, nil)
+				r.EXPECT().Update(1, models.PostRevision{UserId: 12, Title: "new title", Anons: "new anons"}, nil).Return(nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"message":"Post with id 1 updated"}` + "\n",
+		},
+		{
+			name:      "Other user updates",
+			method:    http.MethodPut,
+			principal: other,
+			mockBehavior: func(r *mockRepository.MockPost) {
+				r.EXPECT().GetById(1).Return(stored, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to update post 1"}` + "\n",
+		},
+		{
+			name:      "Other user deletes",
+			method:    http.MethodDelete,
+			principal: other,
+			mockBehavior: func(r *mockRepository.MockPost) {
+				r.EXPECT().GetById(1).Return(stored, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to delete post 1"}` + "\n",
+		},
+		{
+			name:      "Moderator updates",
+			method:    http.MethodPut,
+			principal: moderator,
+			mockBehavior: func(r *mockRepository.MockPost) {
+				r.EXPECT().GetById(1).Return(stored, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to update post 1"}` + "\n",
+		},
+		{
+			name:      "Moderator deletes",
+			method:    http.MethodDelete,
+			principal: moderator,
+			mockBehavior: func(r *mockRepository.MockPost) {
+				r.EXPECT().GetById(1).Return(stored, nil)
+				r.EXPECT().Delete(1, 14).Return(nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"message":"Post with id 1 deleted"}` + "\n",
+		},
+		{
+			name:      "Not found",
+			method:    http.MethodDelete,
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockPost) {
+				r.EXPECT().GetById(1).Return(models.Post{}, gorm.ErrRecordNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repos := mockRepository.NewMockPost(c)
+			testCase.mockBehavior(repos)
+
+			services := &service.Service{Post: service.NewPostService(repos, mockRepository.NewMockComment(c), repository.NewMemorySearchIndex())}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+
+			req := httptest.NewRequest(testCase.method, "/api/posts/:id",
+				strings.NewReader(`{"title":"new title","anons":"new anons"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testCase.principal)
+			ctx.SetPath("/api/posts/:id")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues("1")
+
+			var err error
+			if testCase.method == http.MethodPut {
+				err = handler.UpdatePost(ctx)
+			} else {
+				err = handler.DeletePost(ctx)
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RestorePost(t *testing.T) {
+	type mockBehavior func(r *mockRepository.MockPost, comments *mockRepository.MockComment)
+
+	owner := service.Principal{Id: 12, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+	other := service.Principal{Id: 13, Roles: []string{service.RoleModerator}, Scopes: []string{service.ScopeAll}}
+	admin := service.Principal{Id: 1, Roles: []string{service.RoleAdmin}, Scopes: []string{service.ScopeAll}}
+	deleted := models.Post{Id: 1, UserId: 12, Title: "title", Anons: "anons", Status: models.PostStatusPublished, DeletedBy: 12}
+	removed := deleted
+	removed.DeletedBy = 13
+
+	testTable := []struct {
+		name                 string
+		principal            service.Principal
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Owner restores",
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(deleted, nil)
+				r.EXPECT().Restore(1).Return(nil)
+				comments.EXPECT().Get(1, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"Post with id 1 restored"}` + "\n",
+		},
+		{
+			name:      "Admin restores",
+			principal: admin,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(deleted, nil)
+				r.EXPECT().Restore(1).Return(nil)
+				comments.EXPECT().Get(1, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"Post with id 1 restored"}` + "\n",
+		},
+		{
+			name:      "Owner restores removed by moderator",
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(removed, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to restore post 1"}` + "\n",
+		},
+		{
+			name:      "Admin restores removed by moderator",
+			principal: admin,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(removed, nil)
+				r.EXPECT().Restore(1).Return(nil)
+				comments.EXPECT().Get(1, gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"Post with id 1 restored"}` + "\n",
+		},
+		{
+			name:      "Other user restores",
+			principal: other,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(deleted, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to restore post 1"}` + "\n",
+		},
+		{
+			name:      "Not in trash",
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(models.Post{}, gorm.ErrRecordNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}` + "\n",
+		},
+		{
+			name:      "Repository Error",
+			principal: owner,
+			mockBehavior: func(r *mockRepository.MockPost, comments *mockRepository.MockComment) {
+				r.EXPECT().GetDeletedById(1).Return(deleted, nil)
+				r.EXPECT().Restore(1).Return(errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"server error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repos := mockRepository.NewMockPost(c)
+			comments := mockRepository.NewMockComment(c)
+			testCase.mockBehavior(repos, comments)
+
+			services := &service.Service{Post: service.NewPostService(repos, comments, repository.NewMemorySearchIndex())}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/api/posts/:id/restore", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testCase.principal)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+
+			if assert.NoError(t, handler.RestorePost(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_UpdatePostStatus(t *testing.T) {
+	type mockBehavior func(s *mockService.MockPost)
+
+	publishAt := time.Date(2030, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"status":"scheduled","publish_at":"2030-03-10T12:00:00Z"}`,
+			mockBehavior: func(s *mockService.MockPost) {
+				s.EXPECT().SetStatus(testPrincipal, 1, models.PostStatusScheduled, &publishAt).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"Post with id 1 is scheduled"}` + "\n",
+		},
+		{
+			name:                 "Scheduled without time",
+			inputBody:            `{"status":"scheduled"}`,
+			mockBehavior:         func(s *mockService.MockPost) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"publish_at","rule":"required_if","param":"Status scheduled","message":"publish_at is required when status is scheduled"}]}` + "\n",
+		},
+		{
+			name:      "Time passed",
+			inputBody: `{"status":"scheduled","publish_at":"2030-03-10T12:00:00Z"}`,
+			mockBehavior: func(s *mockService.MockPost) {
+				s.EXPECT().SetStatus(testPrincipal, 1, models.PostStatusScheduled, &publishAt).Return(service.ErrPublishAtPassed)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"publish_at must be in the future"}` + "\n",
+		},
+		{
+			name:      "Not found",
+			inputBody: `{"status":"archived"}`,
+			mockBehavior: func(s *mockService.MockPost) {
+				s.EXPECT().SetStatus(testPrincipal, 1, models.PostStatusArchived, nil).Return(service.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mockService.NewMockPost(c)
+			testCase.mockBehavior(post)
+
+			services := &service.Service{Post: post}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPut, "/api/posts/:id/status", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, testPrincipal)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+
+			if assert.NoError(t, handler.UpdatePostStatus(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
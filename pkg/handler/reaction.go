@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// GetReactionKinds godoc
+// @Summary     List reaction kinds
+// @Description Get the kinds of reactions: like and the configured emoji
+// @Tags        reactions
+// @Produce     json
+// @Success     200 {object} ReactionKindsResponse
+// @Router      /api/reactions [get]
+func (h *Handler) GetReactionKinds(c echo.Context) error {
+	errRes := c.JSON(http.StatusOK, ReactionKindsResponse{Kinds: h.services.Reaction.GetReactionKinds()})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetPostReactions godoc
+// @Summary     List who reacted to a post
+// @Description Get the reactions to a post, the latest first
+// @Tags        reactions
+// @Produce     json
+// @Param       id     path     int    true  "Post ID"
+// @Param       kind   query    string false "Reaction kind"
+// @Param       limit  query    int    false "Page size"
+// @Param       cursor query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success     200 {object} GetReactionsResponse
+// @Failure 	400 {object} ErrorResponse	 "unknown reaction"
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/posts/{id}/reactions [get]
+func (h *Handler) GetPostReactions(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	return h.getReactions(c, service.PostTarget(id))
+}
+
+// ReactToPost godoc
+// @Summary      React to a post
+// @Description  add a reaction of the user to a post; a user reacts at most once per kind
+// @Tags         reactions
+// @Accept       json
+// @Produce      json
+// @Param        id        path     int              true  "Post ID"
+// @Param        reaction  body     ReactionRequest  true  "Reaction kind"
+// @Success      200 	{object} models.ReactionSummary "reactions to the post"
+// @Failure 	 400 	{object} ErrorResponse	 "unknown reaction"
+// @Failure 	 404 	{object} ErrorResponse	 "post not found"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/posts/{id}/reactions [post]
+func (h *Handler) ReactToPost(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	return h.react(c, service.PostTarget(id))
+}
+
+// UnreactToPost godoc
+// @Summary      Remove a reaction from a post
+// @Description  remove a reaction of the user from a post
+// @Tags         reactions
+// @Produce      json
+// @Param        id    path     int     true  "Post ID"
+// @Param        kind  path     string  true  "Reaction kind"
+// @Success      200 	{object} models.ReactionSummary "reactions to the post"
+// @Failure 	 400 	{object} ErrorResponse	 "unknown reaction"
+// @Failure 	 404 	{object} ErrorResponse	 "post not found"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/posts/{id}/reactions/{kind} [delete]
+func (h *Handler) UnreactToPost(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	return h.unreact(c, service.PostTarget(id))
+}
+
+// GetCommentReactions godoc
+// @Summary     List who reacted to a comment
+// @Description Get the reactions to a comment, the latest first
+// @Tags        reactions
+// @Produce     json
+// @Param       postId path     int    true  "Post ID"
+// @Param       id     path     int    true  "Comment ID"
+// @Param       kind   query    string false "Reaction kind"
+// @Param       limit  query    int    false "Page size"
+// @Param       cursor query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success     200 {object} GetReactionsResponse
+// @Failure 	400 {object} ErrorResponse	 "unknown reaction"
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	404 {object} ErrorResponse	 "comment not found"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/posts/{postId}/comments/{id}/reactions [get]
+func (h *Handler) GetCommentReactions(c echo.Context) error {
+	target, errParams := getCommentTarget(c)
+	if errParams != nil {
+		return nil
+	}
+	return h.getReactions(c, target)
+}
+
+// ReactToComment godoc
+// @Summary      React to a comment
+// @Description  add a reaction of the user to a comment; a user reacts at most once per kind
+// @Tags         reactions
+// @Accept       json
+// @Produce      json
+// @Param        postId    path     int              true  "Post ID"
+// @Param        id        path     int              true  "Comment ID"
+// @Param        reaction  body     ReactionRequest  true  "Reaction kind"
+// @Success      200 	{object} models.ReactionSummary "reactions to the comment"
+// @Failure 	 400 	{object} ErrorResponse	 "unknown reaction"
+// @Failure 	 404 	{object} ErrorResponse	 "comment not found"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/posts/{postId}/comments/{id}/reactions [post]
+func (h *Handler) ReactToComment(c echo.Context) error {
+	target, errParams := getCommentTarget(c)
+	if errParams != nil {
+		return nil
+	}
+	return h.react(c, target)
+}
+
+// UnreactToComment godoc
+// @Summary      Remove a reaction from a comment
+// @Description  remove a reaction of the user from a comment
+// @Tags         reactions
+// @Produce      json
+// @Param        postId  path     int     true  "Post ID"
+// @Param        id      path     int     true  "Comment ID"
+// @Param        kind    path     string  true  "Reaction kind"
+// @Success      200 	{object} models.ReactionSummary "reactions to the comment"
+// @Failure 	 400 	{object} ErrorResponse	 "unknown reaction"
+// @Failure 	 404 	{object} ErrorResponse	 "comment not found"
+// @Failure 	 500 	{object} ErrorResponse	 "server error"
+// @Router       /api/posts/{postId}/comments/{id}/reactions/{kind} [delete]
+func (h *Handler) UnreactToComment(c echo.Context) error {
+	target, errParams := getCommentTarget(c)
+	if errParams != nil {
+		return nil
+	}
+	return h.unreact(c, target)
+}
+
+func (h *Handler) getReactions(c echo.Context, target service.ReactionTarget) error {
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+
+	reactions, next, err := h.services.Reaction.GetReactions(GetViewerId(c), target, c.QueryParam("kind"), page)
+	if writeReactionError(c, err) {
+		return nil
+	}
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, GetReactionsResponse{Reactions: reactions, NextCursor: next})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+func (h *Handler) react(c echo.Context, target service.ReactionTarget) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+
+	var input ReactionRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	summary, err := h.services.Reaction.React(userId, target, input.Kind)
+	if writeReactionError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, summary)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+func (h *Handler) unreact(c echo.Context, target service.ReactionTarget) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+
+	summary, err := h.services.Reaction.Unreact(userId, target, c.Param(ParamKind))
+	if writeReactionError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, summary)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+func getCommentTarget(c echo.Context) (service.ReactionTarget, error) {
+	postId, err := GetParam(c, ParamPostId)
+	if err != nil {
+		return service.ReactionTarget{}, err
+	}
+	id, err := GetParam(c, ParamId)
+	if err != nil {
+		return service.ReactionTarget{}, err
+	}
+	return service.CommentTarget(postId, id), nil
+}
+
+// writeReactionError writes the response for the errors of the reaction service
+// and reports whether it did.
+func writeReactionError(c echo.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrInvalidReaction):
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrPostNotFound), errors.Is(err, service.ErrCommentNotFound):
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_ReactToPost(t *testing.T) {
+	type mockBehavior func(s *mockService.MockReaction)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"kind":"like"}`,
+			mockBehavior: func(s *mockService.MockReaction) {
+				s.EXPECT().React(3, service.PostTarget(1), "like").
+					Return(models.ReactionSummary{Counts: map[string]int{"like": 2}, Mine: []string{"like"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"counts":{"like":2},"mine":["like"]}` + "\n",
+		},
+		{
+			name:      "Unknown reaction",
+			inputBody: `{"kind":"meh"}`,
+			mockBehavior: func(s *mockService.MockReaction) {
+				s.EXPECT().React(3, service.PostTarget(1), "meh").Return(models.ReactionSummary{}, service.ErrInvalidReaction)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"unknown reaction"}` + "\n",
+		},
+		{
+			name:      "Post not found",
+			inputBody: `{"kind":"like"}`,
+			mockBehavior: func(s *mockService.MockReaction) {
+				s.EXPECT().React(3, service.PostTarget(1), "like").Return(models.ReactionSummary{}, service.ErrPostNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"post not found"}` + "\n",
+		},
+		{
+			name:                 "No kind",
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mockService.MockReaction) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"kind","rule":"required","message":"kind is required"}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reactions := mockService.NewMockReaction(c)
+			testCase.mockBehavior(reactions)
+			handler := NewHandler(&service.Service{Reaction: reactions})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/posts/:id/reactions", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+			ctx.Set(userCtx, 3)
+
+			if assert.NoError(t, handler.ReactToPost(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
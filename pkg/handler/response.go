@@ -1,16 +1,44 @@
 package handler
 
 import (
+	"errors"
 	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
 	"test/pkg/repository/models"
+	"test/pkg/service"
+	"time"
 )
 
 type GetPostsResponse struct {
-	Posts []models.Post `json:"posts"`
+	Posts      []models.Post `json:"posts"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 type GetCommentsResponse struct {
-	Comments []models.Comment `json:"comments"`
+	Comments   []models.Comment `json:"comments"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type CommentThreadRequest struct {
+	View    string `query:"view" json:"view" validate:"omitempty,oneof=flat tree"`
+	Parent  int    `query:"parent" json:"parent" validate:"min=0"`
+	Depth   int    `query:"depth" json:"depth" validate:"min=0,max=20"`
+	Replies int    `query:"replies" json:"replies" validate:"min=0,max=100"`
+}
+
+type GetRevisionsResponse struct {
+	Revisions  []models.PostRevision `json:"revisions"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+type DiffRequest struct {
+	From int `query:"from" json:"from" validate:"required,min=1"`
+	To   int `query:"to" json:"to" validate:"required,min=1"`
+}
+
+type GetMediaResponse struct {
+	Media []models.Media `json:"media"`
 }
 
 type MessageResponse struct {
@@ -18,30 +46,243 @@ type MessageResponse struct {
 }
 
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// TwoFactorChallengeResponse is returned by sign-in instead of tokens
+// when the user has two-factor authentication enabled.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 type IdResponse struct {
 	Id int `json:"id"`
 }
 
+// PostRequest replaces the tags of a post when they are given.
 type PostRequest struct {
-	Title string `json:"title" form:"title" binding:"required"`
-	Anons string `json:"anons" form:"anons" binding:"required"`
+	Title string   `json:"title" form:"title" validate:"required,max=255"`
+	Anons string   `json:"anons" form:"anons" validate:"required,max=5000"`
+	Body  string   `json:"body" form:"body" validate:"max=100000"`
+	Tags  []string `json:"tags" validate:"omitempty,max=10,dive,max=64"`
+}
+
+type CreatePostRequest struct {
+	PostRequest
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time `json:"publish_at" validate:"required_if=Status scheduled"`
+}
+
+type PostStatusRequest struct {
+	Status    string     `json:"status" validate:"required,oneof=draft scheduled published archived"`
+	PublishAt *time.Time `json:"publish_at" validate:"required_if=Status scheduled"`
 }
 
 type CommentRequest struct {
-	Body string `json:"body"  binding:"required"`
+	Body     string `json:"body" validate:"required,max=2000"`
+	ParentId int    `json:"parent_id" validate:"min=0"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type ApiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ApiKeyResponse struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateApiKeyResponse is the only response that contains the key itself.
+type CreateApiKeyResponse struct {
+	Key string `json:"key"`
+	ApiKeyResponse
+}
+
+type GetApiKeysResponse struct {
+	Keys []ApiKeyResponse `json:"keys"`
+}
+
+type SessionResponse struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+type GetSessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// PublicUserResponse holds the fields anybody may see; the username is left out, an external login may have set it to an email.
+type PublicUserResponse struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Bio       string `json:"bio"`
+	AvatarUrl string `json:"avatar_url"`
+}
+
+type MeResponse struct {
+	PublicUserResponse
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+}
+
+type ProfileRequest struct {
+	Name      *string `json:"name" validate:"omitempty,max=100"`
+	Bio       *string `json:"bio" validate:"omitempty,max=500"`
+	AvatarUrl *string `json:"avatar_url" validate:"omitempty,url,max=500"`
+}
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=128"`
+}
+
+type GetTagsResponse struct {
+	Tags       []models.TagCount `json:"tags"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type TagSuggestRequest struct {
+	Query string `query:"q" json:"q" validate:"required,max=64"`
+	Limit int    `query:"limit" json:"limit" validate:"omitempty,min=1"`
+}
+
+type TagSuggestionsResponse struct {
+	Tags []models.Tag `json:"tags"`
+}
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type MergeTagsRequest struct {
+	Into int `json:"into" validate:"required,min=1"`
+}
+
+type ReactionRequest struct {
+	Kind string `json:"kind" validate:"required,max=32"`
+}
+
+type GetReactionsResponse struct {
+	Reactions  []models.Reaction `json:"reactions"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type ReactionKindsResponse struct {
+	Kinds []string `json:"kinds"`
+}
+
+type SearchRequest struct {
+	Query string `query:"q" json:"q" validate:"required,max=200"`
+	Limit int    `query:"limit" json:"limit" validate:"omitempty,min=1"`
+}
+
+type SearchResponse struct {
+	Results []service.SearchResult `json:"results"`
 }
 
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// UserResponse is the sign-up request.
 type UserResponse struct {
-	Name     string `json:"name" form:"name" binding:"required"`
-	Username string `json:"username" form:"username"  binding:"required"`
-	Password string `json:"password" gorm:"column:password_hash" form:"password"  binding:"required"`
+	Name     string `json:"name" form:"name" validate:"required,max=100"`
+	Email    string `json:"email" form:"email" validate:"omitempty,email,max=255"`
+	Username string `json:"username" form:"username" validate:"required,max=64"`
+	Password string `json:"password" form:"password" validate:"required,min=6,max=128"`
+}
+
+func NewTokenResponse(tokens service.Tokens) TokenResponse {
+	return TokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}
+}
+
+func NewApiKeyResponse(key models.ApiKey) ApiKeyResponse {
+	return ApiKeyResponse{
+		Id:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     strings.Split(key.Scopes, ","),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func NewPublicUserResponse(user models.User) PublicUserResponse {
+	return PublicUserResponse{
+		Id:        user.Id,
+		Name:      user.Name,
+		Bio:       user.Bio,
+		AvatarUrl: user.AvatarUrl,
+	}
+}
+
+func NewMeResponse(user models.User) MeResponse {
+	return MeResponse{
+		PublicUserResponse: NewPublicUserResponse(user),
+		Username:           user.Username,
+		Email:              user.Email,
+		EmailVerified:      user.EmailVerified,
+		Role:               user.Role,
+	}
+}
+
+// writePageError writes the response for a failed page request.
+func writePageError(c echo.Context, err error) {
+	if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSort) {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
 }
 
 func NewErrorResponse(c echo.Context, statusCode int, message string) {
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// GetRevisions godoc
+// @Summary     List revisions of a post
+// @Description Get the saved states of a post, shown to the author, moderators and admins
+// @Tags        revisions
+// @Produce     json
+// @Param       id      path     int    true  "Post ID"
+// @Param       sort    query    string false "Sort fields: number, id; prefix - for descending" default(-number)
+// @Param       limit   query    int    false "Page size"
+// @Param       cursor  query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success     200 {object} GetRevisionsResponse
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	400 {object} ErrorResponse	 "invalid sort"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to view revisions of post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/posts/{id}/revisions [get]
+func (h *Handler) GetRevisions(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+
+	revisions, next, err := h.services.Post.GetRevisions(principal, id, page)
+	if writePostError(c, err) {
+		return nil
+	}
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, GetRevisionsResponse{Revisions: revisions, NextCursor: next})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetRevision godoc
+// @Summary     Find a revision of a post
+// @Description Get a saved state of a post by its number
+// @Tags        revisions
+// @Produce     json
+// @Param       id      path     int true "Post ID"
+// @Param       number  path     int true "Revision number"
+// @Success     200 {object} models.PostRevision
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	400 {object} ErrorResponse	 "number is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to view revisions of post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	404 {object} ErrorResponse	 "revision not found"
+// @Failure 	500 {object} ErrorResponse	 "server error"
+// @Router      /api/posts/{id}/revisions/{number} [get]
+func (h *Handler) GetRevision(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	number, errNumber := GetParam(c, ParamNumber)
+	if errNumber != nil {
+		return nil
+	}
+
+	revision, err := h.services.Post.GetRevision(principal, id, number)
+	if writePostError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, revision)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// DiffRevisions godoc
+// @Summary     Compare two revisions of a post
+// @Description Get the fields that differ between two revisions with a word diff of each
+// @Tags        revisions
+// @Produce     json
+// @Param       id    path     int true "Post ID"
+// @Param       from  query    int true "Number of the older revision"
+// @Param       to    query    int true "Number of the newer revision"
+// @Success     200 {object} service.RevisionDiff
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to view revisions of post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	404 {object} ErrorResponse	 "revision not found"
+// @Failure 	422 {object} ValidationErrorResponse "validation failed"
+// @Failure 	500 {object} ErrorResponse	 "server error"
+// @Router      /api/posts/{id}/revisions/diff [get]
+func (h *Handler) DiffRevisions(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	var input DiffRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	diff, err := h.services.Post.DiffRevisions(principal, id, input.From, input.To)
+	if writePostError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, diff)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// RevertPost godoc
+// @Summary     Revert a post to a revision
+// @Description Set the title, anons and body of a post back to a past revision, saved as a new revision
+// @Tags        revisions
+// @Produce     json
+// @Param       id      path     int true "Post ID"
+// @Param       number  path     int true "Revision number"
+// @Success     200 {object} MessageResponse	"Post with id # reverted to revision #"
+// @Failure 	400 {object} ErrorResponse	 "id is not integer"
+// @Failure 	400 {object} ErrorResponse	 "number is not integer"
+// @Failure 	403 {object} ErrorResponse	 "user is not allowed to update post #"
+// @Failure 	404 {object} ErrorResponse	 "post not found"
+// @Failure 	404 {object} ErrorResponse	 "revision not found"
+// @Failure 	500 {object} ErrorResponse	 "server error"
+// @Router      /api/posts/{id}/revisions/{number}/revert [post]
+func (h *Handler) RevertPost(c echo.Context) error {
+	principal, errPrincipal := GetPrincipal(c)
+	if errPrincipal != nil {
+		return nil
+	}
+
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	number, errNumber := GetParam(c, ParamNumber)
+	if errNumber != nil {
+		return nil
+	}
+
+	err := h.services.Post.Revert(principal, id, number)
+	if writePostError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{
+		Message: fmt.Sprintf("Post with id %d reverted to revision %d", id, number),
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_DiffRevisions(t *testing.T) {
+	type mockBehavior func(s *mockService.MockPost, principal service.Principal)
+
+	principal := service.Principal{Id: 12, Roles: []string{service.RoleUser}, Scopes: []string{service.ScopeAll}}
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "ok",
+			query: "?from=1&to=2",
+			mockBehavior: func(s *mockService.MockPost, principal service.Principal) {
+				s.EXPECT().DiffRevisions(principal, 1, 1, 2).Return(service.RevisionDiff{From: 1, To: 2, Fields: []service.FieldDiff{{
+					Field: "title", From: "old", To: "new",
+					Changes: []service.DiffChange{{Op: service.DiffDelete, Text: "old"}, {Op: service.DiffInsert, Text: "new"}},
+				}}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"from":1,"to":2,"fields":[{"field":"title","from":"old","to":"new",` +
+				`"changes":[{"op":"delete","text":"old"},{"op":"insert","text":"new"}]}]}` + "\n",
+		},
+		{
+			name:                 "Missing revision number",
+			query:                "?from=1",
+			mockBehavior:         func(s *mockService.MockPost, principal service.Principal) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"to","rule":"required","message":"to is required"}]}` + "\n",
+		},
+		{
+			name:  "Revision not found",
+			query: "?from=1&to=7",
+			mockBehavior: func(s *mockService.MockPost, principal service.Principal) {
+				s.EXPECT().DiffRevisions(principal, 1, 1, 7).Return(service.RevisionDiff{}, service.ErrRevisionNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"revision not found"}` + "\n",
+		},
+		{
+			name:  "Forbidden",
+			query: "?from=1&to=2",
+			mockBehavior: func(s *mockService.MockPost, principal service.Principal) {
+				s.EXPECT().DiffRevisions(principal, 1, 1, 2).Return(service.RevisionDiff{},
+					&service.ForbiddenError{Action: "view revisions of", Resource: "post", Id: 1})
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"user is not allowed to view revisions of post 1"}` + "\n",
+		},
+		{
+			name:  "Service Error",
+			query: "?from=1&to=2",
+			mockBehavior: func(s *mockService.MockPost, principal service.Principal) {
+				s.EXPECT().DiffRevisions(principal, 1, 1, 2).Return(service.RevisionDiff{}, errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"server error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			post := mockService.NewMockPost(c)
+			testCase.mockBehavior(post, principal)
+
+			services := &service.Service{Post: post}
+			handler := NewHandler(services)
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/diff"+testCase.query, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(principalCtx, principal)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+
+			if assert.NoError(t, handler.DiffRevisions(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// Search godoc
+// @Summary      Search posts and comments
+// @Description  full-text search in post titles, anons and comment bodies, best matches first; matched words are wrapped in <mark>
+// @Tags         search
+// @Produce      json
+// @Param        q      query    string true  "Search query"
+// @Param        limit  query    int    false "Number of results"
+// @Success      200 	{object} SearchResponse  "results"
+// @Failure 	 400 	{object} ErrorResponse	 "query must contain a word"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/search [get]
+func (h *Handler) Search(c echo.Context) error {
+	var input SearchRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	results, err := h.services.Search.Search(input.Query, input.Limit)
+	if errors.Is(err, service.ErrInvalidQuery) {
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, SearchResponse{Results: results})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
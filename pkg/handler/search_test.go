@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_Search(t *testing.T) {
+	type mockBehavior func(s *mockService.MockSearch)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "ok",
+			query: "?q=pasta&limit=5",
+			mockBehavior: func(s *mockService.MockSearch) {
+				s.EXPECT().Search("pasta", 5).Return([]service.SearchResult{
+					{Kind: "post", Id: 1, PostId: 1, Title: "Cooking <mark>pasta</mark>", Snippet: "Boil water", Score: 1.5},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"results":[{"kind":"post","id":1,"post_id":1,` +
+				`"title":"Cooking \u003cmark\u003epasta\u003c/mark\u003e","snippet":"Boil water","score":1.5}]}` + "\n",
+		},
+		{
+			name:                 "Empty query",
+			query:                "?q=",
+			mockBehavior:         func(s *mockService.MockSearch) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"q","rule":"required","message":"q is required"}]}` + "\n",
+		},
+		{
+			name:  "No words",
+			query: "?q=%3F%3F",
+			mockBehavior: func(s *mockService.MockSearch) {
+				s.EXPECT().Search("??", 0).Return(nil, service.ErrInvalidQuery)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"query must contain a word"}` + "\n",
+		},
+		{
+			name:  "Service Error",
+			query: "?q=pasta",
+			mockBehavior: func(s *mockService.MockSearch) {
+				s.EXPECT().Search("pasta", 0).Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			search := mockService.NewMockSearch(c)
+			testCase.mockBehavior(search)
+			handler := NewHandler(&service.Service{Search: search})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodGet, "/api/search"+testCase.query, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.Search(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
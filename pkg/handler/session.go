@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// GetSessions godoc
+// @Summary      List active sessions
+// @Description  get the devices the user is signed in on
+// @Tags         sessions
+// @Produce      json
+// @Success      200 	{object} GetSessionsResponse "sessions of the user"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me/sessions [get]
+func (h *Handler) GetSessions(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	sessions, err := h.services.Session.GetSessions(userId)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	current := currentSessionId(c)
+	response := GetSessionsResponse{Sessions: make([]SessionResponse, 0, len(sessions))}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, SessionResponse{
+			Id:         session.Id,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.Id == current,
+		})
+	}
+	errRes := c.JSON(http.StatusOK, response)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// RevokeSession godoc
+// @Summary      Revoke a session
+// @Description  sign out the device of the session
+// @Tags         sessions
+// @Produce      json
+// @Param        sessionId  path     string  true  "Session ID"
+// @Success      200 	{object} MessageResponse "session revoked"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 404 	{object} ErrorResponse	 "session not found"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me/sessions/{sessionId} [delete]
+func (h *Handler) RevokeSession(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	err := h.services.Session.RevokeSession(userId, c.Param(ParamSessionId))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "session revoked"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// RevokeOtherSessions godoc
+// @Summary      Revoke other sessions
+// @Description  sign out every device except the current one
+// @Tags         sessions
+// @Produce      json
+// @Success      200 	{object} MessageResponse "# sessions revoked"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me/sessions [delete]
+func (h *Handler) RevokeOtherSessions(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	revoked, err := h.services.Session.RevokeOtherSessions(userId, currentSessionId(c))
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: fmt.Sprintf("%d sessions revoked", revoked)})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// currentSessionId is the session of the access token; requests made with an API key have none.
+func currentSessionId(c echo.Context) string {
+	claims, ok := c.Get(claimsCtx).(*service.TokenClaims)
+	if !ok {
+		return ""
+	}
+	return claims.SessionId
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestHandler_GetSessions(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	sessions := mockService.NewMockSession(c)
+	sessions.EXPECT().GetSessions(3).Return([]models.Session{
+		{Id: "sid", UserId: 3, UserAgent: "curl", IP: "10.0.0.1", CreatedAt: at, LastSeenAt: at},
+		{Id: "other", UserId: 3, UserAgent: "phone", IP: "10.0.0.2", CreatedAt: at, LastSeenAt: at},
+	}, nil)
+	handler := NewHandler(&service.Service{Session: sessions})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me/sessions", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Set(userCtx, 3)
+	ctx.Set(claimsCtx, &service.TokenClaims{UserId: 3, SessionId: "sid"})
+
+	if assert.NoError(t, handler.GetSessions(ctx)) {
+		assert.Equal(t, 200, rec.Code)
+		assert.Equal(t, `{"sessions":[`+
+			`{"id":"sid","user_agent":"curl","ip":"10.0.0.1","created_at":"2022-01-01T00:00:00Z","last_seen_at":"2022-01-01T00:00:00Z","current":true},`+
+			`{"id":"other","user_agent":"phone","ip":"10.0.0.2","created_at":"2022-01-01T00:00:00Z","last_seen_at":"2022-01-01T00:00:00Z","current":false}]}`+"\n",
+			rec.Body.String())
+	}
+}
+
+func TestHandler_RevokeSession(t *testing.T) {
+	type mockBehavior func(s *mockService.MockSession)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(s *mockService.MockSession) {
+				s.EXPECT().RevokeSession(3, "other").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"session revoked"}` + "\n",
+		},
+		{
+			name: "Not found",
+			mockBehavior: func(s *mockService.MockSession) {
+				s.EXPECT().RevokeSession(3, "other").Return(service.ErrSessionNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"session not found"}` + "\n",
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mockService.MockSession) {
+				s.EXPECT().RevokeSession(3, "other").Return(errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			sessions := mockService.NewMockSession(c)
+			testCase.mockBehavior(sessions)
+			handler := NewHandler(&service.Service{Session: sessions})
+
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/api/users/me/sessions/:sessionId", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+			ctx.SetParamNames(ParamSessionId)
+			ctx.SetParamValues("other")
+
+			if assert.NoError(t, handler.RevokeSession(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RevokeOtherSessions(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	sessions := mockService.NewMockSession(c)
+	sessions.EXPECT().RevokeOtherSessions(3, "sid").Return(2, nil)
+	handler := NewHandler(&service.Service{Session: sessions})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodDelete, "/api/users/me/sessions", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Set(userCtx, 3)
+	ctx.Set(claimsCtx, &service.TokenClaims{UserId: 3, SessionId: "sid"})
+
+	if assert.NoError(t, handler.RevokeOtherSessions(ctx)) {
+		assert.Equal(t, 200, rec.Code)
+		assert.Equal(t, `{"message":"2 sessions revoked"}`+"\n", rec.Body.String())
+	}
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// GetTags godoc
+// @Summary     List tags
+// @Description Get the tags with the number of their published posts
+// @Tags        tags
+// @Produce     json
+// @Param       sort   query    string false "Sort fields: posts, name, id; prefix - for descending" default(-posts)
+// @Param       limit  query    int    false "Page size"
+// @Param       cursor query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success     200 {object} GetTagsResponse
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	400 {object} ErrorResponse	 "invalid sort"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/tags [get]
+func (h *Handler) GetTags(c echo.Context) error {
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+
+	tags, next, err := h.services.Tag.GetTags(page)
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, GetTagsResponse{Tags: tags, NextCursor: next})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// SuggestTags godoc
+// @Summary     Autocomplete tag names
+// @Description Get the tags whose names start with the query, in alphabetical order
+// @Tags        tags
+// @Produce     json
+// @Param       q     query    string true  "Beginning of the tag name"
+// @Param       limit query    int    false "Number of tags"
+// @Success     200 {object} TagSuggestionsResponse
+// @Failure 	422 {object} ValidationErrorResponse "validation failed"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/tags/suggest [get]
+func (h *Handler) SuggestTags(c echo.Context) error {
+	var input TagSuggestRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	tags, err := h.services.Tag.SuggestTags(input.Query, input.Limit)
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, TagSuggestionsResponse{Tags: tags})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetTagPosts godoc
+// @Summary     Find the posts of a tag
+// @Description Get the posts that have the tag; accepts the same filters as the list of posts
+// @Tags        tags
+// @Produce     json
+// @Param       name         path     string true  "Tag name"
+// @Param       author       query    int    false "Author ID"
+// @Param       created_from query    string false "Created at or after, RFC 3339 time or date"
+// @Param       created_to   query    string false "Created before, RFC 3339 time or date (inclusive)"
+// @Param       title        query    string false "Title contains"
+// @Param       format       query    string false "Format of the bodies: markdown or html" default(markdown)
+// @Param       sort         query    string false "Sort fields: created_at, title, id; prefix - for descending" default(-created_at)
+// @Param       limit        query    int    false "Page size"
+// @Param       cursor       query    string false "Cursor of the page, next_cursor of the previous one"
+// @Success     200 {object} GetPostsResponse
+// @Failure 	400 {object} ErrorResponse	 "invalid cursor"
+// @Failure 	400 {object} ErrorResponse	 "invalid sort"
+// @Failure 	400 {object} ErrorResponse	 "format must be html or markdown"
+// @Failure 	404 {object} ErrorResponse	 "tag not found"
+// @Failure 	500 {object} ErrorResponse	 "something went wrong"
+// @Router      /api/tags/{name}/posts [get]
+func (h *Handler) GetTagPosts(c echo.Context) error {
+	filter, errFilter := GetPostFilter(c)
+	if errFilter != nil {
+		return nil
+	}
+	page, errPage := GetPageRequest(c)
+	if errPage != nil {
+		return nil
+	}
+	format, errFormat := GetFormat(c)
+	if errFormat != nil {
+		return nil
+	}
+
+	tag, err := h.services.Tag.GetTag(c.Param(ParamName))
+	if writeTagError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	filter.Tag = tag.Name
+
+	posts, next, err := h.services.Post.Get(filter, page)
+	if err != nil {
+		writePageError(c, err)
+		return nil
+	}
+	h.services.Content.PreparePosts(posts, format)
+	errRes := c.JSON(http.StatusOK, GetPostsResponse{Posts: posts, NextCursor: next})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// RenameTag godoc
+// @Summary      Rename a tag
+// @Description  rename a tag on all of its posts; to give it the name of another tag, merge them
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path     int         true  "Tag ID"
+// @Param        tag   body     TagRequest  true  "New name"
+// @Success      200   {object} models.Tag
+// @Failure 	 400   {object} ErrorResponse	 "tag name must not be empty"
+// @Failure 	 403   {object} ErrorResponse	 "permission denied"
+// @Failure 	 404   {object} ErrorResponse	 "tag not found"
+// @Failure 	 409   {object} ErrorResponse	 "tag already exists, merge the tags instead"
+// @Failure 	 422   {object} ValidationErrorResponse "validation failed"
+// @Failure 	 500   {object} ErrorResponse	 "server error"
+// @Router       /api/admin/tags/{id} [put]
+func (h *Handler) RenameTag(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	var input TagRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	tag, err := h.services.Tag.RenameTag(id, input.Name)
+	if writeTagError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, tag)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// MergeTags godoc
+// @Summary      Merge tags
+// @Description  move the posts of a tag to another tag and delete the tag
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id    path     int               true  "ID of the tag to merge"
+// @Param        into  body     MergeTagsRequest  true  "ID of the tag that is kept"
+// @Success      200   {object} models.Tag      "the tag that is kept"
+// @Failure 	 400   {object} ErrorResponse	 "tag cannot be merged into itself"
+// @Failure 	 403   {object} ErrorResponse	 "permission denied"
+// @Failure 	 404   {object} ErrorResponse	 "tag not found"
+// @Failure 	 422   {object} ValidationErrorResponse "validation failed"
+// @Failure 	 500   {object} ErrorResponse	 "server error"
+// @Router       /api/admin/tags/{id}/merge [post]
+func (h *Handler) MergeTags(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+
+	var input MergeTagsRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+
+	tag, err := h.services.Tag.MergeTags(id, input.Into)
+	if writeTagError(c, err) {
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "server error")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, tag)
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// writeTagError writes the response for the errors of the tag service
+// and reports whether it did.
+func writeTagError(c echo.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrTagNotFound):
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrTagExists):
+		NewErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidTag), errors.Is(err, service.ErrMergeIntoSelf):
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_GetTagPosts(t *testing.T) {
+	type mockBehavior func(tags *mockService.MockTag, posts *mockService.MockPost)
+
+	testTable := []struct {
+		name                 string
+		tag                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok",
+			tag:  "Go",
+			mockBehavior: func(tags *mockService.MockTag, posts *mockService.MockPost) {
+				tags.EXPECT().GetTag("Go").Return(models.Tag{Id: 2, Name: "go"}, nil)
+				posts.EXPECT().Get(service.PostFilter{Tag: "go"}, service.PageRequest{}).
+					Return([]models.Post{{Id: 1, UserId: 12, Title: "title", Anons: "anons", Tags: []string{"go"}}}, "", nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"posts":[{"id":1,"user_id":12,"title":"title","anons":"anons","body":"","word_count":0,"reading_time":0,"status":"","publish_at":null,"tags":["go"],` +
+				`"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":null}]}` + "\n",
+		},
+		{
+			name: "Unknown tag",
+			tag:  "cobol",
+			mockBehavior: func(tags *mockService.MockTag, posts *mockService.MockPost) {
+				tags.EXPECT().GetTag("cobol").Return(models.Tag{}, service.ErrTagNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"tag not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tags := mockService.NewMockTag(c)
+			posts := mockService.NewMockPost(c)
+			testCase.mockBehavior(tags, posts)
+			handler := NewHandler(&service.Service{Tag: tags, Post: posts, Content: service.NewContentService()})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodGet, "/api/tags/"+testCase.tag+"/posts", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamName)
+			ctx.SetParamValues(testCase.tag)
+
+			if assert.NoError(t, handler.GetTagPosts(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_RenameTag(t *testing.T) {
+	type mockBehavior func(s *mockService.MockTag)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"name":"Go"}`,
+			mockBehavior: func(s *mockService.MockTag) {
+				s.EXPECT().RenameTag(1, "Go").Return(models.Tag{Id: 1, Name: "go"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1,"name":"go"}` + "\n",
+		},
+		{
+			name:      "Name taken",
+			inputBody: `{"name":"rust"}`,
+			mockBehavior: func(s *mockService.MockTag) {
+				s.EXPECT().RenameTag(1, "rust").Return(models.Tag{}, service.ErrTagExists)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"tag already exists, merge the tags instead"}` + "\n",
+		},
+		{
+			name:                 "No name",
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mockService.MockTag) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"name","rule":"required","message":"name is required"}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tags := mockService.NewMockTag(c)
+			testCase.mockBehavior(tags)
+			handler := NewHandler(&service.Service{Tag: tags})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/tags/:id", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+
+			if assert.NoError(t, handler.RenameTag(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_MergeTags(t *testing.T) {
+	type mockBehavior func(s *mockService.MockTag)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"into":2}`,
+			mockBehavior: func(s *mockService.MockTag) {
+				s.EXPECT().MergeTags(1, 2).Return(models.Tag{Id: 2, Name: "go"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":2,"name":"go"}` + "\n",
+		},
+		{
+			name:      "Into itself",
+			inputBody: `{"into":1}`,
+			mockBehavior: func(s *mockService.MockTag) {
+				s.EXPECT().MergeTags(1, 1).Return(models.Tag{}, service.ErrMergeIntoSelf)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"tag cannot be merged into itself"}` + "\n",
+		},
+		{
+			name:      "Unknown tag",
+			inputBody: `{"into":9}`,
+			mockBehavior: func(s *mockService.MockTag) {
+				s.EXPECT().MergeTags(1, 9).Return(models.Tag{}, service.ErrTagNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"tag not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tags := mockService.NewMockTag(c)
+			testCase.mockBehavior(tags)
+			handler := NewHandler(&service.Service{Tag: tags})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/tags/:id/merge", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues("1")
+
+			if assert.NoError(t, handler.MergeTags(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// VerifyTwoFactor godoc
+// @Summary      Finish a two-factor sign-in
+// @Description  exchange the challenge token of sign-in and a TOTP or recovery code for tokens
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input	body     TwoFactorVerifyRequest  true  "Challenge token and code"
+// @Success      200 	{object} TokenResponse   "result is user token"
+// @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
+// @Failure 	 401 	{object} ErrorResponse	 "invalid two-factor code"
+// @Failure 	 429 	{object} ErrorResponse	 "too many sign-in attempts"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/2fa/verify [post]
+func (h *Handler) VerifyTwoFactor(c echo.Context) error {
+	var input TwoFactorVerifyRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	tokens, err := h.services.Authorization.VerifyTwoFactor(input.ChallengeToken, input.Code, clientInfo(c))
+	if writeTooManyAttempts(c, err) {
+		return nil
+	}
+	if errors.Is(err, service.ErrInvalidChallenge) || errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		NewErrorResponse(c, http.StatusUnauthorized, err.Error())
+		return nil
+	}
+	if err != nil {
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, NewTokenResponse(tokens))
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// EnrollTwoFactor godoc
+// @Summary      Start two-factor enrollment
+// @Description  create a TOTP secret and its provisioning URI, it is active after confirmation
+// @Tags         auth
+// @Produce      json
+// @Success      200 	{object} TwoFactorEnrollmentResponse "secret and otpauth URI"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 409 	{object} ErrorResponse	 "two-factor authentication is already enabled"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	enrollment, err := h.services.Authorization.EnrollTwoFactor(userId)
+	if writeTwoFactorError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, TwoFactorEnrollmentResponse{Secret: enrollment.Secret, URI: enrollment.URI})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// ConfirmTwoFactor godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  enable two-factor authentication with a code of the new secret, returns recovery codes once
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input	body     TwoFactorCodeRequest  true  "TOTP code"
+// @Success      200 	{object} RecoveryCodesResponse "recovery codes"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid two-factor code"
+// @Failure 	 409 	{object} ErrorResponse	 "two-factor authentication is already enabled"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(c echo.Context) error {
+	userId, input, ok := h.twoFactorInput(c)
+	if !ok {
+		return nil
+	}
+	codes, err := h.services.Authorization.ConfirmTwoFactor(userId, input.Code)
+	return writeRecoveryCodes(c, codes, err)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  replace the recovery codes, requires a TOTP or recovery code
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input	body     TwoFactorCodeRequest  true  "TOTP or recovery code"
+// @Success      200 	{object} RecoveryCodesResponse "recovery codes"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid two-factor code"
+// @Failure 	 409 	{object} ErrorResponse	 "two-factor authentication is not enabled"
+// @Failure 	 429 	{object} ErrorResponse	 "too many failed attempts"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/2fa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c echo.Context) error {
+	userId, input, ok := h.twoFactorInput(c)
+	if !ok {
+		return nil
+	}
+	codes, err := h.services.Authorization.RegenerateRecoveryCodes(userId, input.Code)
+	return writeRecoveryCodes(c, codes, err)
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable two-factor authentication
+// @Description  remove the TOTP secret and recovery codes, requires a TOTP or recovery code
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        input	body     TwoFactorCodeRequest  true  "TOTP or recovery code"
+// @Success      200 	{object} MessageResponse "two-factor authentication disabled"
+// @Failure 	 400 	{object} ErrorResponse	 "invalid two-factor code"
+// @Failure 	 409 	{object} ErrorResponse	 "two-factor authentication is not enabled"
+// @Failure 	 429 	{object} ErrorResponse	 "too many failed attempts"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /auth/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	userId, input, ok := h.twoFactorInput(c)
+	if !ok {
+		return nil
+	}
+	err := h.services.Authorization.DisableTwoFactor(userId, input.Code)
+	if writeTwoFactorError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "two-factor authentication disabled"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+func (h *Handler) twoFactorInput(c echo.Context) (int, TwoFactorCodeRequest, bool) {
+	var input TwoFactorCodeRequest
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return 0, input, false
+	}
+	if err := GetRequest(c, &input); err != nil {
+		return 0, input, false
+	}
+	return userId, input, true
+}
+
+func writeRecoveryCodes(c echo.Context, codes []string, err error) error {
+	if writeTwoFactorError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// writeTwoFactorError writes the response for a failed two-factor management call.
+func writeTwoFactorError(c echo.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case writeTooManyAttempts(c, err):
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorNotEnabled),
+		errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		NewErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+	"time"
+)
+
+func TestHandler_VerifyTwoFactor(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifyTwoFactor("challenge", "123456", testClient).Return(service.Tokens{
+					AccessToken:  "token",
+					RefreshToken: "refresh",
+					ExpiresIn:    900,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token","refresh_token":"refresh","expires_in":900}` + "\n",
+		},
+		{
+			name:                 "Empty code",
+			inputBody:            `{"challenge_token":"challenge"}`,
+			mockBehavior:         func(s *mockService.MockAuthorization) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"code","rule":"required","message":"code is required"}]}` + "\n",
+		},
+		{
+			name:      "Wrong code",
+			inputBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifyTwoFactor("challenge", "123456", testClient).Return(service.Tokens{}, service.ErrInvalidTwoFactorCode)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid two-factor code"}` + "\n",
+		},
+		{
+			name:      "Expired challenge",
+			inputBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifyTwoFactor("challenge", "123456", testClient).Return(service.Tokens{}, service.ErrInvalidChallenge)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"invalid or expired challenge token"}` + "\n",
+		},
+		{
+			name:      "Too many attempts",
+			inputBody: `{"challenge_token":"challenge","code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().VerifyTwoFactor("challenge", "123456", testClient).Return(service.Tokens{},
+					&service.TooManyAttemptsError{RetryAfter: time.Minute})
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: `{"message":"too many sign-in attempts"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/auth/2fa/verify", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+
+			if assert.NoError(t, handler.VerifyTwoFactor(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_EnrollTwoFactor(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mockService.NewMockAuthorization(c)
+	auth.EXPECT().EnrollTwoFactor(3).Return(service.TwoFactorEnrollment{
+		Secret: "SECRET",
+		URI:    "otpauth://totp/Server:test?secret=SECRET",
+	}, nil)
+	handler := NewHandler(&service.Service{Authorization: auth})
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	req := httptest.NewRequest(http.MethodPost, "/auth/2fa/enroll", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Set(userCtx, 3)
+
+	if assert.NoError(t, handler.EnrollTwoFactor(ctx)) {
+		assert.Equal(t, 200, rec.Code)
+		assert.Equal(t, `{"secret":"SECRET","uri":"otpauth://totp/Server:test?secret=SECRET"}`+"\n", rec.Body.String())
+	}
+}
+
+func TestHandler_ConfirmTwoFactor(t *testing.T) {
+	type mockBehavior func(s *mockService.MockAuthorization)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().ConfirmTwoFactor(3, "123456").Return([]string{"abcde-fghjk"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"recovery_codes":["abcde-fghjk"]}` + "\n",
+		},
+		{
+			name:      "Wrong code",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().ConfirmTwoFactor(3, "123456").Return(nil, service.ErrInvalidTwoFactorCode)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid two-factor code"}` + "\n",
+		},
+		{
+			name:      "Already enabled",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().ConfirmTwoFactor(3, "123456").Return(nil, service.ErrTwoFactorEnabled)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"two-factor authentication is already enabled"}` + "\n",
+		},
+		{
+			name:      "Service Error",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mockService.MockAuthorization) {
+				s.EXPECT().ConfirmTwoFactor(3, "123456").Return(nil, errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mockService.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/auth/2fa/confirm", strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+
+			if assert.NoError(t, handler.ConfirmTwoFactor(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
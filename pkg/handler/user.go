@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"test/pkg/service"
+)
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  get the profile and account fields of the signed-in user
+// @Tags         users
+// @Produce      json
+// @Success      200 	{object} MeResponse     "current user"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 404 	{object} ErrorResponse	 "user not found"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me [get]
+func (h *Handler) GetMe(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	user, err := h.services.User.GetUser(userId)
+	if writeUserError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, NewMeResponse(user))
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// GetUserProfile godoc
+// @Summary      Get a public profile
+// @Description  get the public fields of a user
+// @Tags         users
+// @Produce      json
+// @Param        id     path     int          true  "User ID"
+// @Success      200 	{object} PublicUserResponse "public profile"
+// @Failure 	 400 	{object} ErrorResponse	 "id is not integer"
+// @Failure 	 404 	{object} ErrorResponse	 "user not found"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/{id} [get]
+func (h *Handler) GetUserProfile(c echo.Context) error {
+	id, errParams := GetParam(c, ParamId)
+	if errParams != nil {
+		return nil
+	}
+	user, err := h.services.User.GetUser(id)
+	if writeUserError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, NewPublicUserResponse(user))
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// UpdateMe godoc
+// @Summary      Edit the profile
+// @Description  change name, bio or avatar url of the current user, omitted fields are kept
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        profile body    ProfileRequest  true  "Profile fields"
+// @Success      200 	{object} MeResponse     "updated user"
+// @Failure 	 400 	{object} ErrorResponse	 "incorrect request data"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me [patch]
+func (h *Handler) UpdateMe(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	var input ProfileRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	user, err := h.services.User.UpdateProfile(userId, service.ProfileUpdate{
+		Name:      input.Name,
+		Bio:       input.Bio,
+		AvatarUrl: input.AvatarUrl,
+	})
+	if writeUserError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, NewMeResponse(user))
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// ChangePassword godoc
+// @Summary      Change the password
+// @Description  set a new password, requires the current one; other sessions are signed out
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        password body   PasswordChangeRequest  true  "Current and new password"
+// @Success      200 	{object} MessageResponse "password changed"
+// @Failure 	 400 	{object} ErrorResponse	 "password must be at least 6 symbols"
+// @Failure 	 403 	{object} ErrorResponse	 "current password is incorrect"
+// @Failure 	 422 	{object} ValidationErrorResponse "validation failed"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me/password [post]
+func (h *Handler) ChangePassword(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	var input PasswordChangeRequest
+	if err := GetRequest(c, &input); err != nil {
+		return nil
+	}
+	err := h.services.User.ChangePassword(userId, input.CurrentPassword, input.NewPassword, currentSessionId(c))
+	if writeUserError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "password changed"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// DeleteMe godoc
+// @Summary      Delete the account
+// @Description  delete the current user; posts and comments are deleted or anonymized by the server policy
+// @Tags         users
+// @Produce      json
+// @Success      200 	{object} MessageResponse "account deleted"
+// @Failure 	 401 	{object} ErrorResponse	 "empty auth header"
+// @Failure 	 404 	{object} ErrorResponse	 "user not found"
+// @Failure 	 500 	{object} ErrorResponse	 "something went wrong"
+// @Router       /api/users/me [delete]
+func (h *Handler) DeleteMe(c echo.Context) error {
+	userId, errUser := GetUserId(c)
+	if errUser != nil {
+		return nil
+	}
+	err := h.services.User.DeleteAccount(userId)
+	if writeUserError(c, err) {
+		return nil
+	}
+	errRes := c.JSON(http.StatusOK, MessageResponse{Message: "account deleted"})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
+
+// writeUserError writes the response for a failed user service call.
+func writeUserError(c echo.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, service.ErrUserNotFound):
+		NewErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrWrongPassword):
+		NewErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidName), errors.Is(err, service.ErrBioTooLong),
+		errors.Is(err, service.ErrInvalidAvatarUrl), errors.Is(err, service.ErrPasswordTooShort):
+		NewErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		NewErrorResponse(c, http.StatusInternalServerError, "something went wrong")
+	}
+	return true
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"test/pkg/repository/models"
+	"test/pkg/service"
+	mockService "test/pkg/service/mocks"
+	"testing"
+)
+
+func TestHandler_GetUserProfile(t *testing.T) {
+	type mockBehavior func(s *mockService.MockUser)
+
+	testTable := []struct {
+		name                 string
+		id                   string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "ok",
+			id:   "3",
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().GetUser(3).Return(models.User{Id: 3, Name: "Test", Username: "test", Password: "hash",
+					Email: "test@example.com", Bio: "hi", AvatarUrl: "https://example.com/a.png"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":3,"name":"Test","bio":"hi","avatar_url":"https://example.com/a.png"}` + "\n",
+		},
+		{
+			name:                 "Wrong id",
+			id:                   "x",
+			mockBehavior:         func(s *mockService.MockUser) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"id is not integer"}` + "\n",
+		},
+		{
+			name: "Not found",
+			id:   "3",
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().GetUser(3).Return(models.User{}, service.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"user not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mockService.NewMockUser(c)
+			testCase.mockBehavior(users)
+			handler := NewHandler(&service.Service{User: users})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodGet, "/api/users/:id", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames(ParamId)
+			ctx.SetParamValues(testCase.id)
+
+			if assert.NoError(t, handler.GetUserProfile(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_GetMe(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockService.NewMockUser(c)
+	users.EXPECT().GetUser(3).Return(models.User{Id: 3, Name: "Test", Username: "test", Password: "hash",
+		Email: "test@example.com", EmailVerified: true, Role: service.RoleUser}, nil)
+	handler := NewHandler(&service.Service{User: users})
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Set(userCtx, 3)
+
+	if assert.NoError(t, handler.GetMe(ctx)) {
+		assert.Equal(t, 200, rec.Code)
+		assert.Equal(t, `{"id":3,"name":"Test","bio":"","avatar_url":"","username":"test",`+
+			`"email":"test@example.com","email_verified":true,"role":"user"}`+"\n", rec.Body.String())
+	}
+}
+
+func TestHandler_UpdateMe(t *testing.T) {
+	type mockBehavior func(s *mockService.MockUser)
+
+	bio := "about me"
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"bio":"about me"}`,
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().UpdateProfile(3, service.ProfileUpdate{Bio: &bio}).
+					Return(models.User{Id: 3, Name: "Test", Username: "test", Bio: bio, Role: service.RoleUser}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":3,"name":"Test","bio":"about me","avatar_url":"","username":"test",` +
+				`"email":"","email_verified":false,"role":"user"}` + "\n",
+		},
+		{
+			name:      "Invalid avatar",
+			inputBody: `{"avatar_url":"javascript:alert(1)"}`,
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().UpdateProfile(3, gomock.Any()).Return(models.User{}, service.ErrInvalidAvatarUrl)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"avatar url must be an http or https url"}` + "\n",
+		},
+		{
+			name:                 "Wrong body",
+			inputBody:            `{"bio":`,
+			mockBehavior:         func(s *mockService.MockUser) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mockService.NewMockUser(c)
+			testCase.mockBehavior(users)
+			handler := NewHandler(&service.Service{User: users})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPatch, "/api/users/me", bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+
+			if assert.NoError(t, handler.UpdateMe(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ChangePassword(t *testing.T) {
+	type mockBehavior func(s *mockService.MockUser)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputBody: `{"current_password":"old","new_password":"newpassword"}`,
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().ChangePassword(3, "old", "newpassword", "sid").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"password changed"}` + "\n",
+		},
+		{
+			name:      "Wrong password",
+			inputBody: `{"current_password":"bad","new_password":"newpassword"}`,
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().ChangePassword(3, "bad", "newpassword", "sid").Return(service.ErrWrongPassword)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"current password is incorrect"}` + "\n",
+		},
+		{
+			name:                 "Too short",
+			inputBody:            `{"current_password":"old","new_password":"new"}`,
+			mockBehavior:         func(s *mockService.MockUser) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: `{"message":"validation failed","errors":[{"field":"new_password","rule":"min","param":"6","message":"new_password must be at least 6 symbols"}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mockService.NewMockUser(c)
+			testCase.mockBehavior(users)
+			handler := NewHandler(&service.Service{User: users})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodPost, "/api/users/me/password", bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+			ctx.Set(claimsCtx, &service.TokenClaims{UserId: 3, SessionId: "sid"})
+
+			if assert.NoError(t, handler.ChangePassword(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_DeleteMe(t *testing.T) {
+	type mockBehavior func(s *mockService.MockUser)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "ok",
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().DeleteAccount(3).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"account deleted"}` + "\n",
+		},
+		{
+			name: "Service Error",
+			mockBehavior: func(s *mockService.MockUser) {
+				s.EXPECT().DeleteAccount(3).Return(errors.New("db is down"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"something went wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mockService.NewMockUser(c)
+			testCase.mockBehavior(users)
+			handler := NewHandler(&service.Service{User: users})
+
+			e := echo.New()
+			e.Validator = NewValidator()
+			req := httptest.NewRequest(http.MethodDelete, "/api/users/me", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(userCtx, 3)
+
+			if assert.NoError(t, handler.DeleteMe(ctx)) {
+				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			}
+		})
+	}
+}
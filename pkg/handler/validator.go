@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Validator checks the `validate` tags of request DTOs for echo.
+// Failing fields are reported by their json names.
+type Validator struct {
+	validate *validator.Validate
+}
+
+func NewValidator() *Validator {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return &Validator{validate: validate}
+}
+
+func (v *Validator) Validate(i interface{}) error {
+	return v.validate.Struct(i)
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type ValidationErrorResponse struct {
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors"`
+}
+
+// writeValidationError responds with 422 and every failing field,
+// or with 400 if err does not come from the validator.
+func writeValidationError(c echo.Context, err error) {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
+		return
+	}
+	response := ValidationErrorResponse{Message: "validation failed", Errors: make([]FieldError, 0, len(fieldErrors))}
+	for _, fieldError := range fieldErrors {
+		response.Errors = append(response.Errors, FieldError{
+			Field:   fieldError.Field(),
+			Rule:    fieldError.Tag(),
+			Param:   fieldError.Param(),
+			Message: fieldMessage(fieldError),
+		})
+	}
+	errRes := c.JSON(http.StatusUnprocessableEntity, response)
+	if errRes != nil {
+		return
+	}
+}
+
+func fieldMessage(fieldError validator.FieldError) string {
+	field, param := fieldError.Field(), fieldError.Param()
+	switch fieldError.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s symbols", field, param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s symbols", field, param)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid url", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, param)
+	case "required_if":
+		if condition := strings.Fields(param); len(condition) == 2 {
+			return fmt.Sprintf("%s is required when %s is %s", field, strings.ToLower(condition[0]), condition[1])
+		}
+		return fmt.Sprintf("%s is required", field)
+	default:
+		return fmt.Sprintf("%s is invalid", field)
+	}
+}
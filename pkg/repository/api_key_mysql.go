@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type ApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) *ApiKeyRepository {
+	return &ApiKeyRepository{db: db}
+}
+
+func (a *ApiKeyRepository) CreateApiKey(key models.ApiKey) (int, error) {
+	err := a.db.Table(ApiKeysTable).Create(&key).Error
+	return key.Id, err
+}
+
+func (a *ApiKeyRepository) GetApiKeys(userId int) ([]models.ApiKey, error) {
+	var keys []models.ApiKey
+	err := a.db.Table(ApiKeysTable).Where("user_id = ?", userId).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (a *ApiKeyRepository) GetApiKeyByHash(keyHash string) (models.ApiKey, error) {
+	var key models.ApiKey
+	err := a.db.Table(ApiKeysTable).Where("key_hash = ?", keyHash).First(&key).Error
+	return key, err
+}
+
+func (a *ApiKeyRepository) TouchApiKey(id int, usedAt time.Time) error {
+	return a.db.Table(ApiKeysTable).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
+// RevokeApiKey revokes the key of the user and reports false if there is no such active key.
+func (a *ApiKeyRepository) RevokeApiKey(userId, id int) (bool, error) {
+	res := a.db.Table(ApiKeysTable).
+		Where("id = ? and user_id = ? and revoked_at is null", id, userId).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// RevokeUserApiKeys revokes all the active keys of the user.
+func (a *ApiKeyRepository) RevokeUserApiKeys(userId int) error {
+	return a.db.Table(ApiKeysTable).
+		Where("user_id = ? and revoked_at is null", userId).
+		Update("revoked_at", time.Now()).Error
+}
@@ -14,7 +14,7 @@ func NewAuthRepository(db *gorm.DB) *AuthRepository {
 }
 
 func (a *AuthRepository) CreateUser(user models.User) (int, error) {
-	err := a.db.Select(UsersTable, "name", "username", "password_hash").Create(&user).Error
+	err := a.db.Select(UsersTable, "name", "username", "password_hash", "email").Create(&user).Error
 	if user.Id == 0 {
 		return 0, err
 	}
@@ -28,12 +28,41 @@ func (a *AuthRepository) Testing(name string) (string, error) {
 	return name, nil
 }
 
-func (a *AuthRepository) GetUser(username, password string) (models.User, error) {
+func (a *AuthRepository) GetUser(username string) (models.User, error) {
 	var user models.User
-	err := a.db.Where("username = ? and password_hash = ?", username, password).Find(&user).Error
+	err := a.db.Table(UsersTable).Where("username = ?", username).First(&user).Error
+	return user, err
+}
+
+func (a *AuthRepository) GetUserById(id int) (models.User, error) {
+	var user models.User
+	err := a.db.Table(UsersTable).First(&user, id).Error
+	return user, err
+}
+
+func (a *AuthRepository) GetUserByEmail(email string) (models.User, error) {
+	var user models.User
+	err := a.db.Table(UsersTable).Where("email = ?", email).First(&user).Error
 	return user, err
 }
 
+func (a *AuthRepository) SetEmailVerified(id int) error {
+	return a.db.Table(UsersTable).Where("id = ?", id).Update("email_verified", true).Error
+}
+
+func (a *AuthRepository) UpdateRole(id int, role string) error {
+	res := a.db.Table(UsersTable).Where("id = ?", id).Update("role", role)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+func (a *AuthRepository) UpdatePasswordHash(id int, passwordHash string) error {
+	err := a.db.Table(UsersTable).Where("id = ?", id).Update("password_hash", passwordHash).Error
+	return err
+}
+
 func (a *AuthRepository) CheckUser(username string) error {
 	var user models.User
 	err := a.db.Table(UsersTable).Where("username = ?", username).First(&user).Error
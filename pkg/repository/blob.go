@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	BlobStoreLocal = "local"
+	BlobStoreS3    = "s3"
+)
+
+var ErrInvalidBlobKey = errors.New("invalid blob key")
+
+// BlobStore keeps the contents of the uploaded media by key. Keys are slash separated paths.
+// Open of a missing blob returns an error that matches fs.ErrNotExist,
+// Delete of a missing blob does nothing.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// BlobConfig chooses the blob store: files under Dir for local,
+// a bucket at Endpoint for s3.
+type BlobConfig struct {
+	Store     string
+	Dir       string
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+func NewBlobStore(cnf BlobConfig) (BlobStore, error) {
+	switch cnf.Store {
+	case "", BlobStoreLocal:
+		return NewLocalBlobStore(cnf.Dir)
+	case BlobStoreS3:
+		return NewS3BlobStore(cnf.Endpoint, cnf.Region, cnf.Bucket, cnf.AccessKey, cnf.SecretKey, &http.Client{Timeout: s3Timeout})
+	}
+	return nil, errors.New("unknown blob store " + cnf.Store)
+}
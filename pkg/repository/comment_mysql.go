@@ -1,9 +1,9 @@
 package repository
 
 import (
-	"fmt"
 	"gorm.io/gorm"
 	"test/pkg/repository/models"
+	"time"
 )
 
 type CommentRepository struct {
@@ -15,30 +15,138 @@ func NewCommentRepository(db *gorm.DB) *CommentRepository {
 }
 
 func (p *CommentRepository) Create(comment models.Comment) (int, error) {
-	tx := p.db.Begin()
-	errPost := tx.Select(CommentsTable, "body", "user_id", "post_id").Create(&comment).Error
+	errPost := p.db.Select(CommentsTable, "body", "user_id", "post_id", "parent_id", "depth", "path", "created_at", "updated_at").
+		Create(&comment).Error
 	return comment.Id, errPost
 }
 
-func (p *CommentRepository) Get(postId int) ([]models.Comment, error) {
+func (p *CommentRepository) Get(postId int, filter CommentFilter, page Page) ([]models.Comment, error) {
 	var comments []models.Comment
-	query := fmt.Sprintf("SELECT * FROM %s cmt WHERE cmt.post_id = %d",
-		CommentsTable, postId)
-	err := p.db.Raw(query).Scan(&comments).Error
+	query := p.db.Table(CommentsTable).Where("post_id = ?", postId)
+	if filter.Placeholders {
+		query = withPlaceholders(query)
+	}
+	if filter.Parent != nil {
+		query = query.Where("parent_id = ?", *filter.Parent)
+	}
+	if filter.Thread != "" {
+		query = query.Where("path LIKE ?", prefixPattern(filter.Thread))
+	}
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	query = applyCreatedRange(query, filter.CreatedFrom, filter.CreatedTo)
+	if filter.Body != "" {
+		query = query.Where("body LIKE ?", containsPattern(filter.Body))
+	}
+	err := applyPage(query, page).Find(&comments).Error
 	if err != nil {
 		return nil, err
 	}
 	return comments, nil
 }
 
+// GetReplies returns the direct replies to the comments, at most limit oldest ones for each comment,
+// together with the deleted replies that still have replies of their own.
+func (p *CommentRepository) GetReplies(postId int, parentIds []int, limit int) ([]models.Comment, error) {
+	var replies []models.Comment
+	numbered := withPlaceholders(p.db.Table(CommentsTable).Where("post_id = ? AND parent_id IN ?", postId, parentIds)).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at, id) AS reply_number")
+	err := p.db.Unscoped().Table("(?) AS replies", numbered).Where("reply_number <= ?", limit).
+		Order("parent_id, created_at, id").Find(&replies).Error
+	return replies, err
+}
+
+// CountReplies returns the number of direct replies to each of the comments that have them.
+func (p *CommentRepository) CountReplies(postId int, parentIds []int) (map[int]int, error) {
+	var rows []struct {
+		ParentId int
+		Replies  int
+	}
+	err := withPlaceholders(p.db.Table(CommentsTable).Where("post_id = ? AND parent_id IN ?", postId, parentIds)).
+		Select("parent_id, COUNT(*) AS replies").Group("parent_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int, len(rows))
+	for _, row := range rows {
+		counts[row.ParentId] = row.Replies
+	}
+	return counts, nil
+}
+
+func (p *CommentRepository) GetById(postId, id int) (models.Comment, error) {
+	var comment models.Comment
+	err := p.db.Table(CommentsTable).Where("id = ? and post_id = ?", id, postId).First(&comment).Error
+	return comment, err
+}
+
 func (p *CommentRepository) Update(postId, id int, comment models.Comment) error {
-	err := p.db.Select(CommentsTable, "body").Where("id = ? and post_id = ?", id, postId).Updates(&comment).Error
+	err := p.db.Select(CommentsTable, "body", "updated_at").Where("id = ? and post_id = ?", id, postId).Updates(&comment).Error
 	return err
 }
 
-func (p *CommentRepository) Delete(postId, id int) error {
-
-	errPost := p.db.Table(CommentsTable).Where("id = ? and post_id = ?", id, postId).Delete(&models.Comment{}).Error
+// Delete moves the comment to the trash and records the user who deleted it.
+func (p *CommentRepository) Delete(postId, id, deletedBy int) error {
+	errPost := p.db.Table(CommentsTable).Where("id = ? and post_id = ? and deleted_at IS NULL", id, postId).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by": deletedBy}).Error
 	return errPost
+}
+
+// GetDeleted returns a page of the comments in the trash.
+func (p *CommentRepository) GetDeleted(page Page) ([]models.Comment, error) {
+	var comments []models.Comment
+	query := p.db.Unscoped().Table(CommentsTable).Where("deleted_at IS NOT NULL")
+	err := applyPage(query, page).Find(&comments).Error
+	return comments, err
+}
+
+func (p *CommentRepository) GetDeletedById(postId, id int) (models.Comment, error) {
+	var comment models.Comment
+	err := p.db.Unscoped().Table(CommentsTable).Where("id = ? and post_id = ? and deleted_at IS NOT NULL", id, postId).
+		First(&comment).Error
+	return comment, err
+}
+
+func (p *CommentRepository) Restore(postId, id int) error {
+	res := p.db.Unscoped().Table(CommentsTable).Where("id = ? and post_id = ? and deleted_at IS NOT NULL", id, postId).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": 0})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+// Purge deletes the comments that were moved to the trash before the time for good
+// together with their reactions and returns their number. Comments that still have
+// replies stay as placeholders of their threads until the replies are gone.
+func (p *CommentRepository) Purge(deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		err := tx.Table(CommentsTable).Where("deleted_at < ? AND NOT EXISTS "+
+			"(SELECT 1 FROM "+CommentsTable+" r WHERE r.parent_id = "+CommentsTable+".id)", deletedBefore).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		comments := func() *gorm.DB {
+			return tx.Table(CommentsTable).Select("id").Where("id IN ?", ids)
+		}
+		if err = deleteReactions(tx, models.ReactionTargetComment, comments); err != nil {
+			return err
+		}
+		res := tx.Unscoped().Table(CommentsTable).Where("id IN ?", ids).Delete(&models.Comment{})
+		purged = res.RowsAffected
+		return res.Error
+	})
+	return purged, err
+}
 
+// withPlaceholders adds the deleted comments that still have replies which are not deleted,
+// so that the replies keep their place in the thread.
+func withPlaceholders(query *gorm.DB) *gorm.DB {
+	return query.Unscoped().Where(CommentsTable + ".deleted_at IS NULL OR EXISTS (SELECT 1 FROM " + CommentsTable + " r " +
+		"WHERE r.post_id = " + CommentsTable + ".post_id AND r.path LIKE CONCAT(" + CommentsTable + ".path, " + CommentsTable + ".id, '/%') " +
+		"AND r.deleted_at IS NULL)")
 }
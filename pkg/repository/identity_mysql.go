@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+)
+
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+func (i *IdentityRepository) GetIdentity(provider, subject string) (models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	err := i.db.Table(ExternalIdentitiesTable).Where("provider = ? and subject = ?", provider, subject).First(&identity).Error
+	return identity, err
+}
+
+func (i *IdentityRepository) CreateIdentity(identity models.ExternalIdentity) error {
+	return i.db.Table(ExternalIdentitiesTable).Create(&identity).Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// Sort orders a list by a column. Columns come from the allow-lists
+// of the services and are quoted, values are always passed as parameters.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// Page selects up to Limit rows in Sort order. After holds the values of the
+// sort columns of the last row of the previous page, it is empty for the first page.
+// The last sort column must be unique, so that pages don't overlap.
+type Page struct {
+	Sort  []Sort
+	After []interface{}
+	Limit int
+}
+
+// PostFilter narrows a list of posts; zero fields are not applied.
+// Posts that are not published are always left out, unless they belong to ViewerId.
+type PostFilter struct {
+	UserId      int
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Title       string
+	Tag         string
+	Status      string
+	ViewerId    int
+}
+
+// CommentFilter narrows a list of comments; zero fields are not applied.
+// Parent selects the direct replies of a comment, 0 selects the top-level comments.
+// Thread selects the replies at any depth under a comment, it is the path of the replies
+// to that comment. Placeholders keeps the deleted comments that still have replies.
+type CommentFilter struct {
+	UserId       int
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	Body         string
+	Parent       *int
+	Thread       string
+	Placeholders bool
+}
+
+func applyPage(query *gorm.DB, page Page) *gorm.DB {
+	if len(page.After) > 0 && len(page.After) == len(page.Sort) {
+		query = query.Where(afterCondition(page))
+	}
+	for _, sort := range page.Sort {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.Column}, Desc: sort.Desc})
+	}
+	return query.Limit(page.Limit)
+}
+
+// afterCondition selects the rows that follow page.After in sort order:
+// (a > x) OR (a = x AND b > y) OR ...
+func afterCondition(page Page) clause.Expression {
+	var alternatives []clause.Expression
+	for i, sort := range page.Sort {
+		var parts []clause.Expression
+		for j := 0; j < i; j++ {
+			parts = append(parts, clause.Eq{Column: clause.Column{Name: page.Sort[j].Column}, Value: page.After[j]})
+		}
+		column := clause.Column{Name: sort.Column}
+		if sort.Desc {
+			parts = append(parts, clause.Lt{Column: column, Value: page.After[i]})
+		} else {
+			parts = append(parts, clause.Gt{Column: column, Value: page.After[i]})
+		}
+		alternatives = append(alternatives, clause.And(parts...))
+	}
+	return clause.Or(alternatives...)
+}
+
+func applyCreatedRange(query *gorm.DB, from, to *time.Time) *gorm.DB {
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+	return query
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern is a LIKE pattern that matches values containing s literally.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// prefixPattern is a LIKE pattern that matches values starting with s literally.
+func prefixPattern(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+	return db, mock
+}
+
+func TestPostRepository_Get(t *testing.T) {
+	db, mock := newMockDB(t)
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2022, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT * FROM `posts` WHERE user_id = ? AND created_at >= ? AND title LIKE ? AND "+
+		"(status = ? OR user_id = ?) AND "+
+		"EXISTS (SELECT 1 FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.post_id = posts.id AND t.name = ?) AND "+
+		"(`created_at` < ? OR (`created_at` = ? AND `title` > ?) OR (`created_at` = ? AND `title` = ? AND `id` > ?)) AND `posts`.`deleted_at` IS NULL "+
+		"ORDER BY `created_at` DESC,`title`,`id` LIMIT 21").
+		WithArgs(3, from, `%50\%\_off%`, "published", 5, "news", after, after, "b", after, "b", 8).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "title", "anons", "created_at"}).
+			AddRow(7, 3, "c", "anons", after))
+	mock.ExpectQuery("SELECT pt.post_id, t.name FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.post_id IN (?) ORDER BY t.name").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "name"}).AddRow(7, "deals").AddRow(7, "news"))
+
+	posts, err := NewPostRepository(db).Get(PostFilter{UserId: 3, CreatedFrom: &from, Title: "50%_off", Tag: "news", ViewerId: 5}, Page{
+		Sort:  []Sort{{Column: "created_at", Desc: true}, {Column: "title"}, {Column: "id"}},
+		After: []interface{}{after, "b", 8},
+		Limit: 21,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, posts, 1) {
+		assert.Equal(t, []string{"deals", "news"}, posts[0].Tags)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_Get(t *testing.T) {
+	db, mock := newMockDB(t)
+	to := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT * FROM `comments` WHERE post_id = ? AND user_id = ? AND created_at < ? AND body LIKE ? AND `comments`.`deleted_at` IS NULL "+
+		"ORDER BY `created_at`,`id` LIMIT 11").
+		WithArgs(5, 3, to, "%nice%").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "user_id", "body", "created_at"}))
+
+	_, err := NewCommentRepository(db).Get(5, CommentFilter{UserId: 3, CreatedTo: &to, Body: "nice"}, Page{
+		Sort:  []Sort{{Column: "created_at"}, {Column: "id"}},
+		Limit: 11,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
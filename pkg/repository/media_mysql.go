@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"test/pkg/repository/models"
+)
+
+type MediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+func (m *MediaRepository) CreateMedia(media models.Media) (int, error) {
+	err := m.db.Table(MediaTable).Create(&media).Error
+	return media.Id, err
+}
+
+// GetMedia returns the media of a post in the order they were uploaded.
+func (m *MediaRepository) GetMedia(postId int) ([]models.Media, error) {
+	var media []models.Media
+	err := m.db.Table(MediaTable).Where("post_id = ?", postId).Order("id").Find(&media).Error
+	return media, err
+}
+
+func (m *MediaRepository) GetMediaById(postId, id int) (models.Media, error) {
+	var media models.Media
+	err := m.db.Table(MediaTable).Where("post_id = ?", postId).First(&media, id).Error
+	return media, err
+}
+
+// ReserveMediaUsage adds the size to the usage of the user unless it goes over the quota.
+// It returns false when it would. The usage row is locked until the transaction ends,
+// so the uploads of a user running at the same time can't pass the quota together.
+func (m *MediaRepository) ReserveMediaUsage(userId int, size, quota int64) (bool, error) {
+	reserved := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		usage := models.MediaUsage{UserId: userId}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&usage).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&usage).Error
+		if err != nil || usage.Bytes+size > quota {
+			return err
+		}
+		reserved = true
+		return tx.Model(&models.MediaUsage{}).Where("user_id = ?", userId).Update("bytes", gorm.Expr("bytes + ?", size)).Error
+	})
+	return reserved && err == nil, err
+}
+
+// ReleaseMediaUsage takes back the size reserved by an upload that failed.
+func (m *MediaRepository) ReleaseMediaUsage(userId int, size int64) error {
+	return releaseMediaUsage(m.db, userId, size)
+}
+
+// GetOrphanedMedia returns media whose post is gone for good. Posts in the trash keep their media.
+func (m *MediaRepository) GetOrphanedMedia(limit int) ([]models.Media, error) {
+	var media []models.Media
+	err := m.db.Table(MediaTable).Where("NOT EXISTS (SELECT 1 FROM " + PostsTable + " p WHERE p.id = " + MediaTable + ".post_id)").
+		Order("id").Limit(limit).Find(&media).Error
+	return media, err
+}
+
+// DeleteMedia deletes the records and takes their sizes off the usages of the users.
+func (m *MediaRepository) DeleteMedia(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var usages []models.MediaUsage
+		err := tx.Table(MediaTable).Select("user_id, SUM(size) AS bytes").Where("id IN ?", ids).
+			Group("user_id").Order("user_id").Scan(&usages).Error
+		if err != nil {
+			return err
+		}
+		for _, usage := range usages {
+			if err = releaseMediaUsage(tx, usage.UserId, usage.Bytes); err != nil {
+				return err
+			}
+		}
+		return tx.Table(MediaTable).Where("id IN ?", ids).Delete(&models.Media{}).Error
+	})
+}
+
+func releaseMediaUsage(db *gorm.DB, userId int, size int64) error {
+	return db.Model(&models.MediaUsage{}).Where("user_id = ?", userId).
+		Update("bytes", gorm.Expr("GREATEST(bytes - ?, 0)", size)).Error
+}
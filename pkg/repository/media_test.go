@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMediaRepository_ReserveMediaUsage(t *testing.T) {
+	db, mock := newMockDB(t)
+	media := NewMediaRepository(db)
+	insert := "INSERT INTO `media_usages` (`user_id`,`bytes`) VALUES (?,?) ON DUPLICATE KEY UPDATE `user_id`=`user_id`"
+	lock := "SELECT * FROM `media_usages` WHERE `media_usages`.`user_id` = ? LIMIT 1 FOR UPDATE"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).WithArgs(3, 0).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(lock).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "bytes"}).AddRow(3, 2000))
+	mock.ExpectExec("UPDATE `media_usages` SET `bytes`=bytes + ? WHERE user_id = ?").WithArgs(48, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	reserved, err := media.ReserveMediaUsage(3, 48, 2048)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).WithArgs(3, 0).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(lock).WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "bytes"}).AddRow(3, 2048))
+	mock.ExpectCommit()
+	reserved, err = media.ReserveMediaUsage(3, 1, 2048)
+	assert.NoError(t, err)
+	assert.False(t, reserved, "the usage is full")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `media_usages` SET `bytes`=GREATEST(bytes - ?, 0) WHERE user_id = ?").WithArgs(48, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, media.ReleaseMediaUsage(3, 48))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaRepository_GetOrphanedMedia(t *testing.T) {
+	db, mock := newMockDB(t)
+	media := NewMediaRepository(db)
+
+	mock.ExpectQuery("SELECT * FROM `media` WHERE NOT EXISTS (SELECT 1 FROM posts p WHERE p.id = media.post_id) ORDER BY id LIMIT 100").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "key"}).AddRow(5, 9, "posts/9/a"))
+	orphans, err := media.GetOrphanedMedia(100)
+	assert.NoError(t, err)
+	assert.Len(t, orphans, 1)
+	assert.Equal(t, "posts/9/a", orphans[0].Key)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT user_id, SUM(size) AS bytes FROM `media` WHERE id IN (?,?) GROUP BY `user_id` ORDER BY user_id").
+		WithArgs(5, 6).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "bytes"}).AddRow(3, 100).AddRow(4, 20))
+	mock.ExpectExec("UPDATE `media_usages` SET `bytes`=GREATEST(bytes - ?, 0) WHERE user_id = ?").
+		WithArgs(100, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `media_usages` SET `bytes`=GREATEST(bytes - ?, 0) WHERE user_id = ?").
+		WithArgs(20, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `media` WHERE id IN (?,?)").
+		WithArgs(5, 6).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	assert.NoError(t, media.DeleteMedia([]int{5, 6}))
+	assert.NoError(t, media.DeleteMedia(nil), "nothing to delete makes no query")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
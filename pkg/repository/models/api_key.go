@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ApiKey is a long-lived credential of a user for machine clients.
+// Only the hash of the key is stored, Prefix is kept to tell keys apart.
+type ApiKey struct {
+	Id         int        `json:"id" gorm:"primaryKey"`
+	UserId     int        `json:"user_id" gorm:"index"`
+	Name       string     `json:"name" gorm:"size:100"`
+	Prefix     string     `json:"prefix" gorm:"size:16"`
+	KeyHash    string     `json:"-" gorm:"size:64;uniqueIndex"`
+	Scopes     string     `json:"scopes" gorm:"size:255"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -1,8 +1,30 @@
 package models
 
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// DeletedCommentBody replaces the body of a deleted comment that is kept in a thread for its replies.
+const DeletedCommentBody = "[deleted]"
+
+// Comment is a comment on a post or a reply to another comment, ParentId is 0 for the top-level ones.
+// Path lists the ids of the ancestors from the top-level comment down, each followed by a slash,
+// so the replies at any depth under a comment have paths starting with its path, its id and a slash.
 type Comment struct {
-	Id     int    `json:"id"  gorm:"<-:false"`
-	PostId int    `json:"post_id"`
-	UserId int    `json:"user_id"`
-	Body   string `json:"body"  binding:"required"`
+	Id            int              `json:"id"  gorm:"<-:false"`
+	PostId        int              `json:"post_id" gorm:"index"`
+	ParentId      int              `json:"parent_id" gorm:"not null;default:0;index"`
+	UserId        int              `json:"user_id"`
+	Body          string           `json:"body"`
+	Depth         int              `json:"depth" gorm:"not null;default:0"`
+	Path          string           `json:"path" gorm:"size:255;not null;default:'';index"`
+	Reactions     *ReactionSummary `json:"reactions,omitempty" gorm:"-"`
+	ReplyCount    int              `json:"reply_count,omitempty" gorm:"-"`
+	Replies       []Comment        `json:"replies,omitempty" gorm:"-"`
+	RepliesCursor string           `json:"replies_cursor,omitempty" gorm:"-"`
+	CreatedAt     time.Time        `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP(3)"`
+	UpdatedAt     time.Time        `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP(3)"`
+	DeletedAt     gorm.DeletedAt   `json:"deleted_at" gorm:"index"`
+	DeletedBy     int              `json:"deleted_by,omitempty" gorm:"<-:false;not null;default:0"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ExternalIdentity links an account of an external login provider to a user.
+type ExternalIdentity struct {
+	Id        int       `json:"id" gorm:"primaryKey"`
+	UserId    int       `json:"user_id" gorm:"index"`
+	Provider  string    `json:"provider" gorm:"size:64;uniqueIndex:idx_provider_subject"`
+	Subject   string    `json:"subject" gorm:"size:255;uniqueIndex:idx_provider_subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
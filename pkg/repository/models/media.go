@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Images are shown inline, other files are downloaded.
+const (
+	MediaKindImage = "image"
+	MediaKindFile  = "file"
+)
+
+// Media is an image or a file attached to a post. The content is kept in the blob store
+// under Key, Checksum is its sha256 in hex. Width and Height are known for images
+// of the formats that can be decoded.
+type Media struct {
+	Id          int       `json:"id" gorm:"primaryKey"`
+	PostId      int       `json:"post_id" gorm:"not null;index"`
+	UserId      int       `json:"user_id" gorm:"not null;index"`
+	Key         string    `json:"-" gorm:"size:255;not null;uniqueIndex"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Kind        string    `json:"kind" gorm:"size:16;not null"`
+	ContentType string    `json:"content_type" gorm:"size:100;not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	Checksum    string    `json:"checksum" gorm:"size:64;not null"`
+	Url         string    `json:"url" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MediaUsage is the bytes a user has uploaded, the uploads in progress included.
+// An upload reserves its size while the row is locked, so uploads running
+// at the same time are checked against the quota one after another.
+type MediaUsage struct {
+	UserId int   `gorm:"primaryKey;autoIncrement:false"`
+	Bytes  int64 `gorm:"not null;default:0"`
+}
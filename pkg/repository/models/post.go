@@ -1,10 +1,37 @@
 package models
 
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// A post is public only when it is published. Scheduled posts
+// are published by the scheduler once PublishAt has come.
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
+// Post is an article. Body is the text in Markdown, WordCount and ReadingTime,
+// in minutes, are counted from it when the post is read.
 type Post struct {
-	Id     int    `json:"id" gorm:"<-:false"`
-	UserId int    `json:"user_id"`
-	Title  string `json:"title" form:"title" binding:"required"`
-	Anons  string `json:"anons" form:"anons" binding:"required"`
+	Id          int              `json:"id" gorm:"<-:false"`
+	UserId      int              `json:"user_id" gorm:"index"`
+	Title       string           `json:"title" form:"title"`
+	Anons       string           `json:"anons" form:"anons"`
+	Body        string           `json:"body" form:"body" gorm:"type:mediumtext"`
+	WordCount   int              `json:"word_count" gorm:"-"`
+	ReadingTime int              `json:"reading_time" gorm:"-"`
+	Status      string           `json:"status" gorm:"size:16;not null;default:published;index"`
+	PublishAt   *time.Time       `json:"publish_at" gorm:"index"`
+	Tags        []string         `json:"tags" gorm:"-"`
+	Reactions   *ReactionSummary `json:"reactions,omitempty" gorm:"-"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP(3)"`
+	UpdatedAt   time.Time        `json:"updated_at" gorm:"not null;default:CURRENT_TIMESTAMP(3)"`
+	DeletedAt   gorm.DeletedAt   `json:"deleted_at" gorm:"index"`
+	DeletedBy   int              `json:"deleted_by,omitempty" gorm:"<-:false;not null;default:0"`
 }
 
 type Posts struct {
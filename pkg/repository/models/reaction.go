@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetComment = "comment"
+
+	ReactionLike = "like"
+)
+
+// Reaction is a reaction of a user to a post or a comment.
+// A user reacts to a target at most once per kind.
+type Reaction struct {
+	Id         int       `json:"id" gorm:"primaryKey"`
+	TargetType string    `json:"target_type" gorm:"size:16;uniqueIndex:idx_reactions_user,priority:1;index:idx_reactions_target,priority:1"`
+	TargetId   int       `json:"target_id" gorm:"uniqueIndex:idx_reactions_user,priority:2;index:idx_reactions_target,priority:2"`
+	Kind       string    `json:"kind" gorm:"size:32;uniqueIndex:idx_reactions_user,priority:3;index:idx_reactions_target,priority:3"`
+	UserId     int       `json:"user_id" gorm:"uniqueIndex:idx_reactions_user,priority:4;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReactionCount is the number of reactions of a kind to a target. It is changed
+// in the same transaction as the reactions, so it doesn't have to be counted on every read.
+type ReactionCount struct {
+	TargetType string `gorm:"primaryKey;size:16"`
+	TargetId   int    `gorm:"primaryKey"`
+	Kind       string `gorm:"primaryKey;size:32"`
+	Count      int    `gorm:"not null;default:0"`
+}
+
+// ReactionSummary counts the reactions to a target by kind
+// and lists the kinds the viewer reacted with.
+type ReactionSummary struct {
+	Counts map[string]int `json:"counts"`
+	Mine   []string       `json:"mine"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PostRevision is a saved state of a post. Revisions are numbered from 1 per post
+// and never change; reverting a post adds a new revision with RevertedFrom set
+// to the number of the revision it restored.
+type PostRevision struct {
+	Id           int       `json:"id" gorm:"primaryKey"`
+	PostId       int       `json:"post_id" gorm:"uniqueIndex:idx_post_revisions_number,priority:1"`
+	Number       int       `json:"number" gorm:"uniqueIndex:idx_post_revisions_number,priority:2"`
+	UserId       int       `json:"user_id"`
+	Title        string    `json:"title" gorm:"size:255"`
+	Anons        string    `json:"anons" gorm:"type:text"`
+	Body         string    `json:"body" gorm:"type:mediumtext"`
+	RevertedFrom *int      `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+// Kinds of search documents.
+const (
+	SearchKindPost    = "post"
+	SearchKindComment = "comment"
+)
+
+// SearchDocument is the searchable text of a post or a comment.
+// RefId is the id of the post or the comment, PostId is the post it belongs to.
+type SearchDocument struct {
+	Kind   string `json:"kind" gorm:"primaryKey;size:16"`
+	RefId  int    `json:"ref_id" gorm:"primaryKey;autoIncrement:false"`
+	PostId int    `json:"post_id" gorm:"index"`
+	Title  string `json:"title" gorm:"size:255"`
+	Body   string `json:"body" gorm:"type:mediumtext"`
+}
+
+// SearchHit is a document that matches a query; a higher score is a better match.
+type SearchHit struct {
+	SearchDocument
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Session is a signed-in device. Its id is the family id of the refresh
+// tokens issued to the device and the sid claim of its access tokens.
+type Session struct {
+	Id         string     `json:"id" gorm:"primaryKey;size:64"`
+	UserId     int        `json:"user_id" gorm:"index"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IP         string     `json:"ip" gorm:"size:64"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SigningKey is a key pair that signs JWTs. New tokens are signed until
+// SignUntil, the public key verifies tokens until VerifyUntil.
+type SigningKey struct {
+	Kid         string    `json:"kid" gorm:"primaryKey;size:64"`
+	Algorithm   string    `json:"alg" gorm:"size:16"`
+	PrivateKey  string    `json:"-" gorm:"type:text"`
+	PublicKey   string    `json:"public_key" gorm:"type:text"`
+	SignUntil   time.Time `json:"sign_until"`
+	VerifyUntil time.Time `json:"verify_until" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+type Tag struct {
+	Id   int    `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"size:64;uniqueIndex"`
+}
+
+// PostTag links a post to one of its tags.
+type PostTag struct {
+	PostId int `gorm:"primaryKey"`
+	TagId  int `gorm:"primaryKey;index"`
+}
+
+// TagCount is a tag with the number of published posts that have it.
+type TagCount struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Posts int    `json:"posts"`
+}
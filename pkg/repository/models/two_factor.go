@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP secret of a user. It is pending until Enabled
+// is set by confirming a code. LastStep prevents reusing a code.
+type TwoFactor struct {
+	UserId      int        `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Secret      string     `json:"-" gorm:"size:64"`
+	Enabled     bool       `json:"enabled" gorm:"not null;default:false"`
+	LastStep    int64      `json:"-" gorm:"not null;default:0"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// RecoveryCode is a single-use code that replaces a TOTP code.
+type RecoveryCode struct {
+	Id       int        `json:"id" gorm:"primaryKey"`
+	UserId   int        `json:"user_id" gorm:"index"`
+	CodeHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	UsedAt   *time.Time `json:"used_at"`
+}
@@ -2,7 +2,14 @@ package models
 
 type User struct {
 	Id       int    `json:"id" db:"id"`
-	Name     string `json:"name" form:"name" binding:"required"`
-	Username string `json:"username" form:"username"  binding:"required"`
-	Password string `json:"password" gorm:"column:password_hash" form:"password"  binding:"required"`
+	Name     string `json:"name" form:"name"`
+	Username string `json:"username" form:"username"`
+	Password string `json:"password" gorm:"column:password_hash" form:"password"`
+	Role     string `json:"role" gorm:"size:32;not null;default:user"`
+
+	Email         string `json:"email" form:"email" gorm:"size:255;index"`
+	EmailVerified bool   `json:"email_verified" gorm:"not null;default:false"`
+
+	Bio       string `json:"bio" gorm:"size:500"`
+	AvatarUrl string `json:"avatar_url" gorm:"size:500"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// UserToken is a single-use token sent to the user, e.g. in a verification email.
+type UserToken struct {
+	Id        int        `json:"id" gorm:"primaryKey"`
+	UserId    int        `json:"user_id" gorm:"index"`
+	Purpose   string     `json:"purpose" gorm:"size:32"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
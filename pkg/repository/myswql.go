@@ -4,12 +4,36 @@ import (
 	"fmt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"test/pkg/repository/models"
 )
 
 const (
 	UsersTable    = "users"
 	PostsTable    = "posts"
 	CommentsTable = "comments"
+
+	RefreshTokensTable = "refresh_tokens"
+	RevokedTokensTable = "revoked_tokens"
+
+	ExternalIdentitiesTable = "external_identities"
+	UserTokensTable         = "user_tokens"
+	ApiKeysTable            = "api_keys"
+	TwoFactorsTable         = "two_factors"
+	RecoveryCodesTable      = "recovery_codes"
+	SigningKeysTable        = "signing_keys"
+	SessionsTable           = "sessions"
+
+	TagsTable     = "tags"
+	PostTagsTable = "post_tags"
+
+	SearchDocumentsTable = "search_documents"
+	PostRevisionsTable   = "post_revisions"
+
+	ReactionsTable      = "reactions"
+	ReactionCountsTable = "reaction_counts"
+
+	MediaTable       = "media"
+	MediaUsagesTable = "media_usages"
 )
 
 type Config struct {
@@ -21,10 +45,128 @@ type Config struct {
 }
 
 func NewRepositoryDB(cnf Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s@%s%s(%s)/%s", cnf.Username, cnf.Password, cnf.Host, cnf.Url, cnf.DBName)
+	dsn := fmt.Sprintf("%s@%s%s(%s)/%s?parseTime=true", cnf.Username, cnf.Password, cnf.Host, cnf.Url, cnf.DBName)
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
+
+// Migrate creates the tables that are managed by gorm
+// and adds the columns that were introduced later to the existing ones.
+func Migrate(db *gorm.DB) error {
+	err := db.AutoMigrate(
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.ExternalIdentity{},
+		&models.UserToken{},
+		&models.ApiKey{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.SigningKey{},
+		&models.Session{},
+		&models.Tag{},
+		&models.PostTag{},
+		&models.Reaction{},
+		&models.ReactionCount{},
+		&models.Media{},
+	)
+	if err != nil {
+		return err
+	}
+	if err = addColumns(db, &models.User{}, "Role", "Email", "EmailVerified", "Bio", "AvatarUrl"); err != nil {
+		return err
+	}
+	if err = addColumns(db, &models.Post{}, "CreatedAt", "UpdatedAt", "DeletedAt", "Status", "PublishAt", "Body"); err != nil {
+		return err
+	}
+	if err = addColumns(db, &models.Comment{}, "CreatedAt", "UpdatedAt", "DeletedAt", "ParentId", "Depth", "Path"); err != nil {
+		return err
+	}
+	// pages of a user's posts, of a post's comments and of the trash,
+	// the replies of threads and the due scheduled posts are read by these indexes
+	if err = addIndexes(db, &models.Post{}, "UserId", "DeletedAt", "Status", "PublishAt"); err != nil {
+		return err
+	}
+	if err = addIndexes(db, &models.Comment{}, "PostId", "DeletedAt", "ParentId", "Path"); err != nil {
+		return err
+	}
+	if err = migrateRevisions(db); err != nil {
+		return err
+	}
+	if err = migrateMediaUsages(db); err != nil {
+		return err
+	}
+	return migrateSearch(db)
+}
+
+// migrateRevisions creates the revision history and saves
+// the current state of the existing posts as their first revision.
+func migrateRevisions(db *gorm.DB) error {
+	backfill := !db.Migrator().HasTable(&models.PostRevision{})
+	if err := db.AutoMigrate(&models.PostRevision{}); err != nil || !backfill {
+		return err
+	}
+	return db.Exec("INSERT INTO " + PostRevisionsTable + " (post_id, number, user_id, title, anons, body, created_at) " +
+		"SELECT id, 1, user_id, title, anons, body, created_at FROM " + PostsTable).Error
+}
+
+// migrateMediaUsages creates the media usages and sums up the media uploaded so far.
+func migrateMediaUsages(db *gorm.DB) error {
+	backfill := !db.Migrator().HasTable(&models.MediaUsage{})
+	if err := db.AutoMigrate(&models.MediaUsage{}); err != nil || !backfill {
+		return err
+	}
+	return db.Exec("INSERT INTO " + MediaUsagesTable + " (user_id, bytes) " +
+		"SELECT user_id, SUM(size) FROM " + MediaTable + " GROUP BY user_id").Error
+}
+
+// migrateSearch creates the search documents of the database
+// and fills them from the existing posts and comments.
+func migrateSearch(db *gorm.DB) error {
+	backfill := !db.Migrator().HasTable(&models.SearchDocument{})
+	var err error
+	if db.Dialector.Name() == "postgres" {
+		err = migratePostgresSearch(db)
+	} else {
+		err = migrateMySQLSearch(db)
+	}
+	if err != nil || !backfill {
+		return err
+	}
+	err = db.Exec("INSERT INTO "+SearchDocumentsTable+" (kind, ref_id, post_id, title, body) "+
+		"SELECT ?, id, id, title, anons FROM "+PostsTable+" WHERE deleted_at IS NULL AND status = ?",
+		models.SearchKindPost, models.PostStatusPublished).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("INSERT INTO "+SearchDocumentsTable+" (kind, ref_id, post_id, title, body) "+
+		"SELECT ?, id, post_id, '', body FROM "+CommentsTable+" WHERE deleted_at IS NULL "+
+		"AND post_id IN (SELECT id FROM "+PostsTable+" WHERE deleted_at IS NULL AND status = ?)",
+		models.SearchKindComment, models.PostStatusPublished).Error
+}
+
+func addColumns(db *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if db.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addIndexes(db *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if db.Migrator().HasIndex(model, field) {
+			continue
+		}
+		if err := db.Migrator().CreateIndex(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,9 +1,10 @@
 package repository
 
 import (
-	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"test/pkg/repository/models"
+	"time"
 )
 
 type PostRepository struct {
@@ -13,40 +14,203 @@ type PostRepository struct {
 func NewPostRepository(db *gorm.DB) *PostRepository {
 	return &PostRepository{db: db}
 }
-func (p *PostRepository) Get() ([]models.Post, error) {
+
+func (p *PostRepository) Get(filter PostFilter, page Page) ([]models.Post, error) {
 	var posts []models.Post
-	err := p.db.Find(&posts).Error
-	return posts, err
+	query := p.db.Table(PostsTable)
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	query = applyCreatedRange(query, filter.CreatedFrom, filter.CreatedTo)
+	if filter.Title != "" {
+		query = query.Where("title LIKE ?", containsPattern(filter.Title))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.ViewerId != 0 {
+		query = query.Where("status = ? OR user_id = ?", models.PostStatusPublished, filter.ViewerId)
+	} else {
+		query = query.Where("status = ?", models.PostStatusPublished)
+	}
+	if filter.Tag != "" {
+		tagged := p.db.Table(PostTagsTable+" pt").Select("1").
+			Joins("JOIN "+TagsTable+" t ON t.id = pt.tag_id").
+			Where("pt.post_id = "+PostsTable+".id AND t.name = ?", filter.Tag)
+		query = query.Where("EXISTS (?)", tagged)
+	}
+	if err := applyPage(query, page).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, loadTags(p.db, posts)
 }
 
 func (p *PostRepository) GetById(id int) (models.Post, error) {
 	var post models.Post
-	err := p.db.Table(PostsTable).First(&post, id).Error
-	return post, err
-}
-
-func (p *PostRepository) GetByUserId(userId int) ([]models.Post, error) {
-	var posts []models.Post
-	query := fmt.Sprintf("SELECT * FROM %s post WHERE post.user_id = %d",
-		PostsTable, userId)
-	err := p.db.Raw(query).Scan(&posts).Error
-	if err != nil {
-		return nil, err
+	if err := p.db.Table(PostsTable).First(&post, id).Error; err != nil {
+		return post, err
 	}
-	return posts, nil
+	posts := []models.Post{post}
+	err := loadTags(p.db, posts)
+	return posts[0], err
 }
 
+// Create saves the post together with its tags and first revision.
 func (p *PostRepository) Create(post models.Post) (int, error) {
-	errPost := p.db.Select(PostsTable, "user_id", "title", "anons").Create(&post).Error
+	errPost := p.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Select(PostsTable, "user_id", "title", "anons", "body", "status", "publish_at", "created_at", "updated_at").
+			Create(&post).Error
+		if err != nil {
+			return err
+		}
+		if len(post.Tags) > 0 {
+			if err = setPostTags(tx, post.Id, post.Tags); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.PostRevision{
+			PostId: post.Id,
+			Number: 1,
+			UserId: post.UserId,
+			Title:  post.Title,
+			Anons:  post.Anons,
+			Body:   post.Body,
+		}).Error
+	})
 	return post.Id, errPost
 }
 
-func (p *PostRepository) Update(id int, post models.Post) error {
-	err := p.db.Select(PostsTable, "title", "anons").Where("id = ?", id).Updates(&post).Error
-	return err
+// Update sets the title, anons and body of the post to those of the revision
+// and appends the revision to the history of the post.
+// The tags are replaced unless they are nil.
+func (p *PostRepository) Update(id int, revision models.PostRevision, tags []string) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		// the lock serializes updates of the post, so revision numbers don't collide
+		err := tx.Table(PostsTable).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Post{}, id).Error
+		if err != nil {
+			return err
+		}
+		var last int
+		err = tx.Table(PostRevisionsTable).Select("COALESCE(MAX(number), 0)").Where("post_id = ?", id).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		post := models.Post{Title: revision.Title, Anons: revision.Anons, Body: revision.Body}
+		if err = tx.Select(PostsTable, "title", "anons", "body", "updated_at").Where("id = ?", id).Updates(&post).Error; err != nil {
+			return err
+		}
+		if tags != nil {
+			if err = setPostTags(tx, id, tags); err != nil {
+				return err
+			}
+		}
+		revision.Id = 0
+		revision.PostId = id
+		revision.Number = last + 1
+		return tx.Create(&revision).Error
+	})
+}
+
+func (p *PostRepository) SetStatus(id int, status string, publishAt *time.Time) error {
+	res := p.db.Model(&models.Post{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "publish_at": publishAt})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+// GetDue returns up to limit scheduled posts whose time to be published has come.
+func (p *PostRepository) GetDue(now time.Time, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := p.db.Table(PostsTable).Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, now).
+		Order("publish_at").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// PublishDue publishes the post if it is still scheduled and due. The update is
+// conditional, so when several instances race for a post only one of them gets true.
+func (p *PostRepository) PublishDue(id int, now time.Time) (bool, error) {
+	res := p.db.Model(&models.Post{}).
+		Where("id = ? AND status = ? AND publish_at <= ?", id, models.PostStatusScheduled, now).
+		Update("status", models.PostStatusPublished)
+	return res.RowsAffected == 1, res.Error
+}
+
+// GetRevisions returns a page of the revisions of a post.
+func (p *PostRepository) GetRevisions(postId int, page Page) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	query := p.db.Table(PostRevisionsTable).Where("post_id = ?", postId)
+	err := applyPage(query, page).Find(&revisions).Error
+	return revisions, err
 }
 
-func (p *PostRepository) Delete(id int) error {
-	errPost := p.db.Table(PostsTable).Where("id = ?", id).Delete(&models.Post{}).Error
+func (p *PostRepository) GetRevision(postId, number int) (models.PostRevision, error) {
+	var revision models.PostRevision
+	err := p.db.Table(PostRevisionsTable).Where("post_id = ? AND number = ?", postId, number).First(&revision).Error
+	return revision, err
+}
+
+// Delete moves the post to the trash and records the user who deleted it.
+func (p *PostRepository) Delete(id, deletedBy int) error {
+	errPost := p.db.Table(PostsTable).Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "deleted_by": deletedBy}).Error
 	return errPost
 }
+
+// GetDeleted returns a page of the posts in the trash.
+func (p *PostRepository) GetDeleted(page Page) ([]models.Post, error) {
+	var posts []models.Post
+	query := p.db.Unscoped().Table(PostsTable).Where("deleted_at IS NOT NULL")
+	err := applyPage(query, page).Find(&posts).Error
+	return posts, err
+}
+
+func (p *PostRepository) GetDeletedById(id int) (models.Post, error) {
+	var post models.Post
+	err := p.db.Unscoped().Table(PostsTable).Where("deleted_at IS NOT NULL").First(&post, id).Error
+	return post, err
+}
+
+func (p *PostRepository) Restore(id int) error {
+	res := p.db.Unscoped().Table(PostsTable).Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": 0})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+// Purge deletes the posts that were moved to the trash before the time for good,
+// together with their comments, tags, revisions and reactions, and returns the number of posts.
+func (p *PostRepository) Purge(deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		posts := func() *gorm.DB {
+			return tx.Table(PostsTable).Select("id").Where("deleted_at < ?", deletedBefore)
+		}
+		comments := func() *gorm.DB {
+			return tx.Table(CommentsTable).Select("id").Where("post_id IN (?)", posts())
+		}
+		if err := deleteReactions(tx, models.ReactionTargetComment, comments); err != nil {
+			return err
+		}
+		if err := deleteReactions(tx, models.ReactionTargetPost, posts); err != nil {
+			return err
+		}
+		err := tx.Unscoped().Table(CommentsTable).Where("post_id IN (?)", posts()).Delete(&models.Comment{}).Error
+		if err != nil {
+			return err
+		}
+		if err = tx.Table(PostTagsTable).Where("post_id IN (?)", posts()).Delete(&models.PostTag{}).Error; err != nil {
+			return err
+		}
+		if err = tx.Table(PostRevisionsTable).Where("post_id IN (?)", posts()).Delete(&models.PostRevision{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Table(PostsTable).Where("deleted_at < ?", deletedBefore).Delete(&models.Post{})
+		purged = res.RowsAffected
+		return res.Error
+	})
+	return purged, err
+}
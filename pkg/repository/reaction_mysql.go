@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"test/pkg/repository/models"
+)
+
+type ReactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) *ReactionRepository {
+	return &ReactionRepository{db: db}
+}
+
+// AddReaction saves the reaction and counts it. It returns false when the user
+// has already reacted so; the unique index makes concurrent requests count once.
+func (r *ReactionRepository) AddReaction(reaction models.Reaction) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		added = true
+		count := models.ReactionCount{TargetType: reaction.TargetType, TargetId: reaction.TargetId, Kind: reaction.Kind, Count: 1}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "target_type"}, {Name: "target_id"}, {Name: "kind"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr(ReactionCountsTable + ".count + 1")}),
+		}).Create(&count).Error
+	})
+	return added && err == nil, err
+}
+
+// RemoveReaction deletes the reaction and uncounts it. It returns false when there was no such reaction.
+func (r *ReactionRepository) RemoveReaction(reaction models.Reaction) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("target_type = ? AND target_id = ? AND kind = ? AND user_id = ?",
+			reaction.TargetType, reaction.TargetId, reaction.Kind, reaction.UserId).Delete(&models.Reaction{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		removed = true
+		return tx.Model(&models.ReactionCount{}).
+			Where("target_type = ? AND target_id = ? AND kind = ? AND count > 0", reaction.TargetType, reaction.TargetId, reaction.Kind).
+			Update("count", gorm.Expr("count - 1")).Error
+	})
+	return removed && err == nil, err
+}
+
+// GetReactions returns a page of the reactions to a target, of one kind unless kind is empty.
+func (r *ReactionRepository) GetReactions(targetType string, targetId int, kind string, page Page) ([]models.Reaction, error) {
+	var reactions []models.Reaction
+	query := r.db.Table(ReactionsTable).Where("target_type = ? AND target_id = ?", targetType, targetId)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := applyPage(query, page).Find(&reactions).Error
+	return reactions, err
+}
+
+// GetReactionCounts returns the counts of the reactions to the targets.
+func (r *ReactionRepository) GetReactionCounts(targetType string, targetIds []int) ([]models.ReactionCount, error) {
+	var counts []models.ReactionCount
+	err := r.db.Where("target_type = ? AND target_id IN ? AND count > 0", targetType, targetIds).
+		Order("target_id, kind").Find(&counts).Error
+	return counts, err
+}
+
+// GetUserReactions returns the reactions of the user to the targets.
+func (r *ReactionRepository) GetUserReactions(targetType string, targetIds []int, userId int) ([]models.Reaction, error) {
+	var reactions []models.Reaction
+	err := r.db.Where("target_type = ? AND target_id IN ? AND user_id = ?", targetType, targetIds, userId).
+		Order("target_id, kind").Find(&reactions).Error
+	return reactions, err
+}
+
+// deleteReactions deletes the reactions to the targets selected by the subquery and their counts.
+func deleteReactions(tx *gorm.DB, targetType string, targetIds func() *gorm.DB) error {
+	err := tx.Where("target_type = ? AND target_id IN (?)", targetType, targetIds()).Delete(&models.Reaction{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("target_type = ? AND target_id IN (?)", targetType, targetIds()).Delete(&models.ReactionCount{}).Error
+}
+
+// deleteUserReactions deletes the reactions of the user and uncounts them.
+// A user has at most one reaction of a kind per target, so each count goes down by one.
+func deleteUserReactions(tx *gorm.DB, userId int) error {
+	err := tx.Exec("UPDATE "+ReactionCountsTable+" SET count = count - 1 WHERE count > 0 AND EXISTS "+
+		"(SELECT 1 FROM "+ReactionsTable+" r WHERE r.user_id = ? AND r.target_type = "+ReactionCountsTable+".target_type "+
+		"AND r.target_id = "+ReactionCountsTable+".target_id AND r.kind = "+ReactionCountsTable+".kind)", userId).Error
+	if err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userId).Delete(&models.Reaction{}).Error
+}
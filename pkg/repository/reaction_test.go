@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestReactionRepository_AddReaction(t *testing.T) {
+	db, mock := newMockDB(t)
+	reactions := NewReactionRepository(db)
+	reaction := models.Reaction{TargetType: models.ReactionTargetPost, TargetId: 4, Kind: models.ReactionLike, UserId: 3}
+	insert := "INSERT INTO `reactions` (`target_type`,`target_id`,`kind`,`user_id`,`created_at`) VALUES (?,?,?,?,?) " +
+		"ON DUPLICATE KEY UPDATE `id`=`id`"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).
+		WithArgs(models.ReactionTargetPost, 4, models.ReactionLike, 3, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("INSERT INTO `reaction_counts` (`target_type`,`target_id`,`kind`,`count`) VALUES (?,?,?,?) "+
+		"ON DUPLICATE KEY UPDATE `count`=reaction_counts.count + 1").
+		WithArgs(models.ReactionTargetPost, 4, models.ReactionLike, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	added, err := reactions.AddReaction(reaction)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	// the same reaction again is not counted
+	mock.ExpectBegin()
+	mock.ExpectExec(insert).
+		WithArgs(models.ReactionTargetPost, 4, models.ReactionLike, 3, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	added, err = reactions.AddReaction(reaction)
+	assert.NoError(t, err)
+	assert.False(t, added)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReactionRepository_RemoveReaction(t *testing.T) {
+	db, mock := newMockDB(t)
+	reactions := NewReactionRepository(db)
+	reaction := models.Reaction{TargetType: models.ReactionTargetComment, TargetId: 9, Kind: "love", UserId: 3}
+	remove := "DELETE FROM `reactions` WHERE target_type = ? AND target_id = ? AND kind = ? AND user_id = ?"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(remove).
+		WithArgs(models.ReactionTargetComment, 9, "love", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `reaction_counts` SET `count`=count - 1 WHERE target_type = ? AND target_id = ? AND kind = ? AND count > 0").
+		WithArgs(models.ReactionTargetComment, 9, "love").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	removed, err := reactions.RemoveReaction(reaction)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(remove).
+		WithArgs(models.ReactionTargetComment, 9, "love", 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	removed, err = reactions.RemoveReaction(reaction)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
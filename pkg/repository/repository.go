@@ -3,41 +3,203 @@ package repository
 import (
 	"gorm.io/gorm"
 	"test/pkg/repository/models"
+	"time"
 )
 
+//go:generate mockgen -source=repository.go -destination=mocks/mock.go
+
 type Authorization interface {
 	CreateUser(user models.User) (int, error)
-	GetUser(username, password string) (models.User, error)
+	GetUser(username string) (models.User, error)
+	GetUserById(id int) (models.User, error)
+	GetUserByEmail(email string) (models.User, error)
+	SetEmailVerified(id int) error
+	UpdateRole(id int, role string) error
+	UpdatePasswordHash(id int, passwordHash string) error
 	CheckUser(username string) error
 	Testing(name string) (string, error)
 }
 
 type Post interface {
 	Create(post models.Post) (int, error)
-	Get() ([]models.Post, error)
+	Get(filter PostFilter, page Page) ([]models.Post, error)
 	GetById(id int) (models.Post, error)
-	GetByUserId(userId int) ([]models.Post, error)
-	Update(id int, post models.Post) error
-	Delete(id int) error
+	Update(id int, revision models.PostRevision, tags []string) error
+	SetStatus(id int, status string, publishAt *time.Time) error
+	GetDue(now time.Time, limit int) ([]models.Post, error)
+	PublishDue(id int, now time.Time) (bool, error)
+	Delete(id, deletedBy int) error
+	GetRevisions(postId int, page Page) ([]models.PostRevision, error)
+	GetRevision(postId, number int) (models.PostRevision, error)
+	GetDeleted(page Page) ([]models.Post, error)
+	GetDeletedById(id int) (models.Post, error)
+	Restore(id int) error
+	Purge(deletedBefore time.Time) (int64, error)
 }
 
 type Comment interface {
 	Create(comment models.Comment) (int, error)
-	Get(postId int) ([]models.Comment, error)
+	Get(postId int, filter CommentFilter, page Page) ([]models.Comment, error)
+	GetReplies(postId int, parentIds []int, limit int) ([]models.Comment, error)
+	CountReplies(postId int, parentIds []int) (map[int]int, error)
+	GetById(postId, id int) (models.Comment, error)
 	Update(postId, id int, comment models.Comment) error
-	Delete(postId, id int) error
+	Delete(postId, id, deletedBy int) error
+	GetDeleted(page Page) ([]models.Comment, error)
+	GetDeletedById(postId, id int) (models.Comment, error)
+	Restore(postId, id int) error
+	Purge(deletedBefore time.Time) (int64, error)
+}
+
+type Tag interface {
+	GetTags(page Page) ([]models.TagCount, error)
+	GetTag(name string) (models.Tag, error)
+	GetTagById(id int) (models.Tag, error)
+	FindTags(prefix string, limit int) ([]models.Tag, error)
+	RenameTag(id int, name string) error
+	MergeTags(id, intoId int) error
+}
+
+// Reaction keeps the reactions with their counts. AddReaction and RemoveReaction
+// report whether they changed anything, repeated requests are not counted twice.
+type Reaction interface {
+	AddReaction(reaction models.Reaction) (bool, error)
+	RemoveReaction(reaction models.Reaction) (bool, error)
+	GetReactions(targetType string, targetId int, kind string, page Page) ([]models.Reaction, error)
+	GetReactionCounts(targetType string, targetIds []int) ([]models.ReactionCount, error)
+	GetUserReactions(targetType string, targetIds []int, userId int) ([]models.Reaction, error)
+}
+
+// Media keeps the records of the uploaded media, their contents are in the BlobStore.
+type Media interface {
+	CreateMedia(media models.Media) (int, error)
+	GetMedia(postId int) ([]models.Media, error)
+	GetMediaById(postId, id int) (models.Media, error)
+	ReserveMediaUsage(userId int, size, quota int64) (bool, error)
+	ReleaseMediaUsage(userId int, size int64) error
+	GetOrphanedMedia(limit int) ([]models.Media, error)
+	DeleteMedia(ids []int) error
+}
+
+type Token interface {
+	CreateRefreshToken(token models.RefreshToken) error
+	GetRefreshToken(tokenHash string) (models.RefreshToken, error)
+	UseRefreshToken(id int) (bool, error)
+	RevokeFamily(familyId string) error
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	RevokeUserFamilies(userId int) error
+}
+
+type Identity interface {
+	GetIdentity(provider, subject string) (models.ExternalIdentity, error)
+	CreateIdentity(identity models.ExternalIdentity) error
+}
+
+type UserToken interface {
+	CreateUserToken(token models.UserToken) error
+	GetUserToken(purpose, tokenHash string) (models.UserToken, error)
+	UseUserToken(id int) (bool, error)
+	InvalidateUserTokens(userId int, purpose string) error
+}
+
+type ApiKey interface {
+	CreateApiKey(key models.ApiKey) (int, error)
+	GetApiKeys(userId int) ([]models.ApiKey, error)
+	GetApiKeyByHash(keyHash string) (models.ApiKey, error)
+	TouchApiKey(id int, usedAt time.Time) error
+	RevokeApiKey(userId, id int) (bool, error)
+	RevokeUserApiKeys(userId int) error
+}
+
+type TwoFactor interface {
+	GetTwoFactor(userId int) (models.TwoFactor, error)
+	SaveTwoFactor(twoFactor models.TwoFactor) error
+	EnableTwoFactor(userId int) error
+	DeleteTwoFactor(userId int) error
+	UseTotpStep(userId int, step int64) (bool, error)
+	ReplaceRecoveryCodes(userId int, codeHashes []string) error
+	UseRecoveryCode(userId int, codeHash string) (bool, error)
+}
+
+type SigningKey interface {
+	GetSigningKeys(at time.Time) ([]models.SigningKey, error)
+	CreateSigningKey(key models.SigningKey) error
+	DeleteSigningKeys(before time.Time) error
+}
+
+type Session interface {
+	CreateSession(session models.Session) error
+	GetSession(id string) (models.Session, error)
+	GetSessions(userId int) ([]models.Session, error)
+	TouchSession(id string, at time.Time) error
+}
+
+type User interface {
+	GetById(id int) (models.User, error)
+	UpdateProfile(id int, user models.User, fields ...string) error
+	DeleteUser(id int, anonymize bool) (DeletedContent, error)
+}
+
+// DeletedContent is what was deleted together with a user.
+type DeletedContent struct {
+	Posts    []int
+	Comments []int
+}
+
+// SearchIndex finds posts and comments by their text.
+// RemovePost removes a post together with the comments under it.
+type SearchIndex interface {
+	Index(document models.SearchDocument) error
+	Remove(kind string, refId int) error
+	RemovePost(postId int) error
+	Search(query string, limit int) ([]models.SearchHit, error)
 }
 
 type Repository struct {
 	Authorization
 	Post
 	Comment
+	Tag
+	Reaction
+	Media
+	Token
+	Identity
+	UserToken
+	ApiKey
+	TwoFactor
+	SigningKey
+	Session
+	User
+	SearchIndex
+	Blobs BlobStore
 }
 
-func NewRepository(db *gorm.DB) *Repository {
+func NewRepository(db *gorm.DB, blobs BlobStore) *Repository {
 	return &Repository{
 		Authorization: NewAuthRepository(db),
 		Post:          NewPostRepository(db),
 		Comment:       NewCommentRepository(db),
+		Tag:           NewTagRepository(db),
+		Reaction:      NewReactionRepository(db),
+		Media:         NewMediaRepository(db),
+		Token:         NewTokenRepository(db),
+		Identity:      NewIdentityRepository(db),
+		UserToken:     NewUserTokenRepository(db),
+		ApiKey:        NewApiKeyRepository(db),
+		TwoFactor:     NewTwoFactorRepository(db),
+		SigningKey:    NewSigningKeyRepository(db),
+		Session:       NewSessionRepository(db),
+		User:          NewUserRepository(db),
+		SearchIndex:   NewSearchIndex(db),
+		Blobs:         blobs,
+	}
+}
+
+// NewSearchIndex returns the full-text search backend of the database.
+func NewSearchIndex(db *gorm.DB) SearchIndex {
+	if db.Dialector.Name() == "postgres" {
+		return NewPostgresSearchIndex(db)
 	}
+	return NewMySQLSearchIndex(db)
 }
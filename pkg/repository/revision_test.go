@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestPostRepository_Update(t *testing.T) {
+	db, mock := newMockDB(t)
+	number := 2
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT `id` FROM `posts` WHERE `posts`.`id` = ? AND `posts`.`deleted_at` IS NULL " +
+		"ORDER BY `posts`.`id` LIMIT 1 FOR UPDATE").
+		WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	mock.ExpectQuery("SELECT COALESCE(MAX(number), 0) FROM `post_revisions` WHERE post_id = ?").
+		WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(3))
+	mock.ExpectExec("UPDATE `posts` SET `title`=?,`anons`=?,`body`=?,`updated_at`=? WHERE id = ? AND `posts`.`deleted_at` IS NULL").
+		WithArgs("title", "anons", "# body", sqlmock.AnyArg(), 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `post_revisions` (`post_id`,`number`,`user_id`,`title`,`anons`,`body`,`reverted_from`,`created_at`) "+
+		"VALUES (?,?,?,?,?,?,?,?)").
+		WithArgs(4, 4, 3, "title", "anons", "# body", 2, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(8, 1))
+	mock.ExpectCommit()
+
+	err := NewPostRepository(db).Update(4, models.PostRevision{
+		UserId: 3, Title: "title", Anons: "anons", Body: "# body", RevertedFrom: &number,
+	}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
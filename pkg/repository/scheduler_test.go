@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestPostRepository_GetDue(t *testing.T) {
+	db, mock := newMockDB(t)
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT * FROM `posts` WHERE (status = ? AND publish_at <= ?) AND `posts`.`deleted_at` IS NULL ORDER BY publish_at LIMIT 100").
+		WithArgs(models.PostStatusScheduled, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow(4, "title", models.PostStatusScheduled))
+	posts, err := NewPostRepository(db).GetDue(now, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Post{{Id: 4, Title: "title", Status: models.PostStatusScheduled}}, posts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_PublishDue(t *testing.T) {
+	db, mock := newMockDB(t)
+	posts := NewPostRepository(db)
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	query := "UPDATE `posts` SET `status`=?,`updated_at`=? WHERE (id = ? AND status = ? AND publish_at <= ?) AND `posts`.`deleted_at` IS NULL"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).
+		WithArgs(models.PostStatusPublished, sqlmock.AnyArg(), 4, models.PostStatusScheduled, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	ok, err := posts.PublishDue(4, now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Another instance has already published the post.
+	mock.ExpectBegin()
+	mock.ExpectExec(query).
+		WithArgs(models.PostStatusPublished, sqlmock.AnyArg(), 4, models.PostStatusScheduled, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	ok, err = posts.PublishDue(4, now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"test/pkg/repository/models"
+	"unicode"
+)
+
+// titleWeight makes a word in the title count like this many words in the body.
+const titleWeight = 2
+
+type documentKey struct {
+	kind  string
+	refId int
+}
+
+// MemorySearchIndex is an inverted index kept in memory. It scores documents
+// by tf-idf and is meant for tests and single instance setups.
+type MemorySearchIndex struct {
+	mu        sync.RWMutex
+	documents map[documentKey]models.SearchDocument
+	postings  map[string]map[documentKey]int
+}
+
+func NewMemorySearchIndex() *MemorySearchIndex {
+	return &MemorySearchIndex{
+		documents: make(map[documentKey]models.SearchDocument),
+		postings:  make(map[string]map[documentKey]int),
+	}
+}
+
+func (s *MemorySearchIndex) Index(document models.SearchDocument) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := documentKey{kind: document.Kind, refId: document.RefId}
+	s.remove(key)
+	s.documents[key] = document
+	for term, count := range termCounts(document) {
+		if s.postings[term] == nil {
+			s.postings[term] = make(map[documentKey]int)
+		}
+		s.postings[term][key] = count
+	}
+	return nil
+}
+
+func (s *MemorySearchIndex) Remove(kind string, refId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(documentKey{kind: kind, refId: refId})
+	return nil
+}
+
+func (s *MemorySearchIndex) RemovePost(postId int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, document := range s.documents {
+		if document.PostId == postId {
+			s.remove(key)
+		}
+	}
+	return nil
+}
+
+func (s *MemorySearchIndex) Search(query string, limit int) ([]models.SearchHit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	scores := make(map[documentKey]float64)
+	for _, term := range uniqueTerms(query) {
+		postings := s.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(len(s.documents))/float64(len(postings)))
+		for key, count := range postings {
+			scores[key] += float64(count) * idf
+		}
+	}
+	hits := make([]models.SearchHit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, models.SearchHit{SearchDocument: s.documents[key], Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Kind != hits[j].Kind {
+			return hits[i].Kind > hits[j].Kind
+		}
+		return hits[i].RefId > hits[j].RefId
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func (s *MemorySearchIndex) remove(key documentKey) {
+	document, ok := s.documents[key]
+	if !ok {
+		return
+	}
+	for term := range termCounts(document) {
+		delete(s.postings[term], key)
+		if len(s.postings[term]) == 0 {
+			delete(s.postings, term)
+		}
+	}
+	delete(s.documents, key)
+}
+
+func termCounts(document models.SearchDocument) map[string]int {
+	counts := make(map[string]int)
+	for _, term := range Terms(document.Title) {
+		counts[term] += titleWeight
+	}
+	for _, term := range Terms(document.Body) {
+		counts[term]++
+	}
+	return counts
+}
+
+func uniqueTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Terms(text) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Terms splits text into lower case words of letters and digits.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
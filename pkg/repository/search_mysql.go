@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"test/pkg/repository/models"
+)
+
+// sqlSearchIndex writes the documents of the database backed indexes,
+// the databases keep their full-text indexes up to date themselves.
+type sqlSearchIndex struct {
+	db *gorm.DB
+}
+
+func (s *sqlSearchIndex) Index(document models.SearchDocument) error {
+	return s.db.Table(SearchDocumentsTable).Clauses(clause.OnConflict{UpdateAll: true}).Create(&document).Error
+}
+
+func (s *sqlSearchIndex) Remove(kind string, refId int) error {
+	return s.db.Table(SearchDocumentsTable).Where("kind = ? AND ref_id = ?", kind, refId).
+		Delete(&models.SearchDocument{}).Error
+}
+
+func (s *sqlSearchIndex) RemovePost(postId int) error {
+	return s.db.Table(SearchDocumentsTable).Where("post_id = ?", postId).Delete(&models.SearchDocument{}).Error
+}
+
+// MySQLSearchIndex keeps the documents in a table with a FULLTEXT index on title and body.
+type MySQLSearchIndex struct {
+	sqlSearchIndex
+}
+
+func NewMySQLSearchIndex(db *gorm.DB) *MySQLSearchIndex {
+	return &MySQLSearchIndex{sqlSearchIndex{db: db}}
+}
+
+func (s *MySQLSearchIndex) Search(query string, limit int) ([]models.SearchHit, error) {
+	var hits []models.SearchHit
+	err := s.db.Table(SearchDocumentsTable).
+		Select("kind, ref_id, post_id, title, body, MATCH (title, body) AGAINST (? IN NATURAL LANGUAGE MODE) AS score", query).
+		Where("MATCH (title, body) AGAINST (? IN NATURAL LANGUAGE MODE)", query).
+		Order("score DESC").Limit(limit).Find(&hits).Error
+	return hits, err
+}
+
+func migrateMySQLSearch(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.SearchDocument{}); err != nil {
+		return err
+	}
+	if db.Migrator().HasIndex(&models.SearchDocument{}, "idx_search_documents_text") {
+		return nil
+	}
+	return db.Exec("CREATE FULLTEXT INDEX idx_search_documents_text ON " + SearchDocumentsTable + " (title, body)").Error
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+)
+
+// PostgresSearchIndex keeps the documents in a table with a generated tsvector
+// column that is GIN indexed. Titles weigh more than bodies; the simple
+// configuration doesn't stem words, so snippets highlight the words that matched.
+type PostgresSearchIndex struct {
+	sqlSearchIndex
+}
+
+func NewPostgresSearchIndex(db *gorm.DB) *PostgresSearchIndex {
+	return &PostgresSearchIndex{sqlSearchIndex{db: db}}
+}
+
+func (s *PostgresSearchIndex) Search(query string, limit int) ([]models.SearchHit, error) {
+	var hits []models.SearchHit
+	err := s.db.Raw("SELECT kind, ref_id, post_id, title, body, ts_rank(document, query) AS score "+
+		"FROM "+SearchDocumentsTable+", websearch_to_tsquery('simple', ?) query "+
+		"WHERE document @@ query ORDER BY score DESC LIMIT ?", query, limit).Scan(&hits).Error
+	return hits, err
+}
+
+func migratePostgresSearch(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.SearchDocument{}); err != nil {
+		return err
+	}
+	err := db.Exec("ALTER TABLE " + SearchDocumentsTable + " ADD COLUMN IF NOT EXISTS document tsvector " +
+		"GENERATED ALWAYS AS (setweight(to_tsvector('simple', coalesce(title, '')), 'A') || " +
+		"setweight(to_tsvector('simple', coalesce(body, '')), 'B')) STORED").Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_search_documents_document ON " + SearchDocumentsTable +
+		" USING GIN (document)").Error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+	"strings"
+	"sync"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestMemorySearchIndex(t *testing.T) {
+	index := NewMemorySearchIndex()
+	assert.NoError(t, index.Index(models.SearchDocument{Kind: models.SearchKindPost, RefId: 1, PostId: 1,
+		Title: "Cooking pasta", Body: "Boil water, add salt"}))
+	assert.NoError(t, index.Index(models.SearchDocument{Kind: models.SearchKindComment, RefId: 2, PostId: 1,
+		Body: "I add pasta water to the sauce"}))
+	assert.NoError(t, index.Index(models.SearchDocument{Kind: models.SearchKindPost, RefId: 3, PostId: 3,
+		Title: "Gardening", Body: "Water the tomatoes"}))
+
+	hits, err := index.Search("PASTA water", 10)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 3)
+	assert.Equal(t, 1, hits[0].RefId)
+	assert.Equal(t, 2, hits[1].RefId)
+
+	hits, err = index.Search("pasta", 1)
+	assert.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	assert.NoError(t, index.Index(models.SearchDocument{Kind: models.SearchKindPost, RefId: 1, PostId: 1, Title: "Baking"}))
+	assert.NoError(t, index.Remove(models.SearchKindComment, 2))
+	hits, err = index.Search("pasta", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, hits)
+}
+
+// The longest post, 5000 symbols of anons and 100000 of body, doesn't fit a TEXT column of 64 KB.
+func TestMySQLSearchIndex_IndexLongPost(t *testing.T) {
+	body := strings.Repeat("слово ", 5000/6) + "\n\n" + strings.Repeat("𝄞", 100000)
+	assert.Greater(t, len(body), 1<<16)
+
+	db, mock := newMockDB(t)
+	document, err := schema.Parse(&models.SearchDocument{}, &sync.Map{}, db.NamingStrategy)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.DataType("mediumtext"), document.LookUpField("Body").DataType)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `search_documents` (`kind`,`ref_id`,`post_id`,`title`,`body`) VALUES (?,?,?,?,?) "+
+		"ON DUPLICATE KEY UPDATE `post_id`=VALUES(`post_id`),`title`=VALUES(`title`),`body`=VALUES(`body`)").
+		WithArgs(models.SearchKindPost, 1, 1, "Long", body).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, NewMySQLSearchIndex(db).Index(models.SearchDocument{Kind: models.SearchKindPost, RefId: 1, PostId: 1,
+		Title: "Long", Body: body}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLSearchIndex_Search(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery("SELECT kind, ref_id, post_id, title, body, MATCH (title, body) AGAINST (? IN NATURAL LANGUAGE MODE) AS score "+
+		"FROM `search_documents` WHERE MATCH (title, body) AGAINST (? IN NATURAL LANGUAGE MODE) ORDER BY score DESC LIMIT 20").
+		WithArgs("pasta", "pasta").
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "ref_id", "post_id", "title", "body", "score"}).
+			AddRow("post", 1, 1, "Cooking pasta", "Boil water", 0.9))
+
+	hits, err := NewMySQLSearchIndex(db).Search("pasta", 20)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.SearchHit{{SearchDocument: models.SearchDocument{Kind: "post", RefId: 1, PostId: 1,
+		Title: "Cooking pasta", Body: "Boil water"}, Score: 0.9}}, hits)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
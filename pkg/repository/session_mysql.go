@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (s *SessionRepository) CreateSession(session models.Session) error {
+	return s.db.Table(SessionsTable).Create(&session).Error
+}
+
+func (s *SessionRepository) GetSession(id string) (models.Session, error) {
+	var session models.Session
+	err := s.db.Table(SessionsTable).Where("id = ?", id).First(&session).Error
+	return session, err
+}
+
+// GetSessions returns the sessions of the user that are not revoked, most recently seen first.
+func (s *SessionRepository) GetSessions(userId int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := s.db.Table(SessionsTable).
+		Where("user_id = ? and revoked_at is null", userId).
+		Order("last_seen_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// TouchSession moves the last-seen time forward. Times less than
+// a minute apart are not written.
+func (s *SessionRepository) TouchSession(id string, at time.Time) error {
+	return s.db.Table(SessionsTable).
+		Where("id = ? and last_seen_at < ?", id, at.Add(-time.Minute)).
+		Update("last_seen_at", at).Error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+// GetSigningKeys returns the keys that still verify tokens at the time, newest first.
+func (s *SigningKeyRepository) GetSigningKeys(at time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := s.db.Table(SigningKeysTable).Where("verify_until > ?", at).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+func (s *SigningKeyRepository) CreateSigningKey(key models.SigningKey) error {
+	return s.db.Table(SigningKeysTable).Create(&key).Error
+}
+
+func (s *SigningKeyRepository) DeleteSigningKeys(before time.Time) error {
+	return s.db.Table(SigningKeysTable).Where("verify_until <= ?", before).Delete(&models.SigningKey{}).Error
+}
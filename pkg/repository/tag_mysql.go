@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"test/pkg/repository/models"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// GetTags returns a page of the tags with the number of their published posts.
+// Tags without such posts are listed too, so that they can be renamed or merged.
+func (t *TagRepository) GetTags(page Page) ([]models.TagCount, error) {
+	var tags []models.TagCount
+	counts := t.db.Table(TagsTable+" t").Select("t.id, t.name, COUNT(p.id) AS posts").
+		Joins("LEFT JOIN "+PostTagsTable+" pt ON pt.tag_id = t.id").
+		Joins("LEFT JOIN "+PostsTable+" p ON p.id = pt.post_id AND p.status = ? AND p.deleted_at IS NULL",
+			models.PostStatusPublished).
+		Group("t.id, t.name")
+	err := applyPage(t.db.Table("(?) AS tag_counts", counts), page).Find(&tags).Error
+	return tags, err
+}
+
+func (t *TagRepository) GetTag(name string) (models.Tag, error) {
+	var tag models.Tag
+	err := t.db.Where("name = ?", name).First(&tag).Error
+	return tag, err
+}
+
+func (t *TagRepository) GetTagById(id int) (models.Tag, error) {
+	var tag models.Tag
+	err := t.db.First(&tag, id).Error
+	return tag, err
+}
+
+// FindTags returns up to limit tags whose names start with prefix, in alphabetical order.
+func (t *TagRepository) FindTags(prefix string, limit int) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := t.db.Where("name LIKE ?", prefixPattern(prefix)).Order("name").Limit(limit).Find(&tags).Error
+	return tags, err
+}
+
+func (t *TagRepository) RenameTag(id int, name string) error {
+	res := t.db.Model(&models.Tag{}).Where("id = ?", id).Update("name", name)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+// MergeTags moves the posts of the tag to the other tag and deletes the tag.
+func (t *TagRepository) MergeTags(id, intoId int) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		// posts that have both tags keep only the link to the other one
+		err := tx.Exec("DELETE FROM "+PostTagsTable+" WHERE tag_id = ? AND post_id IN "+
+			"(SELECT post_id FROM (SELECT post_id FROM "+PostTagsTable+" WHERE tag_id = ?) AS merged)", id, intoId).Error
+		if err != nil {
+			return err
+		}
+		if err = tx.Table(PostTagsTable).Where("tag_id = ?", id).Update("tag_id", intoId).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&models.Tag{}, id)
+		if res.Error == nil && res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return res.Error
+	})
+}
+
+// setPostTags replaces the tags of the post and creates the tags that don't exist yet.
+func setPostTags(tx *gorm.DB, postId int, names []string) error {
+	if err := tx.Where("post_id = ?", postId).Delete(&models.PostTag{}).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return err
+	}
+	var ids []int
+	if err := tx.Table(TagsTable).Where("name IN ?", names).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	links := make([]models.PostTag, len(ids))
+	for i, id := range ids {
+		links[i] = models.PostTag{PostId: postId, TagId: id}
+	}
+	return tx.Create(&links).Error
+}
+
+// loadTags sets the tag names of the posts, in alphabetical order.
+func loadTags(db *gorm.DB, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(posts))
+	byId := make(map[int]*models.Post, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].Id
+		posts[i].Tags = []string{}
+		byId[posts[i].Id] = &posts[i]
+	}
+	var rows []struct {
+		PostId int
+		Name   string
+	}
+	err := db.Table(PostTagsTable+" pt").Select("pt.post_id, t.name").
+		Joins("JOIN "+TagsTable+" t ON t.id = pt.tag_id").
+		Where("pt.post_id IN ?", ids).Order("t.name").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if post, ok := byId[row.PostId]; ok {
+			post.Tags = append(post.Tags, row.Name)
+		}
+	}
+	return nil
+}
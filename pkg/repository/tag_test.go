@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestSetPostTags(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM `post_tags` WHERE post_id = ?").
+		WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `tags` (`name`) VALUES (?),(?) ON DUPLICATE KEY UPDATE `id`=`id`").
+		WithArgs("go", "databases").WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectQuery("SELECT `id` FROM `tags` WHERE name IN (?,?)").
+		WithArgs("go", "databases").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(9))
+	mock.ExpectExec("INSERT INTO `post_tags` (`post_id`,`tag_id`) VALUES (?,?),(?,?)").
+		WithArgs(4, 2, 4, 9).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return setPostTags(tx, 4, []string{"go", "databases"})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_GetTags(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery("SELECT * FROM (SELECT t.id, t.name, COUNT(p.id) AS posts FROM tags t "+
+		"LEFT JOIN post_tags pt ON pt.tag_id = t.id "+
+		"LEFT JOIN posts p ON p.id = pt.post_id AND p.status = ? AND p.deleted_at IS NULL GROUP BY t.id, t.name) AS tag_counts "+
+		"WHERE (`posts` < ? OR (`posts` = ? AND `id` < ?)) ORDER BY `posts` DESC,`id` DESC LIMIT 21").
+		WithArgs(models.PostStatusPublished, 3, 3, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "posts"}).AddRow(2, "go", 2))
+	tags, err := NewTagRepository(db).GetTags(Page{
+		Sort:  []Sort{{Column: "posts", Desc: true}, {Column: "id", Desc: true}},
+		After: []interface{}{3, 5},
+		Limit: 21,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Id: 2, Name: "go", Posts: 2}}, tags)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_MergeTags(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM post_tags WHERE tag_id = ? AND post_id IN "+
+		"(SELECT post_id FROM (SELECT post_id FROM post_tags WHERE tag_id = ?) AS merged)").
+		WithArgs(3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `post_tags` SET `tag_id`=? WHERE tag_id = ?").
+		WithArgs(2, 3).WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("DELETE FROM `tags` WHERE `tags`.`id` = ?").
+		WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, NewTagRepository(db).MergeTags(3, 2))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTagRepository_FindTags(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery("SELECT * FROM `tags` WHERE name LIKE ? ORDER BY name LIMIT 10").
+		WithArgs(`go\_%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "go_modules"))
+	tags, err := NewTagRepository(db).FindTags("go_", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Tag{{Id: 4, Name: "go_modules"}}, tags)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
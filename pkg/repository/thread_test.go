@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+const placeholders = "(comments.deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments r WHERE r.post_id = comments.post_id AND " +
+	"r.path LIKE CONCAT(comments.path, comments.id, '/%') AND r.deleted_at IS NULL))"
+
+func TestCommentRepository_GetThread(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	top := 0
+	mock.ExpectQuery("SELECT * FROM `comments` WHERE post_id = ? AND "+placeholders+" AND parent_id = ? ORDER BY `created_at`,`id` LIMIT 11").
+		WithArgs(5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "parent_id", "user_id", "body", "deleted_at"}).
+			AddRow(1, 5, 0, 2, "body", nil))
+
+	comments, err := NewCommentRepository(db).Get(5, CommentFilter{Parent: &top, Placeholders: true}, Page{
+		Sort:  []Sort{{Column: "created_at"}, {Column: "id"}},
+		Limit: 11,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+
+	mock.ExpectQuery("SELECT * FROM `comments` WHERE post_id = ? AND path LIKE ? AND `comments`.`deleted_at` IS NULL ORDER BY `created_at`,`id` LIMIT 11").
+		WithArgs(5, "1/4/%").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = NewCommentRepository(db).Get(5, CommentFilter{Thread: "1/4/"}, Page{
+		Sort:  []Sort{{Column: "created_at"}, {Column: "id"}},
+		Limit: 11,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_GetReplies(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery("SELECT * FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY parent_id ORDER BY created_at, id) AS reply_number "+
+		"FROM `comments` WHERE (post_id = ? AND parent_id IN (?,?)) AND "+placeholders+") AS replies "+
+		"WHERE reply_number <= ? ORDER BY parent_id, created_at, id").
+		WithArgs(5, 1, 2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "parent_id", "body", "depth", "path", "reply_number"}).
+			AddRow(3, 5, 1, "reply", 1, "1/", 1).
+			AddRow(6, 5, 2, "reply", 1, "2/", 1))
+
+	replies, err := NewCommentRepository(db).GetReplies(5, []int{1, 2}, 4)
+	assert.NoError(t, err)
+	if assert.Len(t, replies, 2) {
+		assert.Equal(t, models.Comment{Id: 6, PostId: 5, ParentId: 2, Body: "reply", Depth: 1, Path: "2/"}, replies[1])
+	}
+
+	mock.ExpectQuery("SELECT parent_id, COUNT(*) AS replies FROM `comments` WHERE (post_id = ? AND parent_id IN (?,?)) AND "+placeholders+" GROUP BY `parent_id`").
+		WithArgs(5, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"parent_id", "replies"}).AddRow(1, 7))
+
+	counts, err := NewCommentRepository(db).CountReplies(5, []int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]int{1: 7}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_Purge(t *testing.T) {
+	db, mock := newMockDB(t)
+	before := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT `id` FROM `comments` WHERE deleted_at < ? AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = comments.id)").
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(8))
+	for _, table := range []string{"reactions", "reaction_counts"} {
+		mock.ExpectExec("DELETE FROM `"+table+"` WHERE target_type = ? AND target_id IN (SELECT id FROM `comments` WHERE id IN (?,?))").
+			WithArgs(models.ReactionTargetComment, 3, 8).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("DELETE FROM `comments` WHERE id IN (?,?)").
+		WithArgs(3, 8).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	purged, err := NewCommentRepository(db).Purge(before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
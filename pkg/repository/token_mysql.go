@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type TokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (t *TokenRepository) CreateRefreshToken(token models.RefreshToken) error {
+	return t.db.Table(RefreshTokensTable).Create(&token).Error
+}
+
+func (t *TokenRepository) GetRefreshToken(tokenHash string) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := t.db.Table(RefreshTokensTable).Where("token_hash = ?", tokenHash).First(&token).Error
+	return token, err
+}
+
+// UseRefreshToken marks the token as used. It reports false when the token
+// was already used or revoked, so concurrent refreshes can't both succeed.
+func (t *TokenRepository) UseRefreshToken(id int) (bool, error) {
+	res := t.db.Table(RefreshTokensTable).
+		Where("id = ? and used_at is null and revoked_at is null", id).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// RevokeFamily revokes the refresh tokens of the family and the session with the same id.
+func (t *TokenRepository) RevokeFamily(familyId string) error {
+	now := time.Now()
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(RefreshTokensTable).
+			Where("family_id = ? and revoked_at is null", familyId).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Table(SessionsTable).
+			Where("id = ? and revoked_at is null", familyId).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeUserFamilies revokes every refresh token and session of the user.
+func (t *TokenRepository) RevokeUserFamilies(userId int) error {
+	now := time.Now()
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(RefreshTokensTable).
+			Where("user_id = ? and revoked_at is null", userId).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Table(SessionsTable).
+			Where("user_id = ? and revoked_at is null", userId).
+			Update("revoked_at", now).Error
+	})
+}
+
+func (t *TokenRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	token := models.RevokedToken{Jti: jti, ExpiresAt: expiresAt}
+	return t.db.Table(RevokedTokensTable).Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+func (t *TokenRepository) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := t.db.Table(RevokedTokensTable).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestPostRepository_DeleteAndRestore(t *testing.T) {
+	db, mock := newMockDB(t)
+	posts := NewPostRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `posts` SET `deleted_at`=?,`deleted_by`=? WHERE id = ? AND deleted_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), 3, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, posts.Delete(4, 3))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `posts` SET `deleted_at`=?,`deleted_by`=? WHERE id = ? AND deleted_at IS NOT NULL").
+		WithArgs(nil, 0, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, posts.Restore(4))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `posts` SET `deleted_at`=?,`deleted_by`=? WHERE id = ? AND deleted_at IS NOT NULL").
+		WithArgs(nil, 0, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	assert.ErrorIs(t, posts.Restore(5), gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostRepository_Purge(t *testing.T) {
+	db, mock := newMockDB(t)
+	before := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	for _, table := range []string{"reactions", "reaction_counts"} {
+		mock.ExpectExec("DELETE FROM `"+table+"` WHERE target_type = ? AND target_id IN "+
+			"(SELECT id FROM `comments` WHERE post_id IN (SELECT id FROM `posts` WHERE deleted_at < ?))").
+			WithArgs(models.ReactionTargetComment, before).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	for _, table := range []string{"reactions", "reaction_counts"} {
+		mock.ExpectExec("DELETE FROM `"+table+"` WHERE target_type = ? AND target_id IN (SELECT id FROM `posts` WHERE deleted_at < ?)").
+			WithArgs(models.ReactionTargetPost, before).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec("DELETE FROM `comments` WHERE post_id IN (SELECT id FROM `posts` WHERE deleted_at < ?)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 6))
+	mock.ExpectExec("DELETE FROM `post_tags` WHERE post_id IN (SELECT id FROM `posts` WHERE deleted_at < ?)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `post_revisions` WHERE post_id IN (SELECT id FROM `posts` WHERE deleted_at < ?)").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM `posts` WHERE deleted_at < ?").
+		WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	purged, err := NewPostRepository(db).Purge(before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCommentRepository_GetDeleted(t *testing.T) {
+	db, mock := newMockDB(t)
+	deletedAt := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT * FROM `comments` WHERE deleted_at IS NOT NULL AND "+
+		"(`deleted_at` < ? OR (`deleted_at` = ? AND `id` < ?)) ORDER BY `deleted_at` DESC,`id` DESC LIMIT 21").
+		WithArgs(deletedAt, deletedAt, 9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "user_id", "body", "deleted_at"}).
+			AddRow(3, 1, 2, "body", deletedAt))
+
+	comments, err := NewCommentRepository(db).GetDeleted(Page{
+		Sort:  []Sort{{Column: "deleted_at", Desc: true}, {Column: "id", Desc: true}},
+		After: []interface{}{deletedAt, 9},
+		Limit: 21,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+	assert.True(t, comments[0].DeletedAt.Valid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
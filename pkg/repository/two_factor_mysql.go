@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type TwoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+func (t *TwoFactorRepository) GetTwoFactor(userId int) (models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	err := t.db.Table(TwoFactorsTable).Where("user_id = ?", userId).First(&twoFactor).Error
+	return twoFactor, err
+}
+
+// SaveTwoFactor creates the record of the user or replaces the existing one.
+func (t *TwoFactorRepository) SaveTwoFactor(twoFactor models.TwoFactor) error {
+	return t.db.Table(TwoFactorsTable).Save(&twoFactor).Error
+}
+
+func (t *TwoFactorRepository) EnableTwoFactor(userId int) error {
+	return t.db.Table(TwoFactorsTable).Where("user_id = ?", userId).
+		Updates(map[string]interface{}{"enabled": true, "confirmed_at": time.Now()}).Error
+}
+
+// DeleteTwoFactor removes the secret and the recovery codes of the user.
+func (t *TwoFactorRepository) DeleteTwoFactor(userId int) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(RecoveryCodesTable).Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Table(TwoFactorsTable).Where("user_id = ?", userId).Delete(&models.TwoFactor{}).Error
+	})
+}
+
+// UseTotpStep records the time step of an accepted code. It reports false
+// when the step or a later one was already used.
+func (t *TwoFactorRepository) UseTotpStep(userId int, step int64) (bool, error) {
+	res := t.db.Table(TwoFactorsTable).
+		Where("user_id = ? and last_step < ?", userId, step).
+		Update("last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ReplaceRecoveryCodes drops the old recovery codes of the user and stores the new ones.
+func (t *TwoFactorRepository) ReplaceRecoveryCodes(userId int, codeHashes []string) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(RecoveryCodesTable).Where("user_id = ?", userId).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, models.RecoveryCode{UserId: userId, CodeHash: hash})
+		}
+		return tx.Table(RecoveryCodesTable).Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks the code as used and reports false if it is unknown or already used.
+func (t *TwoFactorRepository) UseRecoveryCode(userId int, codeHash string) (bool, error) {
+	res := t.db.Table(RecoveryCodesTable).
+		Where("user_id = ? and code_hash = ? and used_at is null", userId, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (u *UserRepository) GetById(id int) (models.User, error) {
+	var user models.User
+	err := u.db.Table(UsersTable).First(&user, id).Error
+	return user, err
+}
+
+// UpdateProfile writes only the given columns of the user.
+func (u *UserRepository) UpdateProfile(id int, user models.User, fields ...string) error {
+	res := u.db.Table(UsersTable).Select(fields).Where("id = ?", id).Updates(&user)
+	if res.Error == nil && res.RowsAffected == 0 {
+		// MySQL reports 0 rows when nothing changed, so check that the user exists
+		return u.db.Table(UsersTable).Select("id").First(&models.User{}, id).Error
+	}
+	return res.Error
+}
+
+// DeleteUser deletes the user with the credentials and sessions. Posts and comments
+// of the user are either deleted together with the comments under those posts,
+// or kept with user_id set to 0 when anonymize is true. Deleted content skips the trash,
+// except for the comments that other users replied to. The deleted posts and the comments
+// of the user on other posts, deleted or blanked, are returned.
+func (u *UserRepository) DeleteUser(id int, anonymize bool) (DeletedContent, error) {
+	var deleted DeletedContent
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		// reactions are personal and are not kept even for anonymized content
+		if err := deleteUserReactions(tx, id); err != nil {
+			return err
+		}
+		if anonymize {
+			if err := tx.Table(PostsTable).Where("user_id = ?", id).Update("user_id", 0).Error; err != nil {
+				return err
+			}
+			if err := tx.Table(CommentsTable).Where("user_id = ?", id).Update("user_id", 0).Error; err != nil {
+				return err
+			}
+		} else {
+			userPosts := func() *gorm.DB {
+				return tx.Table(PostsTable).Select("id").Where("user_id = ?", id)
+			}
+			if err := tx.Table(PostsTable).Where("user_id = ?", id).Pluck("id", &deleted.Posts).Error; err != nil {
+				return err
+			}
+			err := tx.Table(CommentsTable).Where("user_id = ? AND post_id NOT IN (?)", id, userPosts()).
+				Pluck("id", &deleted.Comments).Error
+			if err != nil {
+				return err
+			}
+			comments := func() *gorm.DB {
+				return tx.Table(CommentsTable).Select("id").Where("user_id = ? or post_id in (?)", id, userPosts())
+			}
+			if err := deleteReactions(tx, models.ReactionTargetComment, comments); err != nil {
+				return err
+			}
+			if err := deleteReactions(tx, models.ReactionTargetPost, userPosts); err != nil {
+				return err
+			}
+			// comments with replies of others stay in their threads as deleted placeholders
+			var kept []int
+			err = tx.Table(CommentsTable+" c").Where("c.user_id = ? AND c.post_id NOT IN (?) AND EXISTS "+
+				"(SELECT 1 FROM "+CommentsTable+" r WHERE r.path LIKE CONCAT(c.path, c.id, '/%') AND r.user_id <> ?)",
+				id, userPosts(), id).Pluck("c.id", &kept).Error
+			if err != nil {
+				return err
+			}
+			if len(kept) > 0 {
+				err = tx.Table(CommentsTable).Where("id IN ?", kept).
+					Updates(map[string]interface{}{"user_id": 0, "body": "", "deleted_at": time.Now()}).Error
+				if err != nil {
+					return err
+				}
+			}
+			posts := tx.Table(PostsTable).Select("id").Where("user_id = ?", id)
+			err = tx.Unscoped().Table(CommentsTable).Where("user_id = ? or post_id in (?)", id, posts).
+				Delete(&models.Comment{}).Error
+			if err != nil {
+				return err
+			}
+			posts = tx.Table(PostsTable).Select("id").Where("user_id = ?", id)
+			err = tx.Table(PostRevisionsTable).Where("post_id in (?)", posts).Delete(&models.PostRevision{}).Error
+			if err != nil {
+				return err
+			}
+			posts = tx.Table(PostsTable).Select("id").Where("user_id = ?", id)
+			if err = tx.Table(PostTagsTable).Where("post_id in (?)", posts).Delete(&models.PostTag{}).Error; err != nil {
+				return err
+			}
+			if err = tx.Unscoped().Table(PostsTable).Where("user_id = ?", id).Delete(&models.Post{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// the revisions the user wrote stay in the history of the posts without the author
+		if err := tx.Table(PostRevisionsTable).Where("user_id = ?", id).Update("user_id", 0).Error; err != nil {
+			return err
+		}
+
+		for _, table := range []string{RefreshTokensTable, SessionsTable, ExternalIdentitiesTable,
+			UserTokensTable, ApiKeysTable, TwoFactorsTable, RecoveryCodesTable} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", id).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Table(UsersTable).Where("id = ?", id).Delete(&models.User{})
+		if res.Error == nil && res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return res.Error
+	})
+	if err != nil {
+		return DeletedContent{}, err
+	}
+	return deleted, nil
+}
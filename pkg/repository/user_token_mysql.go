@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+	"time"
+)
+
+type UserTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (u *UserTokenRepository) CreateUserToken(token models.UserToken) error {
+	return u.db.Table(UserTokensTable).Create(&token).Error
+}
+
+func (u *UserTokenRepository) GetUserToken(purpose, tokenHash string) (models.UserToken, error) {
+	var token models.UserToken
+	err := u.db.Table(UserTokensTable).Where("purpose = ? and token_hash = ?", purpose, tokenHash).First(&token).Error
+	return token, err
+}
+
+// UseUserToken marks the token as used and reports false if it already was.
+func (u *UserTokenRepository) UseUserToken(id int) (bool, error) {
+	res := u.db.Table(UserTokensTable).Where("id = ? and used_at is null", id).Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// InvalidateUserTokens marks the unused tokens of the user with the purpose as used.
+func (u *UserTokenRepository) InvalidateUserTokens(userId int, purpose string) error {
+	return u.db.Table(UserTokensTable).
+		Where("user_id = ? and purpose = ? and used_at is null", userId, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"os"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"time"
+)
+
+const (
+	purposeVerifyEmail   = "verify_email"
+	purposeResetPassword = "reset_password"
+
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = time.Hour
+
+	minimumPasswordLength = 6
+)
+
+var (
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+	ErrNoEmail          = errors.New("user has no email")
+	ErrEmailVerified    = errors.New("email is already verified")
+	ErrEmailNotVerified = errors.New("email is not verified")
+	ErrEmailTaken       = errors.New("email is already used by another account")
+	ErrPasswordTooShort = errors.New("password must be at least 6 symbols")
+)
+
+type AccountService struct {
+	users           repository.Authorization
+	userTokens      repository.UserToken
+	tokens          repository.Token
+	apiKeys         repository.ApiKey
+	hasher          PasswordHasher
+	mailer          Mailer
+	appUrl          string
+	requireVerified bool
+}
+
+// NewAccountService reads the base URL of links in emails from appUrl and
+// whether unverified users may publish content from requireVerifiedEmail.
+func NewAccountService(users repository.Authorization, userTokens repository.UserToken, tokens repository.Token,
+	apiKeys repository.ApiKey, mailer Mailer) *AccountService {
+	return &AccountService{
+		users:           users,
+		userTokens:      userTokens,
+		tokens:          tokens,
+		apiKeys:         apiKeys,
+		hasher:          NewPasswordHasher(),
+		mailer:          mailer,
+		appUrl:          os.Getenv("appUrl"),
+		requireVerified: os.Getenv("requireVerifiedEmail") == "true",
+	}
+}
+
+func (a *AccountService) CheckEmail(email string) error {
+	_, err := a.users.GetUserByEmail(email)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (a *AccountService) SendVerification(userId int) error {
+	user, err := a.users.GetUserById(userId)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return ErrNoEmail
+	}
+	if user.EmailVerified {
+		return ErrEmailVerified
+	}
+	token, err := a.createToken(user.Id, purposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	return a.mailer.Send(Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello %s,\n\nconfirm your email by opening this link:\n%s/auth/verify-email?token=%s\n\n"+
+			"The link expires in 24 hours.", user.Name, a.appUrl, token),
+	})
+}
+
+func (a *AccountService) VerifyEmail(token string) error {
+	userToken, err := a.useToken(purposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+	return a.users.SetEmailVerified(userToken.UserId)
+}
+
+// ForgotPassword mails a reset link if an account uses the email.
+// Unknown emails are not reported, so the endpoint can't be used to find accounts.
+func (a *AccountService) ForgotPassword(email string) error {
+	user, err := a.users.GetUserByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = a.userTokens.InvalidateUserTokens(user.Id, purposeResetPassword); err != nil {
+		return err
+	}
+	token, err := a.createToken(user.Id, purposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+	return a.mailer.Send(Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nset a new password by opening this link:\n%s/reset-password?token=%s\n\n"+
+			"The link expires in one hour. Ignore this email if you did not ask for it.", user.Name, a.appUrl, token),
+	})
+}
+
+// ResetPassword sets the new password, signs the user out everywhere and revokes the API keys.
+// The reset link proves the user controls the email, so it is marked as verified.
+func (a *AccountService) ResetPassword(token, password string) error {
+	if len(password) < minimumPasswordLength {
+		return ErrPasswordTooShort
+	}
+	userToken, err := a.useToken(purposeResetPassword, token)
+	if err != nil {
+		return err
+	}
+	hash, err := a.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if err = a.users.UpdatePasswordHash(userToken.UserId, hash); err != nil {
+		return err
+	}
+	if err = a.users.SetEmailVerified(userToken.UserId); err != nil {
+		return err
+	}
+	if err = a.tokens.RevokeUserFamilies(userToken.UserId); err != nil {
+		return err
+	}
+	// the reset recovers the account, the keys somebody else may have created stop working too
+	return a.apiKeys.RevokeUserApiKeys(userToken.UserId)
+}
+
+// CheckVerified returns ErrEmailNotVerified if unverified users may not publish
+// and the principal's email is not verified.
+func (a *AccountService) CheckVerified(principal Principal) error {
+	if !a.requireVerified {
+		return nil
+	}
+	user, err := a.users.GetUserById(principal.Id)
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (a *AccountService) createToken(userId int, purpose string, ttl time.Duration) (string, error) {
+	token := randomToken(32)
+	err := a.userTokens.CreateUserToken(models.UserToken{
+		UserId:    userId,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return token, err
+}
+
+func (a *AccountService) useToken(purpose, token string) (models.UserToken, error) {
+	userToken, err := a.userTokens.GetUserToken(purpose, hashToken(token))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return userToken, ErrInvalidUserToken
+	}
+	if err != nil {
+		return userToken, err
+	}
+	if time.Now().After(userToken.ExpiresAt) {
+		return userToken, ErrInvalidUserToken
+	}
+	ok, err := a.userTokens.UseUserToken(userToken.Id)
+	if err != nil {
+		return userToken, err
+	}
+	if !ok {
+		return userToken, ErrInvalidUserToken
+	}
+	return userToken, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	type mockBehavior func(users *mockRepository.MockAuthorization, userTokens *mockRepository.MockUserToken,
+		tokens *mockRepository.MockToken, apiKeys *mockRepository.MockApiKey)
+
+	stored := models.UserToken{Id: 1, UserId: 3, Purpose: purposeResetPassword, TokenHash: hashToken("reset"),
+		ExpiresAt: time.Now().Add(time.Hour)}
+
+	testTable := []struct {
+		name         string
+		password     string
+		mockBehavior mockBehavior
+		expectedErr  error
+	}{
+		{
+			name:     "Ok",
+			password: "new password",
+			mockBehavior: func(users *mockRepository.MockAuthorization, userTokens *mockRepository.MockUserToken,
+				tokens *mockRepository.MockToken, apiKeys *mockRepository.MockApiKey) {
+				userTokens.EXPECT().GetUserToken(purposeResetPassword, hashToken("reset")).Return(stored, nil)
+				userTokens.EXPECT().UseUserToken(1).Return(true, nil)
+				users.EXPECT().UpdatePasswordHash(3, gomock.Any()).Return(nil)
+				users.EXPECT().SetEmailVerified(3).Return(nil)
+				tokens.EXPECT().RevokeUserFamilies(3).Return(nil)
+				apiKeys.EXPECT().RevokeUserApiKeys(3).Return(nil)
+			},
+		},
+		{
+			name:     "Revoking keys fails",
+			password: "new password",
+			mockBehavior: func(users *mockRepository.MockAuthorization, userTokens *mockRepository.MockUserToken,
+				tokens *mockRepository.MockToken, apiKeys *mockRepository.MockApiKey) {
+				userTokens.EXPECT().GetUserToken(purposeResetPassword, hashToken("reset")).Return(stored, nil)
+				userTokens.EXPECT().UseUserToken(1).Return(true, nil)
+				users.EXPECT().UpdatePasswordHash(3, gomock.Any()).Return(nil)
+				users.EXPECT().SetEmailVerified(3).Return(nil)
+				tokens.EXPECT().RevokeUserFamilies(3).Return(nil)
+				apiKeys.EXPECT().RevokeUserApiKeys(3).Return(errors.New("something went wrong"))
+			},
+			expectedErr: errors.New("something went wrong"),
+		},
+		{
+			name:     "Used token",
+			password: "new password",
+			mockBehavior: func(users *mockRepository.MockAuthorization, userTokens *mockRepository.MockUserToken,
+				tokens *mockRepository.MockToken, apiKeys *mockRepository.MockApiKey) {
+				userTokens.EXPECT().GetUserToken(purposeResetPassword, hashToken("reset")).Return(stored, nil)
+				userTokens.EXPECT().UseUserToken(1).Return(false, nil)
+			},
+			expectedErr: ErrInvalidUserToken,
+		},
+		{
+			name:     "Short password",
+			password: "short",
+			mockBehavior: func(users *mockRepository.MockAuthorization, userTokens *mockRepository.MockUserToken,
+				tokens *mockRepository.MockToken, apiKeys *mockRepository.MockApiKey) {
+			},
+			expectedErr: ErrPasswordTooShort,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mockRepository.NewMockAuthorization(c)
+			userTokens := mockRepository.NewMockUserToken(c)
+			tokens := mockRepository.NewMockToken(c)
+			apiKeys := mockRepository.NewMockApiKey(c)
+			testCase.mockBehavior(users, userTokens, tokens, apiKeys)
+			s := NewAccountService(users, userTokens, tokens, apiKeys, nil)
+
+			err := s.ResetPassword("reset", testCase.password)
+			if testCase.expectedErr != nil {
+				assert.EqualError(t, err, testCase.expectedErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
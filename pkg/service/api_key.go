@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"time"
+)
+
+// ApiKeyPrefix starts every API key, so they can be told apart from JWTs.
+const ApiKeyPrefix = "pat_"
+
+// apiKeyTouchInterval limits how often the last use of a key is written.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	ErrInvalidApiKey    = errors.New("invalid api key")
+	ErrApiKeyNotFound   = errors.New("api key not found")
+	ErrApiKeyName       = errors.New("api key name must be 1 to 100 symbols")
+	ErrInvalidScope     = errors.New("invalid scope")
+	ErrInvalidExpiresAt = errors.New("expiration time must be in the future")
+)
+
+type ApiKeyService struct {
+	keys  repository.ApiKey
+	users repository.Authorization
+	now   func() time.Time
+}
+
+func NewApiKeyService(keys repository.ApiKey, users repository.Authorization) *ApiKeyService {
+	return &ApiKeyService{keys: keys, users: users, now: time.Now}
+}
+
+// CreateApiKey creates a key of the user. The returned key is shown only once,
+// the repository keeps its hash.
+func (a *ApiKeyService) CreateApiKey(userId int, name string, scopes []string, expiresAt *time.Time) (string, models.ApiKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", models.ApiKey{}, ErrApiKeyName
+	}
+	if len(scopes) == 0 {
+		return "", models.ApiKey{}, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !isKeyScope(scope) {
+			return "", models.ApiKey{}, ErrInvalidScope
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(a.now()) {
+		return "", models.ApiKey{}, ErrInvalidExpiresAt
+	}
+
+	key := ApiKeyPrefix + randomToken(32)
+	apiKey := models.ApiKey{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    key[:len(ApiKeyPrefix)+6],
+		KeyHash:   hashToken(key),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+		CreatedAt: a.now(),
+	}
+	id, err := a.keys.CreateApiKey(apiKey)
+	if err != nil {
+		return "", models.ApiKey{}, err
+	}
+	apiKey.Id = id
+	return key, apiKey, nil
+}
+
+func (a *ApiKeyService) GetApiKeys(userId int) ([]models.ApiKey, error) {
+	return a.keys.GetApiKeys(userId)
+}
+
+func (a *ApiKeyService) RevokeApiKey(userId, id int) error {
+	ok, err := a.keys.RevokeApiKey(userId, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateApiKey returns the principal of a valid key. It has the roles
+// of the key owner limited by the scopes of the key.
+func (a *ApiKeyService) AuthenticateApiKey(key string) (Principal, error) {
+	apiKey, err := a.keys.GetApiKeyByHash(hashToken(key))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Principal{}, ErrInvalidApiKey
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	now := a.now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt)) {
+		return Principal{}, ErrInvalidApiKey
+	}
+	user, err := a.users.GetUserById(apiKey.UserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Principal{}, ErrInvalidApiKey
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err = a.keys.TouchApiKey(apiKey.Id, now); err != nil {
+			return Principal{}, err
+		}
+	}
+	role := user.Role
+	if role == "" {
+		role = RoleUser
+	}
+	return Principal{Id: user.Id, Roles: []string{role}, Scopes: strings.Split(apiKey.Scopes, ",")}, nil
+}
+
+func isKeyScope(scope string) bool {
+	for _, s := range keyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"strings"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestApiKeyService_CreateApiKey(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	keys := mockRepository.NewMockApiKey(c)
+	var stored models.ApiKey
+	keys.EXPECT().CreateApiKey(gomock.Any()).DoAndReturn(func(key models.ApiKey) (int, error) {
+		stored = key
+		return 7, nil
+	})
+	s := NewApiKeyService(keys, mockRepository.NewMockAuthorization(c))
+
+	key, apiKey, err := s.CreateApiKey(3, " ci ", []string{ScopePostsWrite}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, ApiKeyPrefix))
+	assert.Equal(t, 7, apiKey.Id)
+	assert.Equal(t, "ci", stored.Name)
+	assert.Equal(t, hashToken(key), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, key)
+	assert.True(t, strings.HasPrefix(key, stored.Prefix))
+
+	_, _, err = s.CreateApiKey(3, "ci", []string{ScopeAll}, nil)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, _, err = s.CreateApiKey(3, "", []string{ScopePostsWrite}, nil)
+	assert.ErrorIs(t, err, ErrApiKeyName)
+	past := time.Now().Add(-time.Hour)
+	_, _, err = s.CreateApiKey(3, "ci", []string{ScopePostsWrite}, &past)
+	assert.ErrorIs(t, err, ErrInvalidExpiresAt)
+}
+
+func TestApiKeyService_AuthenticateApiKey(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	recently := now.Add(-time.Second)
+	expired := now.Add(-time.Hour)
+
+	testTable := []struct {
+		name          string
+		key           models.ApiKey
+		findErr       error
+		touch         bool
+		expected      Principal
+		expectedError error
+	}{
+		{
+			name:     "ok",
+			key:      models.ApiKey{Id: 1, UserId: 3, Scopes: "posts:write,comments:write"},
+			touch:    true,
+			expected: Principal{Id: 3, Roles: []string{RoleModerator}, Scopes: []string{ScopePostsWrite, ScopeCommentsWrite}},
+		},
+		{
+			name:     "Recently used",
+			key:      models.ApiKey{Id: 1, UserId: 3, Scopes: "posts:write", LastUsedAt: &recently},
+			expected: Principal{Id: 3, Roles: []string{RoleModerator}, Scopes: []string{ScopePostsWrite}},
+		},
+		{
+			name:          "Unknown key",
+			findErr:       gorm.ErrRecordNotFound,
+			expectedError: ErrInvalidApiKey,
+		},
+		{
+			name:          "Expired key",
+			key:           models.ApiKey{Id: 1, UserId: 3, Scopes: "posts:write", ExpiresAt: &expired},
+			expectedError: ErrInvalidApiKey,
+		},
+		{
+			name:          "Revoked key",
+			key:           models.ApiKey{Id: 1, UserId: 3, Scopes: "posts:write", RevokedAt: &expired},
+			expectedError: ErrInvalidApiKey,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			keys := mockRepository.NewMockApiKey(c)
+			users := mockRepository.NewMockAuthorization(c)
+			keys.EXPECT().GetApiKeyByHash(hashToken("pat_key")).Return(testCase.key, testCase.findErr)
+			if testCase.expectedError == nil {
+				users.EXPECT().GetUserById(3).Return(models.User{Id: 3, Role: RoleModerator}, nil)
+			}
+			if testCase.touch {
+				keys.EXPECT().TouchApiKey(1, now).Return(nil)
+			}
+			s := NewApiKeyService(keys, users)
+			s.now = func() time.Time { return now }
+
+			principal, err := s.AuthenticateApiKey("pat_key")
+			assert.ErrorIs(t, err, testCase.expectedError)
+			assert.Equal(t, testCase.expected, principal)
+		})
+	}
+}
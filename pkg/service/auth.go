@@ -1,31 +1,109 @@
 package service
 
 import (
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+	"log"
 	"os"
+	"sync"
 	"test/pkg/repository"
 	"test/pkg/repository/models"
 	"time"
 )
 
 const (
-	tokenTTL = 48 * time.Hour
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+
+	defaultIssuer   = "server"
+	defaultAudience = "api"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrUserNotFound        = errors.New("user not found")
 )
 
 type AuthService struct {
 	repository repository.Authorization
+	tokens     repository.Token
+	identities repository.Identity
+	twoFactor  repository.TwoFactor
+	sessions   repository.Session
+	keys       *SigningKeys
+	issuer     string
+	audience   string
+	hasher     PasswordHasher
+	throttle   *LoginThrottle
+
+	dummyOnce sync.Once
+	dummy     string
 }
 
-type tokenClaims struct {
+// ClientInfo describes the client that signs in.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// TokenClaims are the claims of an access token. StandardClaims.Id is the jti,
+// SessionId is the session the token was issued to.
+type TokenClaims struct {
 	jwt.StandardClaims
-	UserId int `json:"user_id"`
+	UserId    int      `json:"user_id"`
+	Roles     []string `json:"roles"`
+	SessionId string   `json:"sid,omitempty"`
 }
 
-func NewAuthService(repository repository.Authorization) *AuthService {
-	return &AuthService{repository: repository}
+// Principal returns the caller identified by the access token.
+// Access tokens are not limited by scopes.
+func (c *TokenClaims) Principal() Principal {
+	return Principal{Id: c.UserId, Roles: c.Roles, Scopes: []string{ScopeAll}}
+}
+
+// Tokens is the result of a successful sign-in or refresh. When the user has
+// two-factor authentication enabled, sign-in gives only a ChallengeToken
+// to be exchanged with VerifyTwoFactor.
+type Tokens struct {
+	AccessToken    string
+	RefreshToken   string
+	ChallengeToken string
+	ExpiresIn      int64
+}
+
+// NewAuthService reads the iss and aud claims of access tokens from jwtIssuer and jwtAudience.
+func NewAuthService(repository repository.Authorization, tokens repository.Token, identities repository.Identity,
+	twoFactor repository.TwoFactor, sessions repository.Session, keys *SigningKeys, throttle *LoginThrottle) *AuthService {
+	issuer := os.Getenv("jwtIssuer")
+	if issuer == "" {
+		issuer = defaultIssuer
+	}
+	audience := os.Getenv("jwtAudience")
+	if audience == "" {
+		audience = defaultAudience
+	}
+	return &AuthService{
+		repository: repository,
+		tokens:     tokens,
+		identities: identities,
+		twoFactor:  twoFactor,
+		sessions:   sessions,
+		keys:       keys,
+		issuer:     issuer,
+		audience:   audience,
+		hasher:     NewPasswordHasher(),
+		throttle:   throttle,
+	}
 }
 
 func (a *AuthService) Testing(name string) (string, error) {
@@ -33,7 +111,11 @@ func (a *AuthService) Testing(name string) (string, error) {
 }
 
 func (a *AuthService) CreateUser(user models.User) (int, error) {
-	user.Password = CreatePasswordHash(user.Password)
+	hash, err := a.hasher.Hash(user.Password)
+	if err != nil {
+		return 0, err
+	}
+	user.Password = hash
 	return a.repository.CreateUser(user)
 }
 
@@ -41,41 +123,287 @@ func (a *AuthService) CheckUser(username string) error {
 	return a.repository.CheckUser(username)
 }
 
-func (a *AuthService) GenerateToken(username, password string) (string, error) {
-	user, err := a.repository.GetUser(username, CreatePasswordHash(password))
+// GenerateToken signs the user in. Failed attempts are throttled per account and
+// per client IP; unknown users and wrong passwords both give ErrInvalidCredentials.
+func (a *AuthService) GenerateToken(username, password string, client ClientInfo) (Tokens, error) {
+	if err := a.throttle.Check(username, client.IP); err != nil {
+		return Tokens{}, err
+	}
+	user, err := a.checkPassword(username, password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		// the attempt stays recorded as failed
+		return Tokens{}, err
+	}
+	var tokens Tokens
+	if err == nil {
+		tokens, err = a.signIn(user, client)
+	}
+	if err != nil || tokens.ChallengeToken != "" {
+		// failures are forgotten only after the second factor
+		if errRelease := a.throttle.Release(username, client.IP); errRelease != nil && err == nil {
+			return Tokens{}, errRelease
+		}
+		return tokens, err
+	}
+	if err = a.throttle.Succeed(username, client.IP); err != nil {
+		return Tokens{}, err
+	}
+	return tokens, nil
+}
+
+// UnlockUser clears the failed sign-in attempts of the user.
+func (a *AuthService) UnlockUser(userId int) error {
+	user, err := a.repository.GetUserById(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
 	if err != nil {
-		return "", err
+		return err
 	}
+	return a.throttle.Unlock(user.Username)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. Every refresh token
+// can be used once; presenting a used one revokes its whole family.
+func (a *AuthService) RefreshToken(refreshToken string) (Tokens, error) {
+	token, err := a.tokens.GetRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+	if token.RevokedAt != nil || token.UsedAt != nil {
+		if errRevoke := a.tokens.RevokeFamily(token.FamilyId); errRevoke != nil {
+			return Tokens{}, errRevoke
+		}
+		return Tokens{}, ErrRefreshTokenReused
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+	ok, err := a.tokens.UseRefreshToken(token.Id)
+	if err != nil {
+		return Tokens{}, err
+	}
+	if !ok {
+		if errRevoke := a.tokens.RevokeFamily(token.FamilyId); errRevoke != nil {
+			return Tokens{}, errRevoke
+		}
+		return Tokens{}, ErrRefreshTokenReused
+	}
+	user, err := a.repository.GetUserById(token.UserId)
+	if err != nil {
+		return Tokens{}, ErrInvalidRefreshToken
+	}
+	if err = a.sessions.TouchSession(token.FamilyId, time.Now()); err != nil {
+		return Tokens{}, err
+	}
+	return a.issueTokens(user, token.FamilyId)
+}
+
+// Logout revokes the access token and its session and, when given,
+// the family of the refresh token.
+func (a *AuthService) Logout(claims *TokenClaims, refreshToken string) error {
+	if claims.SessionId != "" {
+		if err := a.tokens.RevokeFamily(claims.SessionId); err != nil {
+			return err
+		}
+	}
+	if refreshToken != "" {
+		token, err := a.tokens.GetRefreshToken(hashToken(refreshToken))
+		if err == nil && token.UserId == claims.UserId {
+			if errRevoke := a.tokens.RevokeFamily(token.FamilyId); errRevoke != nil {
+				return errRevoke
+			}
+		}
+	}
+	return a.tokens.RevokeToken(claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+func (a *AuthService) UpdateRole(userId int, role string) error {
+	if !IsValidRole(role) {
+		return ErrInvalidRole
+	}
+	err := a.repository.UpdateRole(userId, role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
+// JWKS returns the public keys that verify access tokens.
+func (a *AuthService) JWKS() (JWKSet, error) {
+	return a.keys.JWKS()
+}
+
+// RotateSigningKey starts signing with a new key. Tokens signed with
+// the previous keys stay valid until they expire.
+func (a *AuthService) RotateSigningKey() error {
+	return a.keys.Rotate()
+}
+
+// IsTokenRevoked reports whether the access token or its session is revoked.
+// It also records that the session was seen.
+func (a *AuthService) IsTokenRevoked(claims *TokenClaims) (bool, error) {
+	revoked, err := a.tokens.IsRevoked(claims.Id)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	if claims.SessionId == "" {
+		return true, nil
+	}
+	session, err := a.sessions.GetSession(claims.SessionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if session.RevokedAt != nil || session.UserId != claims.UserId {
+		return true, nil
+	}
+	return false, a.sessions.TouchSession(session.Id, time.Now())
+}
+
+// ParseToken parses an access token. Challenge tokens of the two-factor
+// sign-in have another audience and are rejected.
+func (a *AuthService) ParseToken(accessToken string) (*TokenClaims, error) {
+	return a.parseClaims(accessToken, a.audience)
+}
+
+// parseClaims verifies the signature, expiration, issuer and audience of a token.
+func (a *AuthService) parseClaims(accessToken, audience string) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &TokenClaims{}, a.keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*TokenClaims)
+	if !ok {
+		return nil, errors.New("token claims are not of type *TokenClaims")
+	}
+	if !claims.VerifyIssuer(a.issuer, true) {
+		return nil, errors.New("invalid token issuer")
+	}
+	if !claims.VerifyAudience(audience, true) {
+		return nil, errors.New("invalid token audience")
+	}
+	return claims, nil
+}
+
+// startSession records a new session of the client and issues its first tokens.
+func (a *AuthService) startSession(user models.User, client ClientInfo) (Tokens, error) {
+	now := time.Now()
+	session := models.Session{
+		Id:         randomToken(16),
+		UserId:     user.Id,
+		UserAgent:  truncate(client.UserAgent, 255),
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := a.sessions.CreateSession(session); err != nil {
+		return Tokens{}, err
+	}
+	return a.issueTokens(user, session.Id)
+}
+
+func (a *AuthService) issueTokens(user models.User, familyId string) (Tokens, error) {
+	role := user.Role
+	if role == "" {
+		role = RoleUser
+	}
+	now := time.Now()
+	accessToken, err := a.keys.Sign(&TokenClaims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
-			IssuedAt:  time.Now().Unix(),
+			Id:        randomToken(16),
+			Issuer:    a.issuer,
+			Audience:  a.audience,
+			ExpiresAt: now.Add(accessTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
 		},
-		UserId: user.Id,
+		UserId:    user.Id,
+		Roles:     []string{role},
+		SessionId: familyId,
 	})
+	if err != nil {
+		return Tokens{}, err
+	}
 
-	return token.SignedString([]byte(os.Getenv("signInKey")))
+	refreshToken := randomToken(32)
+	err = a.tokens.CreateRefreshToken(models.RefreshToken{
+		UserId:    user.Id,
+		FamilyId:  familyId,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, nil
 }
 
-func (a *AuthService) ParseToken(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
+// checkPassword looks the user up by username and verifies the password.
+// Unknown users are checked against a dummy hash, so both cases take the same time.
+// Hashes made with an outdated algorithm are upgraded after a successful check.
+func (a *AuthService) checkPassword(username, password string) (models.User, error) {
+	user, err := a.repository.GetUser(username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		_, _ = a.hasher.Verify(a.dummyHash(), password)
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	ok, err := a.hasher.Verify(user.Password, password)
+	if err != nil || !ok {
+		return models.User{}, ErrInvalidCredentials
+	}
+	if a.hasher.NeedsRehash(user.Password) {
+		hash, errHash := a.hasher.Hash(password)
+		if errHash == nil {
+			errHash = a.repository.UpdatePasswordHash(user.Id, hash)
 		}
-		return []byte(os.Getenv("signInKey")), nil
+		if errHash != nil {
+			log.Printf("password rehash for user %d failed: %s", user.Id, errHash.Error())
+		}
+	}
+	return user, nil
+}
+
+func (a *AuthService) dummyHash() string {
+	a.dummyOnce.Do(func() {
+		a.dummy, _ = a.hasher.Hash(randomToken(16))
 	})
-	if err != nil {
-		return 0, err
+	return a.dummy
+}
+
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
 	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
 
-	claims, ok := token.Claims.(*tokenClaims)
-	if !ok {
-		return 0, errors.New("token claims are not of type *tokenClaims")
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
-	return claims.UserId, nil
+	return s[:n]
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
+// CreatePasswordHash is the legacy SHA-1 scheme. It is only used
+// to verify hashes created before PasswordHasher was introduced.
 func CreatePasswordHash(password string) string {
 	hash := sha1.New()
 	hash.Write([]byte(password))
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	type mockBehavior func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+		sessions *mockRepository.MockSession)
+
+	used := time.Now().Add(-time.Minute)
+	stored := models.RefreshToken{Id: 1, UserId: 3, FamilyId: "family", TokenHash: hashToken("refresh"),
+		ExpiresAt: time.Now().Add(time.Hour)}
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		expectedErr  error
+	}{
+		{
+			name: "Ok",
+			mockBehavior: func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+				sessions *mockRepository.MockSession) {
+				tokens.EXPECT().GetRefreshToken(hashToken("refresh")).Return(stored, nil)
+				tokens.EXPECT().UseRefreshToken(1).Return(true, nil)
+				users.EXPECT().GetUserById(3).Return(models.User{Id: 3}, nil)
+				sessions.EXPECT().TouchSession("family", gomock.Any()).Return(nil)
+				tokens.EXPECT().CreateRefreshToken(gomock.Any()).DoAndReturn(func(token models.RefreshToken) error {
+					assert.Equal(t, 3, token.UserId)
+					assert.Equal(t, "family", token.FamilyId, "the new token stays in the family")
+					assert.NotEqual(t, stored.TokenHash, token.TokenHash)
+					return nil
+				})
+			},
+		},
+		{
+			name: "Reused",
+			mockBehavior: func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+				sessions *mockRepository.MockSession) {
+				reused := stored
+				reused.UsedAt = &used
+				tokens.EXPECT().GetRefreshToken(hashToken("refresh")).Return(reused, nil)
+				tokens.EXPECT().RevokeFamily("family").Return(nil)
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "Used at the same time",
+			mockBehavior: func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+				sessions *mockRepository.MockSession) {
+				tokens.EXPECT().GetRefreshToken(hashToken("refresh")).Return(stored, nil)
+				tokens.EXPECT().UseRefreshToken(1).Return(false, nil)
+				tokens.EXPECT().RevokeFamily("family").Return(nil)
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "Revoked",
+			mockBehavior: func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+				sessions *mockRepository.MockSession) {
+				revoked := stored
+				revoked.RevokedAt = &used
+				tokens.EXPECT().GetRefreshToken(hashToken("refresh")).Return(revoked, nil)
+				tokens.EXPECT().RevokeFamily("family").Return(nil)
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+		{
+			name: "Expired",
+			mockBehavior: func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+				sessions *mockRepository.MockSession) {
+				expired := stored
+				expired.ExpiresAt = used
+				tokens.EXPECT().GetRefreshToken(hashToken("refresh")).Return(expired, nil)
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "Unknown",
+			mockBehavior: func(tokens *mockRepository.MockToken, users *mockRepository.MockAuthorization,
+				sessions *mockRepository.MockSession) {
+				tokens.EXPECT().GetRefreshToken(hashToken("refresh")).Return(models.RefreshToken{}, gorm.ErrRecordNotFound)
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tokens := mockRepository.NewMockToken(c)
+			users := mockRepository.NewMockAuthorization(c)
+			sessions := mockRepository.NewMockSession(c)
+			testCase.mockBehavior(tokens, users, sessions)
+			s := NewAuthService(users, tokens, mockRepository.NewMockIdentity(c), mockRepository.NewMockTwoFactor(c), sessions,
+				newTestSigningKeys(c), NewLoginThrottle(NewMemoryAttemptStore(time.Hour)))
+
+			issued, err := s.RefreshToken("refresh")
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, issued.AccessToken)
+			assert.NotEmpty(t, issued.RefreshToken)
+			assert.NotEqual(t, "refresh", issued.RefreshToken)
+		})
+	}
+}
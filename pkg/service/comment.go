@@ -1,30 +1,295 @@
 package service
 
 import (
+	"errors"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
 	"test/pkg/repository"
 	"test/pkg/repository/models"
 )
 
+const (
+	defaultCommentMaxDepth = 5
+	// maxCommentDepth keeps the paths of the deepest replies within their column.
+	maxCommentDepth   = 20
+	defaultReplyDepth = 2
+	defaultReplyLimit = 3
+	defaultReplySort  = "created_at"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrParentNotFound  = errors.New("parent comment not found")
+	ErrReplyTooDeep    = errors.New("replies are nested too deep")
+)
+
+// ThreadRequest selects the comments under Parent, or all the comments of the post when it is 0.
+// A flat list has the comments at any depth. A tree lists the direct replies to Parent, each with
+// its replies nested up to Depth levels deep, at most Replies of them under a comment; the others
+// are loaded with the replies_cursor of that comment.
+type ThreadRequest struct {
+	Parent  int
+	Tree    bool
+	Depth   int
+	Replies int
+}
+
 type CommentService struct {
 	repository repository.Comment
+	posts      repository.Post
+	index      repository.SearchIndex
+	list       listing[models.Comment]
+	maxDepth   int
 }
 
-func NewCommentService(repository repository.Comment) *CommentService {
-	return &CommentService{repository: repository}
+// NewCommentService reads from commentMaxDepth how deep replies may be nested.
+func NewCommentService(repository repository.Comment, posts repository.Post, index repository.SearchIndex) *CommentService {
+	maxDepth := defaultCommentMaxDepth
+	if depth, err := strconv.Atoi(os.Getenv("commentMaxDepth")); err == nil && depth > 0 {
+		maxDepth = depth
+	}
+	if maxDepth > maxCommentDepth {
+		maxDepth = maxCommentDepth
+	}
+	return &CommentService{repository: repository, posts: posts, index: index, list: newCommentListing(), maxDepth: maxDepth}
 }
 
+// newCommentListing allows sorting comments by these fields, oldest first by default.
+func newCommentListing() listing[models.Comment] {
+	return listing[models.Comment]{
+		fields: map[string]func(models.Comment) interface{}{
+			"id":         func(comment models.Comment) interface{} { return comment.Id },
+			"created_at": func(comment models.Comment) interface{} { return comment.CreatedAt },
+		},
+		defaultSort: "created_at",
+		limits:      newPageLimits(),
+	}
+}
+
+// Create adds a comment to a post the author may see or a reply to the comment ParentId.
 func (p *CommentService) Create(comment models.Comment) (int, error) {
-	return p.repository.Create(comment)
+	post, err := visiblePost(p.posts, comment.UserId, comment.PostId)
+	if err != nil {
+		return 0, err
+	}
+	comment.Depth, comment.Path = 0, ""
+	if comment.ParentId != 0 {
+		parent, err := p.repository.GetById(comment.PostId, comment.ParentId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrParentNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+		if parent.Depth >= p.maxDepth {
+			return 0, ErrReplyTooDeep
+		}
+		comment.Depth = parent.Depth + 1
+		comment.Path = parent.Path + strconv.Itoa(parent.Id) + "/"
+	}
+	id, err := p.repository.Create(comment)
+	if err != nil || post.Status != models.PostStatusPublished {
+		return id, err
+	}
+	return id, indexComment(p.index, comment.PostId, id, comment)
+}
+
+// Get returns a page of the comments of a post the viewer may see that match the filter and the cursor
+// of the next page. The filter and the sort apply to the listed comments, not to the replies nested under them in a tree.
+// Deleted comments that still have replies are kept as placeholders, unless the filter narrows the list.
+func (p *CommentService) Get(viewerId, postId int, thread ThreadRequest, filter CommentFilter, request PageRequest) ([]models.Comment, string, error) {
+	if _, err := visiblePost(p.posts, viewerId, postId); err != nil {
+		return nil, "", err
+	}
+	page, key, err := p.list.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	filter.Placeholders = filter.UserId == 0 && filter.CreatedFrom == nil && filter.CreatedTo == nil && filter.Body == ""
+	if thread.Parent != 0 {
+		parent, err := p.getThreadComment(postId, thread.Parent)
+		if err != nil {
+			return nil, "", err
+		}
+		filter.Thread = parent.Path + strconv.Itoa(parent.Id) + "/"
+	}
+	if thread.Tree {
+		filter.Parent, filter.Thread = &thread.Parent, ""
+	}
+	comments, err := p.repository.Get(postId, filter, page)
+	if err != nil {
+		return nil, "", err
+	}
+	comments, next := p.list.cut(comments, page, key)
+	if thread.Tree {
+		if comments, err = p.loadReplies(postId, comments, thread); err != nil {
+			return nil, "", err
+		}
+	}
+	hideDeleted(comments)
+	return comments, next, nil
+}
+
+// loadReplies nests the replies under the comments level by level and counts the replies of each comment.
+func (p *CommentService) loadReplies(postId int, comments []models.Comment, thread ThreadRequest) ([]models.Comment, error) {
+	if len(comments) == 0 {
+		return comments, nil
+	}
+	depth := thread.Depth
+	if depth <= 0 {
+		depth = defaultReplyDepth
+	}
+	if depth > p.maxDepth {
+		depth = p.maxDepth
+	}
+	limit := thread.Replies
+	if limit <= 0 {
+		limit = defaultReplyLimit
+	}
+	page, key, err := p.list.page(PageRequest{Sort: defaultReplySort, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make(map[int][]models.Comment)
+	cursors := make(map[int]string)
+	ids := commentIds(comments)
+	level := ids
+	for i := 0; i < depth && len(level) > 0; i++ {
+		loaded, err := p.repository.GetReplies(postId, level, page.Limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, reply := range loaded {
+			replies[reply.ParentId] = append(replies[reply.ParentId], reply)
+		}
+		var next []int
+		for _, id := range level {
+			replies[id], cursors[id] = p.list.cut(replies[id], page, key)
+			next = append(next, commentIds(replies[id])...)
+		}
+		ids = append(ids, next...)
+		level = next
+	}
+	counts, err := p.repository.CountReplies(postId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var nest func(comment models.Comment) models.Comment
+	nest = func(comment models.Comment) models.Comment {
+		for _, reply := range replies[comment.Id] {
+			comment.Replies = append(comment.Replies, nest(reply))
+		}
+		comment.ReplyCount = counts[comment.Id]
+		comment.RepliesCursor = cursors[comment.Id]
+		return comment
+	}
+	for i := range comments {
+		comments[i] = nest(comments[i])
+	}
+	return comments, nil
+}
+
+// getThreadComment finds the comment replies are listed under, it may be a deleted placeholder.
+func (p *CommentService) getThreadComment(postId, id int) (models.Comment, error) {
+	comment, err := p.repository.GetById(postId, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		comment, err = p.repository.GetDeletedById(postId, id)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return comment, ErrCommentNotFound
+	}
+	return comment, err
+}
+
+func (p *CommentService) Update(actor Principal, postId, id int, comment models.Comment) error {
+	if _, err := p.authorize(actor, p.repository.GetById, postId, id, "update", PermCommentUpdateAny); err != nil {
+		return err
+	}
+	if err := p.repository.Update(postId, id, comment); err != nil {
+		return err
+	}
+	return p.indexPublished(postId, id, comment)
+}
+
+// Delete moves the comment to the trash.
+func (p *CommentService) Delete(actor Principal, postId, id int) error {
+	if _, err := p.authorize(actor, p.repository.GetById, postId, id, "delete", PermCommentDeleteAny); err != nil {
+		return err
+	}
+	if err := p.repository.Delete(postId, id, actor.Id); err != nil {
+		return err
+	}
+	return p.index.Remove(models.SearchKindComment, id)
+}
+
+// Restore moves the comment back from the trash. The author may restore only what they deleted.
+func (p *CommentService) Restore(actor Principal, postId, id int) error {
+	comment, err := p.authorize(actor, p.repository.GetDeletedById, postId, id, "restore", PermCommentRestoreAny)
+	if err != nil {
+		return err
+	}
+	// the author can't undo the removal by a moderator
+	if comment.DeletedBy != actor.Id && !actor.Can(PermCommentRestoreAny) {
+		return &ForbiddenError{Action: "restore", Resource: "comment", Id: id}
+	}
+	err = p.repository.Restore(postId, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return p.indexPublished(postId, id, comment)
+}
+
+// indexPublished indexes the comment while its post is published. The comments of a post
+// are indexed again when it is published or restored from the trash.
+func (p *CommentService) indexPublished(postId, id int, comment models.Comment) error {
+	post, err := p.posts.GetById(postId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil || post.Status != models.PostStatusPublished {
+		return err
+	}
+	return indexComment(p.index, postId, id, comment)
 }
 
-func (p *CommentService) Get(postId int) ([]models.Comment, error) {
-	return p.repository.Get(postId)
+// authorize allows the action to the author of the comment
+// and to principals that hold the permission for any comment.
+func (p *CommentService) authorize(actor Principal, get func(postId, id int) (models.Comment, error), postId, id int,
+	action, anyPermission string) (models.Comment, error) {
+	comment, err := get(postId, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return comment, ErrCommentNotFound
+	}
+	if err != nil {
+		return comment, err
+	}
+	if comment.UserId != actor.Id && !actor.Can(anyPermission) {
+		return comment, &ForbiddenError{Action: action, Resource: "comment", Id: id}
+	}
+	return comment, nil
 }
 
-func (p *CommentService) Update(postId, id int, comment models.Comment) error {
-	return p.repository.Update(postId, id, comment)
+// hideDeleted shows the deleted comments kept for their replies without their authors and bodies.
+func hideDeleted(comments []models.Comment) {
+	for i := range comments {
+		if comments[i].DeletedAt.Valid {
+			comments[i].UserId = 0
+			comments[i].Body = models.DeletedCommentBody
+		}
+		hideDeleted(comments[i].Replies)
+	}
 }
 
-func (p *CommentService) Delete(postId, id int) error {
-	return p.repository.Delete(postId, id)
+func commentIds(comments []models.Comment) []int {
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.Id
+	}
+	return ids
 }
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"test/pkg/repository/models"
+	"unicode"
+)
+
+// Formats of the post bodies and the comments in responses: Markdown as it is stored or sanitized HTML.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+const (
+	defaultRenderCacheSize = 1000
+	wordsPerMinute         = 200
+	// commentExtensions leave out tables, footnotes and heading ids, comments are short replies.
+	commentExtensions = blackfriday.NoIntraEmphasis | blackfriday.FencedCode | blackfriday.Autolink |
+		blackfriday.Strikethrough | blackfriday.HardLineBreak
+)
+
+type ContentService struct {
+	posts           *bluemonday.Policy
+	comments        *bluemonday.Policy
+	commentMarkdown bool
+	cache           *renderCache
+}
+
+// NewContentService reads from commentMarkdown whether comments are written in Markdown
+// and from renderCacheSize how many rendered texts are kept.
+func NewContentService() *ContentService {
+	posts := bluemonday.UGCPolicy()
+	posts.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+
+	comments := bluemonday.NewPolicy()
+	comments.AllowElements("p", "br", "em", "strong", "del", "code", "pre", "blockquote", "ul", "ol", "li")
+	comments.AllowStandardURLs()
+	comments.AllowAttrs("href").OnElements("a")
+	comments.RequireNoFollowOnLinks(true)
+
+	size := defaultRenderCacheSize
+	if value, err := strconv.Atoi(os.Getenv("renderCacheSize")); err == nil && value > 0 {
+		size = value
+	}
+	return &ContentService{
+		posts:           posts,
+		comments:        comments,
+		commentMarkdown: os.Getenv("commentMarkdown") == "true",
+		cache:           newRenderCache(size),
+	}
+}
+
+// PreparePosts counts the words of the post bodies and their reading time in minutes
+// and renders the bodies as HTML when the format is html.
+func (s *ContentService) PreparePosts(posts []models.Post, format string) {
+	for i := range posts {
+		posts[i].WordCount = countWords(posts[i].Body)
+		posts[i].ReadingTime = (posts[i].WordCount + wordsPerMinute - 1) / wordsPerMinute
+		if format == FormatHTML {
+			posts[i].Body = s.RenderPost(posts[i].Body)
+		}
+	}
+}
+
+// PrepareComments renders the comments and the replies nested under them as HTML when the format is html.
+// The placeholders of deleted comments are kept as they are.
+func (s *ContentService) PrepareComments(comments []models.Comment, format string) {
+	if format != FormatHTML {
+		return
+	}
+	for i := range comments {
+		if !comments[i].DeletedAt.Valid {
+			comments[i].Body = s.RenderComment(comments[i].Body)
+		}
+		s.PrepareComments(comments[i].Replies, format)
+	}
+}
+
+// RenderPost turns the Markdown of a post into sanitized HTML.
+func (s *ContentService) RenderPost(markdown string) string {
+	return s.render("post", markdown, func() string {
+		return string(s.posts.SanitizeBytes(blackfriday.Run([]byte(markdown))))
+	})
+}
+
+// RenderComment turns a comment into sanitized HTML. Comments are plain text
+// with kept line breaks unless commentMarkdown allows a restricted Markdown.
+func (s *ContentService) RenderComment(body string) string {
+	if !s.commentMarkdown {
+		return "<p>" + strings.ReplaceAll(html.EscapeString(body), "\n", "<br>\n") + "</p>\n"
+	}
+	return s.render("comment", body, func() string {
+		rendered := blackfriday.Run([]byte(body), blackfriday.WithExtensions(commentExtensions))
+		return string(s.comments.SanitizeBytes(rendered))
+	})
+}
+
+// render returns the cached HTML of the text if it was rendered before.
+func (s *ContentService) render(kind, text string, render func() string) string {
+	key := sha256.Sum256([]byte(kind + "\x00" + text))
+	if rendered, ok := s.cache.get(key); ok {
+		return rendered
+	}
+	rendered := render()
+	s.cache.put(key, rendered)
+	return rendered
+}
+
+var (
+	// markdownLink matches links and images, only their text is read.
+	markdownLink = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	// htmlTag matches the raw HTML tags that Markdown allows.
+	htmlTag = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// countWords counts the runs of letters and digits, so that Markdown syntax and HTML tags are not counted.
+func countWords(text string) int {
+	text = htmlTag.ReplaceAllString(markdownLink.ReplaceAllString(text, "$1"), " ")
+	return len(strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	}))
+}
+
+// renderCache keeps the most recently used rendered texts by the hash of their source.
+type renderCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type renderEntry struct {
+	key  [sha256.Size]byte
+	html string
+}
+
+func newRenderCache(size int) *renderCache {
+	return &renderCache{size: size, order: list.New(), entries: map[[sha256.Size]byte]*list.Element{}}
+}
+
+func (c *renderCache) get(key [sha256.Size]byte) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(renderEntry).html, true
+}
+
+func (c *renderCache) put(key [sha256.Size]byte, rendered string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(renderEntry{key: key, html: rendered})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(renderEntry).key)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"crypto/sha256"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestContentService_RenderPost(t *testing.T) {
+	s := NewContentService()
+
+	rendered := s.RenderPost("# Title\n\nSome *text* with [a link](https://example.com).\n\n<script>alert(1)</script>\n\n" +
+		"```go\nfmt.Println()\n```\n")
+	assert.Equal(t, "<h1>Title</h1>\n\n<p>Some <em>text</em> with <a href=\"https://example.com\" rel=\"nofollow\">a link</a>.</p>\n\n\n\n"+
+		"<pre><code class=\"language-go\">fmt.Println()\n</code></pre>\n", rendered)
+}
+
+func TestContentService_RenderComment(t *testing.T) {
+	t.Setenv("commentMarkdown", "true")
+	s := NewContentService()
+	assert.Equal(t, "Heading\n\n<p><strong>bold</strong>  b</p>\n", s.RenderComment("# Heading\n**bold** ![image](https://example.com/a.png) <b onclick=\"x()\">b</b>"))
+
+	t.Setenv("commentMarkdown", "")
+	s = NewContentService()
+	assert.Equal(t, "<p>**bold** &lt;b&gt;<br>\nline</p>\n", s.RenderComment("**bold** <b>\nline"))
+}
+
+func TestContentService_PreparePosts(t *testing.T) {
+	s := NewContentService()
+	posts := []models.Post{
+		{Id: 1, Body: "# Hello\n\nIt's a [short link](https://example.com/some/long/path) - and *more*."},
+		{Id: 2, Body: strings.Repeat("word ", 401)},
+		{Id: 3},
+	}
+
+	s.PreparePosts(posts, FormatHTML)
+	assert.Equal(t, 7, posts[0].WordCount)
+	assert.Equal(t, 1, posts[0].ReadingTime)
+	assert.Equal(t, "<h1>Hello</h1>\n\n<p>It’s a <a href=\"https://example.com/some/long/path\" rel=\"nofollow\">short link</a> - and <em>more</em>.</p>\n", posts[0].Body)
+	assert.Equal(t, 401, posts[1].WordCount)
+	assert.Equal(t, 3, posts[1].ReadingTime)
+	assert.Equal(t, 0, posts[2].ReadingTime)
+
+	markdown := []models.Post{{Body: "*text*"}}
+	s.PreparePosts(markdown, FormatMarkdown)
+	assert.Equal(t, "*text*", markdown[0].Body)
+}
+
+func TestRenderCache(t *testing.T) {
+	cache := newRenderCache(2)
+	a, b, c := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b")), sha256.Sum256([]byte("c"))
+
+	cache.put(a, "<p>a</p>")
+	cache.put(b, "<p>b</p>")
+	_, _ = cache.get(a)
+	cache.put(c, "<p>c</p>")
+
+	rendered, ok := cache.get(a)
+	assert.True(t, ok)
+	assert.Equal(t, "<p>a</p>", rendered)
+	_, ok = cache.get(b)
+	assert.False(t, ok, "the least recently used entry is dropped")
+	_, ok = cache.get(c)
+	assert.True(t, ok)
+}
@@ -0,0 +1,15 @@
+package service
+
+import "fmt"
+
+// ForbiddenError is returned when the acting user is not allowed
+// to perform an action on a resource.
+type ForbiddenError struct {
+	Action   string
+	Resource string
+	Id       int
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("user is not allowed to %s %s %d", e.Action, e.Resource, e.Id)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message Message) error
+}
+
+// NewMailer returns the mailer selected by the mailer environment variable:
+// "smtp" (smtpHost, smtpPort, smtpUsername, smtpPassword), "file" (mailDir)
+// or "log", the default.
+func NewMailer() Mailer {
+	from := os.Getenv("mailFrom")
+	switch os.Getenv("mailer") {
+	case "smtp":
+		return NewSMTPMailer(os.Getenv("smtpHost"), os.Getenv("smtpPort"),
+			os.Getenv("smtpUsername"), os.Getenv("smtpPassword"), from)
+	case "file":
+		return NewFileMailer(os.Getenv("mailDir"), from)
+	default:
+		return NewLogMailer(from)
+	}
+}
+
+type SMTPMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{addr: host + ":" + port, host: host, auth: auth, from: from}
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, formatMessage(m.from, message))
+}
+
+// FileMailer writes every message into its own .eml file. It is meant for development.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(message Message) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), randomToken(4))
+	return os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, message), 0o600)
+}
+
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(message Message) error {
+	log.Printf("mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
+
+func formatMessage(from string, message Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + message.To + "\r\n")
+	b.WriteString("Subject: " + message.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
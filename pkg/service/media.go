@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"unicode/utf8"
+)
+
+const (
+	defaultMediaMaxSize = 10 << 20
+	defaultMediaQuota   = 200 << 20
+	maxMediaNameLength  = 255
+	// sniffLength is what http.DetectContentType looks at
+	sniffLength = 512
+)
+
+var (
+	ErrMediaNotFound = errors.New("media not found")
+	ErrMediaType     = errors.New("file type is not allowed")
+	ErrMediaTooLarge = errors.New("file is too large")
+	ErrQuotaExceeded = errors.New("upload quota exceeded")
+)
+
+// mediaTypes are the sniffed content types that may be uploaded, by their kind.
+// HTML is left out, it could run scripts when served from this origin. SVG is not sniffed
+// as an image, it is stored as text and served as such.
+var mediaTypes = map[string]string{
+	"image/jpeg":      models.MediaKindImage,
+	"image/png":       models.MediaKindImage,
+	"image/gif":       models.MediaKindImage,
+	"image/webp":      models.MediaKindImage,
+	"application/pdf": models.MediaKindFile,
+	"application/zip": models.MediaKindFile,
+	"text/plain":      models.MediaKindFile,
+}
+
+// MediaUpload is an uploaded file. Content is read several times, to sniff, to check and to store it.
+type MediaUpload struct {
+	Name    string
+	Size    int64
+	Content io.ReadSeeker
+}
+
+// MediaContent is a stored media with its content. Public media
+// belong to published posts and may be kept by shared caches for a while.
+type MediaContent struct {
+	models.Media
+	Content io.ReadSeekCloser
+	Public  bool
+}
+
+type MediaService struct {
+	media   repository.Media
+	posts   repository.Post
+	blobs   repository.BlobStore
+	maxSize int64
+	quota   int64
+}
+
+// NewMediaService reads the largest upload from mediaMaxSize and the bytes
+// a user may upload in total from mediaQuota.
+func NewMediaService(media repository.Media, posts repository.Post, blobs repository.BlobStore) *MediaService {
+	return &MediaService{
+		media:   media,
+		posts:   posts,
+		blobs:   blobs,
+		maxSize: envBytes("mediaMaxSize", defaultMediaMaxSize),
+		quota:   envBytes("mediaQuota", defaultMediaQuota),
+	}
+}
+
+func envBytes(name string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// MaxUploadSize returns the size of the largest file that can be uploaded.
+func (m *MediaService) MaxUploadSize() int64 {
+	return m.maxSize
+}
+
+// Upload attaches a file to a post of the actor. The type is sniffed from the content,
+// the name the client sent is kept only to be shown and downloaded as.
+func (m *MediaService) Upload(ctx context.Context, actor Principal, postId int, upload MediaUpload) (models.Media, error) {
+	if _, err := authorizePost(actor, m.posts.GetById, postId, "update", PermPostUpdateAny); err != nil {
+		return models.Media{}, err
+	}
+	if upload.Size > m.maxSize {
+		return models.Media{}, ErrMediaTooLarge
+	}
+	media := models.Media{PostId: postId, UserId: actor.Id, Name: mediaName(upload.Name)}
+	if err := sniffMedia(upload.Content, &media); err != nil {
+		return models.Media{}, err
+	}
+
+	if _, err := upload.Content.Seek(0, io.SeekStart); err != nil {
+		return models.Media{}, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(upload.Content, m.maxSize+1))
+	if err != nil {
+		return models.Media{}, err
+	}
+	if size > m.maxSize {
+		return models.Media{}, ErrMediaTooLarge
+	}
+	media.Size = size
+	media.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	// the size is reserved before the content is stored and given back if storing fails
+	reserved, err := m.media.ReserveMediaUsage(actor.Id, size, m.quota)
+	if err != nil {
+		return models.Media{}, err
+	}
+	if !reserved {
+		return models.Media{}, ErrQuotaExceeded
+	}
+	media.Key = fmt.Sprintf("posts/%d/%s", postId, randomToken(16))
+	if media.Id, err = m.store(ctx, media, upload.Content); err != nil {
+		_ = m.media.ReleaseMediaUsage(actor.Id, size)
+		return models.Media{}, err
+	}
+	return withMediaUrl(media), nil
+}
+
+// store puts the content in the blob store and saves the record of the media.
+func (m *MediaService) store(ctx context.Context, media models.Media, content io.ReadSeeker) (int, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if err := m.blobs.Put(ctx, media.Key, content, media.Size, media.ContentType); err != nil {
+		return 0, err
+	}
+	id, err := m.media.CreateMedia(media)
+	if err != nil {
+		// the request may be cancelled already, the content is deleted anyway
+		_ = m.blobs.Delete(context.Background(), media.Key)
+	}
+	return id, err
+}
+
+// GetMedia returns the media of a post that is published or belongs to the viewer.
+func (m *MediaService) GetMedia(viewerId, postId int) ([]models.Media, error) {
+	if _, err := visiblePost(m.posts, viewerId, postId); err != nil {
+		return nil, err
+	}
+	media, err := m.media.GetMedia(postId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range media {
+		media[i] = withMediaUrl(media[i])
+	}
+	return media, nil
+}
+
+// OpenMedia opens the content of a media of a post the viewer may see. The caller closes it.
+func (m *MediaService) OpenMedia(ctx context.Context, viewerId, postId, id int) (MediaContent, error) {
+	post, err := visiblePost(m.posts, viewerId, postId)
+	if err != nil {
+		return MediaContent{}, err
+	}
+	media, err := m.getMedia(postId, id)
+	if err != nil {
+		return MediaContent{}, err
+	}
+	content, err := m.blobs.Open(ctx, media.Key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return MediaContent{}, ErrMediaNotFound
+	}
+	if err != nil {
+		return MediaContent{}, err
+	}
+	return MediaContent{Media: withMediaUrl(media), Content: content, Public: post.Status == models.PostStatusPublished}, nil
+}
+
+// DeleteMedia removes a media from a post of the actor together with its content.
+func (m *MediaService) DeleteMedia(ctx context.Context, actor Principal, postId, id int) error {
+	if _, err := authorizePost(actor, m.posts.GetById, postId, "update", PermPostUpdateAny); err != nil {
+		return err
+	}
+	media, err := m.getMedia(postId, id)
+	if err != nil {
+		return err
+	}
+	return deleteMedia(ctx, m.media, m.blobs, []models.Media{media})
+}
+
+func (m *MediaService) getMedia(postId, id int) (models.Media, error) {
+	media, err := m.media.GetMediaById(postId, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return media, ErrMediaNotFound
+	}
+	return media, err
+}
+
+// deleteMedia deletes the contents before the records, a failed attempt
+// leaves the records to be deleted by the next one.
+func deleteMedia(ctx context.Context, repo repository.Media, blobs repository.BlobStore, media []models.Media) error {
+	ids := make([]int, 0, len(media))
+	for _, item := range media {
+		if err := blobs.Delete(ctx, item.Key); err != nil {
+			return err
+		}
+		ids = append(ids, item.Id)
+	}
+	return repo.DeleteMedia(ids)
+}
+
+// sniffMedia finds the type of the content from its first bytes
+// and reads the dimensions of the images that can be decoded.
+func sniffMedia(content io.ReadSeeker, media *models.Media) error {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	media.ContentType = http.DetectContentType(head[:n])
+	baseType, _, _ := mime.ParseMediaType(media.ContentType)
+	kind, ok := mediaTypes[baseType]
+	if !ok || n == 0 {
+		return ErrMediaType
+	}
+	media.Kind = kind
+	if kind != models.MediaKindImage || baseType == "image/webp" {
+		return nil
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	config, _, err := image.DecodeConfig(content)
+	if err != nil {
+		return ErrMediaType
+	}
+	media.Width, media.Height = config.Width, config.Height
+	return nil
+}
+
+// mediaName keeps the base name of the uploaded file, cut to fit the column.
+func mediaName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" || name == "" {
+		return "file"
+	}
+	for len(name) > maxMediaNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+func withMediaUrl(media models.Media) models.Media {
+	media.Url = fmt.Sprintf("/api/posts/%d/media/%d", media.PostId, media.Id)
+	return media
+}
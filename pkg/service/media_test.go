@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"strings"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestMediaService_Upload(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	t.Setenv("mediaMaxSize", "1024")
+	t.Setenv("mediaQuota", "2048")
+	mediaRepository := mockRepository.NewMockMedia(c)
+	posts := mockRepository.NewMockPost(c)
+	blobs, err := repository.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	media := NewMediaService(mediaRepository, posts, blobs)
+	owner := Principal{Id: 3, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	post := models.Post{Id: 1, UserId: 3, Status: models.PostStatusDraft}
+	picture := testPNG(t, 4, 3)
+
+	posts.EXPECT().GetById(1).Return(post, nil)
+	mediaRepository.EXPECT().ReserveMediaUsage(3, int64(len(picture)), int64(2048)).Return(true, nil)
+	mediaRepository.EXPECT().CreateMedia(gomock.Any()).DoAndReturn(func(stored models.Media) (int, error) {
+		assert.Equal(t, "photo.png", stored.Name)
+		assert.Equal(t, "image/png", stored.ContentType)
+		assert.Equal(t, models.MediaKindImage, stored.Kind)
+		assert.Equal(t, int64(len(picture)), stored.Size)
+		assert.Equal(t, 4, stored.Width)
+		assert.Equal(t, 3, stored.Height)
+		assert.Len(t, stored.Checksum, 64)
+		assert.True(t, strings.HasPrefix(stored.Key, "posts/1/"))
+		return 7, nil
+	})
+	uploaded, err := media.Upload(context.Background(), owner, 1, MediaUpload{Name: `C:\photos\photo.png`, Size: int64(len(picture)),
+		Content: bytes.NewReader(picture)})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, uploaded.Id)
+	assert.Equal(t, "/api/posts/1/media/7", uploaded.Url)
+	stored, err := blobs.Open(context.Background(), uploaded.Key)
+	assert.NoError(t, err)
+	content, _ := io.ReadAll(stored)
+	assert.NoError(t, stored.Close())
+	assert.Equal(t, picture, content)
+
+	// the name and the extension don't matter, the content is sniffed
+	posts.EXPECT().GetById(1).Return(post, nil)
+	page := "<html><script>alert(1)</script></html>"
+	_, err = media.Upload(context.Background(), owner, 1, MediaUpload{Name: "photo.png", Size: int64(len(page)), Content: strings.NewReader(page)})
+	assert.ErrorIs(t, err, ErrMediaType)
+
+	posts.EXPECT().GetById(1).Return(post, nil)
+	broken := picture[:20]
+	_, err = media.Upload(context.Background(), owner, 1, MediaUpload{Name: "photo.png", Size: int64(len(broken)), Content: bytes.NewReader(broken)})
+	assert.ErrorIs(t, err, ErrMediaType, "an image that can't be decoded")
+
+	posts.EXPECT().GetById(1).Return(post, nil)
+	_, err = media.Upload(context.Background(), owner, 1, MediaUpload{Name: "big.txt", Size: 1025, Content: strings.NewReader(strings.Repeat("a", 1025))})
+	assert.ErrorIs(t, err, ErrMediaTooLarge)
+
+	// the size the client claims is not trusted
+	posts.EXPECT().GetById(1).Return(post, nil)
+	_, err = media.Upload(context.Background(), owner, 1, MediaUpload{Name: "big.txt", Size: 10, Content: strings.NewReader(strings.Repeat("a", 1025))})
+	assert.ErrorIs(t, err, ErrMediaTooLarge)
+
+	posts.EXPECT().GetById(1).Return(post, nil)
+	mediaRepository.EXPECT().ReserveMediaUsage(3, int64(100), int64(2048)).Return(false, nil)
+	_, err = media.Upload(context.Background(), owner, 1, MediaUpload{Name: "notes.txt", Size: 100, Content: strings.NewReader(strings.Repeat("a", 100))})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// a failed upload gives the reserved size back and leaves no content behind
+	posts.EXPECT().GetById(1).Return(post, nil)
+	mediaRepository.EXPECT().ReserveMediaUsage(3, int64(100), int64(2048)).Return(true, nil)
+	var key string
+	mediaRepository.EXPECT().CreateMedia(gomock.Any()).DoAndReturn(func(stored models.Media) (int, error) {
+		key = stored.Key
+		return 0, errors.New("something went wrong")
+	})
+	mediaRepository.EXPECT().ReleaseMediaUsage(3, int64(100)).Return(nil)
+	_, err = media.Upload(context.Background(), owner, 1, MediaUpload{Name: "notes.txt", Size: 100, Content: strings.NewReader(strings.Repeat("a", 100))})
+	assert.EqualError(t, err, "something went wrong")
+	_, err = blobs.Open(context.Background(), key)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	posts.EXPECT().GetById(1).Return(post, nil)
+	_, err = media.Upload(context.Background(), Principal{Id: 4, Roles: []string{RoleModerator}, Scopes: []string{ScopeAll}}, 1,
+		MediaUpload{Name: "photo.png", Size: int64(len(picture)), Content: bytes.NewReader(picture)})
+	var forbidden *ForbiddenError
+	assert.ErrorAs(t, err, &forbidden)
+}
+
+func TestMediaService_OpenMedia(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	mediaRepository := mockRepository.NewMockMedia(c)
+	posts := mockRepository.NewMockPost(c)
+	blobs, err := repository.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	media := NewMediaService(mediaRepository, posts, blobs)
+	stored := models.Media{Id: 7, PostId: 1, Key: "posts/1/a", Name: "notes.txt"}
+	assert.NoError(t, blobs.Put(context.Background(), stored.Key, strings.NewReader("notes"), 5, "text/plain"))
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusPublished}, nil)
+	mediaRepository.EXPECT().GetMediaById(1, 7).Return(stored, nil)
+	opened, err := media.OpenMedia(context.Background(), 0, 1, 7)
+	assert.NoError(t, err)
+	assert.True(t, opened.Public)
+	content, _ := io.ReadAll(opened.Content)
+	assert.NoError(t, opened.Content.Close())
+	assert.Equal(t, "notes", string(content))
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusDraft}, nil)
+	_, err = media.OpenMedia(context.Background(), 4, 1, 7)
+	assert.ErrorIs(t, err, ErrPostNotFound, "media of a draft are shown to the author only")
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, Status: models.PostStatusDraft}, nil)
+	_, err = media.OpenMedia(context.Background(), 0, 1, 7)
+	assert.ErrorIs(t, err, ErrPostNotFound, "a draft of a deleted account")
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusDraft}, nil)
+	mediaRepository.EXPECT().GetMediaById(1, 7).Return(stored, nil)
+	opened, err = media.OpenMedia(context.Background(), 3, 1, 7)
+	assert.NoError(t, err)
+	assert.False(t, opened.Public)
+	assert.NoError(t, opened.Content.Close())
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusPublished}, nil)
+	mediaRepository.EXPECT().GetMediaById(1, 8).Return(models.Media{}, gorm.ErrRecordNotFound)
+	_, err = media.OpenMedia(context.Background(), 0, 1, 8)
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusPublished}, nil)
+	mediaRepository.EXPECT().GetMediaById(1, 9).Return(models.Media{Id: 9, PostId: 1, Key: "posts/1/gone"}, nil)
+	_, err = media.OpenMedia(context.Background(), 0, 1, 9)
+	assert.ErrorIs(t, err, ErrMediaNotFound, "a record without its content")
+}
+
+func TestMediaService_DeleteMedia(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	mediaRepository := mockRepository.NewMockMedia(c)
+	posts := mockRepository.NewMockPost(c)
+	blobs, err := repository.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	media := NewMediaService(mediaRepository, posts, blobs)
+	owner := Principal{Id: 3, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	stored := models.Media{Id: 7, PostId: 1, Key: "posts/1/a"}
+	assert.NoError(t, blobs.Put(context.Background(), stored.Key, strings.NewReader("a"), 1, "text/plain"))
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3}, nil)
+	mediaRepository.EXPECT().GetMediaById(1, 7).Return(stored, nil)
+	mediaRepository.EXPECT().DeleteMedia([]int{7}).Return(nil)
+	assert.NoError(t, media.DeleteMedia(context.Background(), owner, 1, 7))
+	_, err = blobs.Open(context.Background(), stored.Key)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3}, nil)
+	mediaRepository.EXPECT().GetMediaById(1, 8).Return(models.Media{}, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, media.DeleteMedia(context.Background(), owner, 1, 8), ErrMediaNotFound)
+}
@@ -5,8 +5,11 @@
 package mock_service
 
 import (
+	context "context"
 	reflect "reflect"
 	models "test/pkg/repository/models"
+	service "test/pkg/service"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -48,6 +51,21 @@ func (mr *MockAuthorizationMockRecorder) CheckUser(username interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUser", reflect.TypeOf((*MockAuthorization)(nil).CheckUser), username)
 }
 
+// ConfirmTwoFactor mocks base method.
+func (m *MockAuthorization) ConfirmTwoFactor(userId int, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTwoFactor", userId, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTwoFactor indicates an expected call of ConfirmTwoFactor.
+func (mr *MockAuthorizationMockRecorder) ConfirmTwoFactor(userId, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTwoFactor", reflect.TypeOf((*MockAuthorization)(nil).ConfirmTwoFactor), userId, code)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthorization) CreateUser(user models.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -63,26 +81,114 @@ func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
 }
 
+// DisableTwoFactor mocks base method.
+func (m *MockAuthorization) DisableTwoFactor(userId int, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", userId, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockAuthorizationMockRecorder) DisableTwoFactor(userId, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockAuthorization)(nil).DisableTwoFactor), userId, code)
+}
+
+// EnrollTwoFactor mocks base method.
+func (m *MockAuthorization) EnrollTwoFactor(userId int) (service.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactor", userId)
+	ret0, _ := ret[0].(service.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTwoFactor indicates an expected call of EnrollTwoFactor.
+func (mr *MockAuthorizationMockRecorder) EnrollTwoFactor(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactor", reflect.TypeOf((*MockAuthorization)(nil).EnrollTwoFactor), userId)
+}
+
 // GenerateToken mocks base method.
-func (m *MockAuthorization) GenerateToken(username, password string) (string, error) {
+func (m *MockAuthorization) GenerateToken(username, password string, client service.ClientInfo) (service.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", username, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GenerateToken", username, password, client)
+	ret0, _ := ret[0].(service.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockAuthorizationMockRecorder) GenerateToken(username, password interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GenerateToken(username, password, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), username, password, client)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockAuthorization) IsTokenRevoked(claims *service.TokenClaims) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", claims)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockAuthorizationMockRecorder) IsTokenRevoked(claims interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockAuthorization)(nil).IsTokenRevoked), claims)
+}
+
+// JWKS mocks base method.
+func (m *MockAuthorization) JWKS() (service.JWKSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(service.JWKSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthorizationMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthorization)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockAuthorization) Logout(claims *service.TokenClaims, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", claims, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthorizationMockRecorder) Logout(claims, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthorization)(nil).Logout), claims, refreshToken)
+}
+
+// OAuthSignIn mocks base method.
+func (m *MockAuthorization) OAuthSignIn(user service.OAuthUser, client service.ClientInfo) (service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OAuthSignIn", user, client)
+	ret0, _ := ret[0].(service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OAuthSignIn indicates an expected call of OAuthSignIn.
+func (mr *MockAuthorizationMockRecorder) OAuthSignIn(user, client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OAuthSignIn", reflect.TypeOf((*MockAuthorization)(nil).OAuthSignIn), user, client)
 }
 
 // ParseToken mocks base method.
-func (m *MockAuthorization) ParseToken(token string) (int, error) {
+func (m *MockAuthorization) ParseToken(token string) (*service.TokenClaims, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ParseToken", token)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(*service.TokenClaims)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -93,6 +199,50 @@ func (mr *MockAuthorizationMockRecorder) ParseToken(token interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuthorization)(nil).ParseToken), token)
 }
 
+// RefreshToken mocks base method.
+func (m *MockAuthorization) RefreshToken(refreshToken string) (service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", refreshToken)
+	ret0, _ := ret[0].(service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockAuthorizationMockRecorder) RefreshToken(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockAuthorization)(nil).RefreshToken), refreshToken)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockAuthorization) RegenerateRecoveryCodes(userId int, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", userId, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockAuthorizationMockRecorder) RegenerateRecoveryCodes(userId, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockAuthorization)(nil).RegenerateRecoveryCodes), userId, code)
+}
+
+// RotateSigningKey mocks base method.
+func (m *MockAuthorization) RotateSigningKey() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningKey")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateSigningKey indicates an expected call of RotateSigningKey.
+func (mr *MockAuthorizationMockRecorder) RotateSigningKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningKey", reflect.TypeOf((*MockAuthorization)(nil).RotateSigningKey))
+}
+
 // Testing mocks base method.
 func (m *MockAuthorization) Testing(name string) (string, error) {
 	m.ctrl.T.Helper()
@@ -108,6 +258,49 @@ func (mr *MockAuthorizationMockRecorder) Testing(name interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Testing", reflect.TypeOf((*MockAuthorization)(nil).Testing), name)
 }
 
+// UnlockUser mocks base method.
+func (m *MockAuthorization) UnlockUser(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockAuthorizationMockRecorder) UnlockUser(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockAuthorization)(nil).UnlockUser), userId)
+}
+
+// UpdateRole mocks base method.
+func (m *MockAuthorization) UpdateRole(userId int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockAuthorizationMockRecorder) UpdateRole(userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockAuthorization)(nil).UpdateRole), userId, role)
+}
+
+// VerifyTwoFactor mocks base method.
+func (m *MockAuthorization) VerifyTwoFactor(challengeToken, code string, client service.ClientInfo) (service.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTwoFactor", challengeToken, code, client)
+	ret0, _ := ret[0].(service.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyTwoFactor indicates an expected call of VerifyTwoFactor.
+func (mr *MockAuthorizationMockRecorder) VerifyTwoFactor(challengeToken, code, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTwoFactor", reflect.TypeOf((*MockAuthorization)(nil).VerifyTwoFactor), challengeToken, code, client)
+}
+
 // MockPost is a mock of Post interface.
 type MockPost struct {
 	ctrl     *gomock.Controller
@@ -147,76 +340,165 @@ func (mr *MockPostMockRecorder) Create(post interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockPost) Delete(id int) error {
+func (m *MockPost) Delete(actor service.Principal, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", actor, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockPostMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockPostMockRecorder) Delete(actor, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPost)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPost)(nil).Delete), actor, id)
 }
 
-// Get mocks base method.
-func (m *MockPost) Get() ([]models.Post, error) {
+// DiffRevisions mocks base method.
+func (m *MockPost) DiffRevisions(actor service.Principal, id, from, to int) (service.RevisionDiff, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get")
-	ret0, _ := ret[0].([]models.Post)
+	ret := m.ctrl.Call(m, "DiffRevisions", actor, id, from, to)
+	ret0, _ := ret[0].(service.RevisionDiff)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// DiffRevisions indicates an expected call of DiffRevisions.
+func (mr *MockPostMockRecorder) DiffRevisions(actor, id, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffRevisions", reflect.TypeOf((*MockPost)(nil).DiffRevisions), actor, id, from, to)
+}
+
+// Get mocks base method.
+func (m *MockPost) Get(filter service.PostFilter, page service.PageRequest) ([]models.Post, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", filter, page)
+	ret0, _ := ret[0].([]models.Post)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // Get indicates an expected call of Get.
-func (mr *MockPostMockRecorder) Get() *gomock.Call {
+func (mr *MockPostMockRecorder) Get(filter, page interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPost)(nil).Get))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPost)(nil).Get), filter, page)
 }
 
 // GetById mocks base method.
-func (m *MockPost) GetById(id int) (models.Post, error) {
+func (m *MockPost) GetById(viewerId, id int) (models.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetById", id)
+	ret := m.ctrl.Call(m, "GetById", viewerId, id)
 	ret0, _ := ret[0].(models.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetById indicates an expected call of GetById.
-func (mr *MockPostMockRecorder) GetById(id interface{}) *gomock.Call {
+func (mr *MockPostMockRecorder) GetById(viewerId, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockPost)(nil).GetById), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockPost)(nil).GetById), viewerId, id)
 }
 
-// GetByUserId mocks base method.
-func (m *MockPost) GetByUserId(userId int) ([]models.Post, error) {
+// GetRevision mocks base method.
+func (m *MockPost) GetRevision(actor service.Principal, id, number int) (models.PostRevision, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByUserId", userId)
-	ret0, _ := ret[0].([]models.Post)
+	ret := m.ctrl.Call(m, "GetRevision", actor, id, number)
+	ret0, _ := ret[0].(models.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockPostMockRecorder) GetRevision(actor, id, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockPost)(nil).GetRevision), actor, id, number)
+}
+
+// GetRevisions mocks base method.
+func (m *MockPost) GetRevisions(actor service.Principal, id int, page service.PageRequest) ([]models.PostRevision, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", actor, id, page)
+	ret0, _ := ret[0].([]models.PostRevision)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockPostMockRecorder) GetRevisions(actor, id, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockPost)(nil).GetRevisions), actor, id, page)
+}
+
+// PublishDue mocks base method.
+func (m *MockPost) PublishDue(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDue", now)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByUserId indicates an expected call of GetByUserId.
-func (mr *MockPostMockRecorder) GetByUserId(userId interface{}) *gomock.Call {
+// PublishDue indicates an expected call of PublishDue.
+func (mr *MockPostMockRecorder) PublishDue(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDue", reflect.TypeOf((*MockPost)(nil).PublishDue), now)
+}
+
+// Restore mocks base method.
+func (m *MockPost) Restore(actor service.Principal, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", actor, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockPostMockRecorder) Restore(actor, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockPost)(nil).Restore), actor, id)
+}
+
+// Revert mocks base method.
+func (m *MockPost) Revert(actor service.Principal, id, number int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", actor, id, number)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockPostMockRecorder) Revert(actor, id, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockPost)(nil).Revert), actor, id, number)
+}
+
+// SetStatus mocks base method.
+func (m *MockPost) SetStatus(actor service.Principal, id int, status string, publishAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", actor, id, status, publishAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockPostMockRecorder) SetStatus(actor, id, status, publishAt interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserId", reflect.TypeOf((*MockPost)(nil).GetByUserId), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockPost)(nil).SetStatus), actor, id, status, publishAt)
 }
 
 // Update mocks base method.
-func (m *MockPost) Update(id int, post models.Post) error {
+func (m *MockPost) Update(actor service.Principal, id int, post models.Post) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", id, post)
+	ret := m.ctrl.Call(m, "Update", actor, id, post)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockPostMockRecorder) Update(id, post interface{}) *gomock.Call {
+func (mr *MockPostMockRecorder) Update(actor, id, post interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPost)(nil).Update), id, post)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPost)(nil).Update), actor, id, post)
 }
 
 // MockComment is a mock of Comment interface.
@@ -258,44 +540,845 @@ func (mr *MockCommentMockRecorder) Create(comment interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockComment) Delete(postId, id int) error {
+func (m *MockComment) Delete(actor service.Principal, postId, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", postId, id)
+	ret := m.ctrl.Call(m, "Delete", actor, postId, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockCommentMockRecorder) Delete(postId, id interface{}) *gomock.Call {
+func (mr *MockCommentMockRecorder) Delete(actor, postId, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockComment)(nil).Delete), postId, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockComment)(nil).Delete), actor, postId, id)
 }
 
 // Get mocks base method.
-func (m *MockComment) Get(postId int) ([]models.Comment, error) {
+func (m *MockComment) Get(viewerId, postId int, thread service.ThreadRequest, filter service.CommentFilter, page service.PageRequest) ([]models.Comment, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", postId)
+	ret := m.ctrl.Call(m, "Get", viewerId, postId, thread, filter, page)
 	ret0, _ := ret[0].([]models.Comment)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockCommentMockRecorder) Get(postId interface{}) *gomock.Call {
+func (mr *MockCommentMockRecorder) Get(viewerId, postId, thread, filter, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockComment)(nil).Get), viewerId, postId, thread, filter, page)
+}
+
+// Restore mocks base method.
+func (m *MockComment) Restore(actor service.Principal, postId, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", actor, postId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockCommentMockRecorder) Restore(actor, postId, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockComment)(nil).Get), postId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockComment)(nil).Restore), actor, postId, id)
 }
 
 // Update mocks base method.
-func (m *MockComment) Update(postId, id int, comment models.Comment) error {
+func (m *MockComment) Update(actor service.Principal, postId, id int, comment models.Comment) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", postId, id, comment)
+	ret := m.ctrl.Call(m, "Update", actor, postId, id, comment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockCommentMockRecorder) Update(postId, id, comment interface{}) *gomock.Call {
+func (mr *MockCommentMockRecorder) Update(actor, postId, id, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockComment)(nil).Update), actor, postId, id, comment)
+}
+
+// MockTag is a mock of Tag interface.
+type MockTag struct {
+	ctrl     *gomock.Controller
+	recorder *MockTagMockRecorder
+}
+
+// MockTagMockRecorder is the mock recorder for MockTag.
+type MockTagMockRecorder struct {
+	mock *MockTag
+}
+
+// NewMockTag creates a new mock instance.
+func NewMockTag(ctrl *gomock.Controller) *MockTag {
+	mock := &MockTag{ctrl: ctrl}
+	mock.recorder = &MockTagMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTag) EXPECT() *MockTagMockRecorder {
+	return m.recorder
+}
+
+// GetTag mocks base method.
+func (m *MockTag) GetTag(name string) (models.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTag", name)
+	ret0, _ := ret[0].(models.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTag indicates an expected call of GetTag.
+func (mr *MockTagMockRecorder) GetTag(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTag", reflect.TypeOf((*MockTag)(nil).GetTag), name)
+}
+
+// GetTags mocks base method.
+func (m *MockTag) GetTags(page service.PageRequest) ([]models.TagCount, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", page)
+	ret0, _ := ret[0].([]models.TagCount)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockTagMockRecorder) GetTags(page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockTag)(nil).GetTags), page)
+}
+
+// MergeTags mocks base method.
+func (m *MockTag) MergeTags(id, intoId int) (models.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeTags", id, intoId)
+	ret0, _ := ret[0].(models.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeTags indicates an expected call of MergeTags.
+func (mr *MockTagMockRecorder) MergeTags(id, intoId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeTags", reflect.TypeOf((*MockTag)(nil).MergeTags), id, intoId)
+}
+
+// RenameTag mocks base method.
+func (m *MockTag) RenameTag(id int, name string) (models.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", id, name)
+	ret0, _ := ret[0].(models.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockTagMockRecorder) RenameTag(id, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockTag)(nil).RenameTag), id, name)
+}
+
+// SuggestTags mocks base method.
+func (m *MockTag) SuggestTags(prefix string, limit int) ([]models.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestTags", prefix, limit)
+	ret0, _ := ret[0].([]models.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestTags indicates an expected call of SuggestTags.
+func (mr *MockTagMockRecorder) SuggestTags(prefix, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestTags", reflect.TypeOf((*MockTag)(nil).SuggestTags), prefix, limit)
+}
+
+// MockReaction is a mock of Reaction interface.
+type MockReaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockReactionMockRecorder
+}
+
+// MockReactionMockRecorder is the mock recorder for MockReaction.
+type MockReactionMockRecorder struct {
+	mock *MockReaction
+}
+
+// NewMockReaction creates a new mock instance.
+func NewMockReaction(ctrl *gomock.Controller) *MockReaction {
+	mock := &MockReaction{ctrl: ctrl}
+	mock.recorder = &MockReactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReaction) EXPECT() *MockReactionMockRecorder {
+	return m.recorder
+}
+
+// GetReactionKinds mocks base method.
+func (m *MockReaction) GetReactionKinds() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReactionKinds")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetReactionKinds indicates an expected call of GetReactionKinds.
+func (mr *MockReactionMockRecorder) GetReactionKinds() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReactionKinds", reflect.TypeOf((*MockReaction)(nil).GetReactionKinds))
+}
+
+// GetReactionSummaries mocks base method.
+func (m *MockReaction) GetReactionSummaries(viewerId int, targetType string, ids []int) (map[int]models.ReactionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReactionSummaries", viewerId, targetType, ids)
+	ret0, _ := ret[0].(map[int]models.ReactionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReactionSummaries indicates an expected call of GetReactionSummaries.
+func (mr *MockReactionMockRecorder) GetReactionSummaries(viewerId, targetType, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReactionSummaries", reflect.TypeOf((*MockReaction)(nil).GetReactionSummaries), viewerId, targetType, ids)
+}
+
+// GetReactions mocks base method.
+func (m *MockReaction) GetReactions(viewerId int, target service.ReactionTarget, kind string, page service.PageRequest) ([]models.Reaction, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReactions", viewerId, target, kind, page)
+	ret0, _ := ret[0].([]models.Reaction)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetReactions indicates an expected call of GetReactions.
+func (mr *MockReactionMockRecorder) GetReactions(viewerId, target, kind, page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReactions", reflect.TypeOf((*MockReaction)(nil).GetReactions), viewerId, target, kind, page)
+}
+
+// React mocks base method.
+func (m *MockReaction) React(viewerId int, target service.ReactionTarget, kind string) (models.ReactionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "React", viewerId, target, kind)
+	ret0, _ := ret[0].(models.ReactionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// React indicates an expected call of React.
+func (mr *MockReactionMockRecorder) React(viewerId, target, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "React", reflect.TypeOf((*MockReaction)(nil).React), viewerId, target, kind)
+}
+
+// Unreact mocks base method.
+func (m *MockReaction) Unreact(viewerId int, target service.ReactionTarget, kind string) (models.ReactionSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unreact", viewerId, target, kind)
+	ret0, _ := ret[0].(models.ReactionSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unreact indicates an expected call of Unreact.
+func (mr *MockReactionMockRecorder) Unreact(viewerId, target, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unreact", reflect.TypeOf((*MockReaction)(nil).Unreact), viewerId, target, kind)
+}
+
+// MockMedia is a mock of Media interface.
+type MockMedia struct {
+	ctrl     *gomock.Controller
+	recorder *MockMediaMockRecorder
+}
+
+// MockMediaMockRecorder is the mock recorder for MockMedia.
+type MockMediaMockRecorder struct {
+	mock *MockMedia
+}
+
+// NewMockMedia creates a new mock instance.
+func NewMockMedia(ctrl *gomock.Controller) *MockMedia {
+	mock := &MockMedia{ctrl: ctrl}
+	mock.recorder = &MockMediaMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMedia) EXPECT() *MockMediaMockRecorder {
+	return m.recorder
+}
+
+// DeleteMedia mocks base method.
+func (m *MockMedia) DeleteMedia(ctx context.Context, actor service.Principal, postId, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMedia", ctx, actor, postId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMedia indicates an expected call of DeleteMedia.
+func (mr *MockMediaMockRecorder) DeleteMedia(ctx, actor, postId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMedia", reflect.TypeOf((*MockMedia)(nil).DeleteMedia), ctx, actor, postId, id)
+}
+
+// GetMedia mocks base method.
+func (m *MockMedia) GetMedia(viewerId, postId int) ([]models.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMedia", viewerId, postId)
+	ret0, _ := ret[0].([]models.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMedia indicates an expected call of GetMedia.
+func (mr *MockMediaMockRecorder) GetMedia(viewerId, postId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedia", reflect.TypeOf((*MockMedia)(nil).GetMedia), viewerId, postId)
+}
+
+// MaxUploadSize mocks base method.
+func (m *MockMedia) MaxUploadSize() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxUploadSize")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// MaxUploadSize indicates an expected call of MaxUploadSize.
+func (mr *MockMediaMockRecorder) MaxUploadSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxUploadSize", reflect.TypeOf((*MockMedia)(nil).MaxUploadSize))
+}
+
+// OpenMedia mocks base method.
+func (m *MockMedia) OpenMedia(ctx context.Context, viewerId, postId, id int) (service.MediaContent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenMedia", ctx, viewerId, postId, id)
+	ret0, _ := ret[0].(service.MediaContent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenMedia indicates an expected call of OpenMedia.
+func (mr *MockMediaMockRecorder) OpenMedia(ctx, viewerId, postId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenMedia", reflect.TypeOf((*MockMedia)(nil).OpenMedia), ctx, viewerId, postId, id)
+}
+
+// Upload mocks base method.
+func (m *MockMedia) Upload(ctx context.Context, actor service.Principal, postId int, upload service.MediaUpload) (models.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", ctx, actor, postId, upload)
+	ret0, _ := ret[0].(models.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockMediaMockRecorder) Upload(ctx, actor, postId, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockMedia)(nil).Upload), ctx, actor, postId, upload)
+}
+
+// MockAccount is a mock of Account interface.
+type MockAccount struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountMockRecorder
+}
+
+// MockAccountMockRecorder is the mock recorder for MockAccount.
+type MockAccountMockRecorder struct {
+	mock *MockAccount
+}
+
+// NewMockAccount creates a new mock instance.
+func NewMockAccount(ctrl *gomock.Controller) *MockAccount {
+	mock := &MockAccount{ctrl: ctrl}
+	mock.recorder = &MockAccountMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccount) EXPECT() *MockAccountMockRecorder {
+	return m.recorder
+}
+
+// CheckEmail mocks base method.
+func (m *MockAccount) CheckEmail(email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckEmail", email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckEmail indicates an expected call of CheckEmail.
+func (mr *MockAccountMockRecorder) CheckEmail(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckEmail", reflect.TypeOf((*MockAccount)(nil).CheckEmail), email)
+}
+
+// CheckVerified mocks base method.
+func (m *MockAccount) CheckVerified(principal service.Principal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckVerified", principal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckVerified indicates an expected call of CheckVerified.
+func (mr *MockAccountMockRecorder) CheckVerified(principal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckVerified", reflect.TypeOf((*MockAccount)(nil).CheckVerified), principal)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockAccount) ForgotPassword(email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockAccountMockRecorder) ForgotPassword(email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockAccount)(nil).ForgotPassword), email)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAccount) ResetPassword(token, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", token, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAccountMockRecorder) ResetPassword(token, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAccount)(nil).ResetPassword), token, password)
+}
+
+// SendVerification mocks base method.
+func (m *MockAccount) SendVerification(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerification", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerification indicates an expected call of SendVerification.
+func (mr *MockAccountMockRecorder) SendVerification(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerification", reflect.TypeOf((*MockAccount)(nil).SendVerification), userId)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAccount) VerifyEmail(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAccountMockRecorder) VerifyEmail(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAccount)(nil).VerifyEmail), token)
+}
+
+// MockApiKey is a mock of ApiKey interface.
+type MockApiKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyMockRecorder
+}
+
+// MockApiKeyMockRecorder is the mock recorder for MockApiKey.
+type MockApiKeyMockRecorder struct {
+	mock *MockApiKey
+}
+
+// NewMockApiKey creates a new mock instance.
+func NewMockApiKey(ctrl *gomock.Controller) *MockApiKey {
+	mock := &MockApiKey{ctrl: ctrl}
+	mock.recorder = &MockApiKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKey) EXPECT() *MockApiKeyMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateApiKey mocks base method.
+func (m *MockApiKey) AuthenticateApiKey(key string) (service.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateApiKey", key)
+	ret0, _ := ret[0].(service.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateApiKey indicates an expected call of AuthenticateApiKey.
+func (mr *MockApiKeyMockRecorder) AuthenticateApiKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateApiKey", reflect.TypeOf((*MockApiKey)(nil).AuthenticateApiKey), key)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKey) CreateApiKey(userId int, name string, scopes []string, expiresAt *time.Time) (string, models.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", userId, name, scopes, expiresAt)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(models.ApiKey)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeyMockRecorder) CreateApiKey(userId, name, scopes, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKey)(nil).CreateApiKey), userId, name, scopes, expiresAt)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKey) GetApiKeys(userId int) ([]models.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", userId)
+	ret0, _ := ret[0].([]models.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeyMockRecorder) GetApiKeys(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKey)(nil).GetApiKeys), userId)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockApiKey) RevokeApiKey(userId, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockApiKeyMockRecorder) RevokeApiKey(userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockApiKey)(nil).RevokeApiKey), userId, id)
+}
+
+// MockSession is a mock of Session interface.
+type MockSession struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionMockRecorder
+}
+
+// MockSessionMockRecorder is the mock recorder for MockSession.
+type MockSessionMockRecorder struct {
+	mock *MockSession
+}
+
+// NewMockSession creates a new mock instance.
+func NewMockSession(ctrl *gomock.Controller) *MockSession {
+	mock := &MockSession{ctrl: ctrl}
+	mock.recorder = &MockSessionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSession) EXPECT() *MockSessionMockRecorder {
+	return m.recorder
+}
+
+// GetSessions mocks base method.
+func (m *MockSession) GetSessions(userId int) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", userId)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockSessionMockRecorder) GetSessions(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockSession)(nil).GetSessions), userId)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockSession) RevokeOtherSessions(userId int, currentSessionId string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", userId, currentSessionId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockSessionMockRecorder) RevokeOtherSessions(userId, currentSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockSession)(nil).RevokeOtherSessions), userId, currentSessionId)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSession) RevokeSession(userId int, sessionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionMockRecorder) RevokeSession(userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSession)(nil).RevokeSession), userId, sessionId)
+}
+
+// MockUser is a mock of User interface.
+type MockUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMockRecorder
+}
+
+// MockUserMockRecorder is the mock recorder for MockUser.
+type MockUserMockRecorder struct {
+	mock *MockUser
+}
+
+// NewMockUser creates a new mock instance.
+func NewMockUser(ctrl *gomock.Controller) *MockUser {
+	mock := &MockUser{ctrl: ctrl}
+	mock.recorder = &MockUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUser) EXPECT() *MockUserMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockUser) ChangePassword(id int, currentPassword, newPassword, currentSessionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", id, currentPassword, newPassword, currentSessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserMockRecorder) ChangePassword(id, currentPassword, newPassword, currentSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUser)(nil).ChangePassword), id, currentPassword, newPassword, currentSessionId)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockUser) DeleteAccount(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockUserMockRecorder) DeleteAccount(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockUser)(nil).DeleteAccount), id)
+}
+
+// GetUser mocks base method.
+func (m *MockUser) GetUser(id int) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", id)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserMockRecorder) GetUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUser)(nil).GetUser), id)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUser) UpdateProfile(id int, update service.ProfileUpdate) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", id, update)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserMockRecorder) UpdateProfile(id, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUser)(nil).UpdateProfile), id, update)
+}
+
+// MockSearch is a mock of Search interface.
+type MockSearch struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchMockRecorder
+}
+
+// MockSearchMockRecorder is the mock recorder for MockSearch.
+type MockSearchMockRecorder struct {
+	mock *MockSearch
+}
+
+// NewMockSearch creates a new mock instance.
+func NewMockSearch(ctrl *gomock.Controller) *MockSearch {
+	mock := &MockSearch{ctrl: ctrl}
+	mock.recorder = &MockSearchMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearch) EXPECT() *MockSearchMockRecorder {
+	return m.recorder
+}
+
+// Search mocks base method.
+func (m *MockSearch) Search(query string, limit int) ([]service.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", query, limit)
+	ret0, _ := ret[0].([]service.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockSearchMockRecorder) Search(query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockSearch)(nil).Search), query, limit)
+}
+
+// MockContent is a mock of Content interface.
+type MockContent struct {
+	ctrl     *gomock.Controller
+	recorder *MockContentMockRecorder
+}
+
+// MockContentMockRecorder is the mock recorder for MockContent.
+type MockContentMockRecorder struct {
+	mock *MockContent
+}
+
+// NewMockContent creates a new mock instance.
+func NewMockContent(ctrl *gomock.Controller) *MockContent {
+	mock := &MockContent{ctrl: ctrl}
+	mock.recorder = &MockContentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContent) EXPECT() *MockContentMockRecorder {
+	return m.recorder
+}
+
+// PrepareComments mocks base method.
+func (m *MockContent) PrepareComments(comments []models.Comment, format string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PrepareComments", comments, format)
+}
+
+// PrepareComments indicates an expected call of PrepareComments.
+func (mr *MockContentMockRecorder) PrepareComments(comments, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareComments", reflect.TypeOf((*MockContent)(nil).PrepareComments), comments, format)
+}
+
+// PreparePosts mocks base method.
+func (m *MockContent) PreparePosts(posts []models.Post, format string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PreparePosts", posts, format)
+}
+
+// PreparePosts indicates an expected call of PreparePosts.
+func (mr *MockContentMockRecorder) PreparePosts(posts, format interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreparePosts", reflect.TypeOf((*MockContent)(nil).PreparePosts), posts, format)
+}
+
+// MockTrash is a mock of Trash interface.
+type MockTrash struct {
+	ctrl     *gomock.Controller
+	recorder *MockTrashMockRecorder
+}
+
+// MockTrashMockRecorder is the mock recorder for MockTrash.
+type MockTrashMockRecorder struct {
+	mock *MockTrash
+}
+
+// NewMockTrash creates a new mock instance.
+func NewMockTrash(ctrl *gomock.Controller) *MockTrash {
+	mock := &MockTrash{ctrl: ctrl}
+	mock.recorder = &MockTrashMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTrash) EXPECT() *MockTrashMockRecorder {
+	return m.recorder
+}
+
+// GetDeletedComments mocks base method.
+func (m *MockTrash) GetDeletedComments(page service.PageRequest) ([]models.Comment, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedComments", page)
+	ret0, _ := ret[0].([]models.Comment)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDeletedComments indicates an expected call of GetDeletedComments.
+func (mr *MockTrashMockRecorder) GetDeletedComments(page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedComments", reflect.TypeOf((*MockTrash)(nil).GetDeletedComments), page)
+}
+
+// GetDeletedPosts mocks base method.
+func (m *MockTrash) GetDeletedPosts(page service.PageRequest) ([]models.Post, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedPosts", page)
+	ret0, _ := ret[0].([]models.Post)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDeletedPosts indicates an expected call of GetDeletedPosts.
+func (mr *MockTrashMockRecorder) GetDeletedPosts(page interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedPosts", reflect.TypeOf((*MockTrash)(nil).GetDeletedPosts), page)
+}
+
+// Purge mocks base method.
+func (m *MockTrash) Purge(ctx context.Context, now time.Time) (service.PurgeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, now)
+	ret0, _ := ret[0].(service.PurgeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockTrashMockRecorder) Purge(ctx, now interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockComment)(nil).Update), postId, id, comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockTrash)(nil).Purge), ctx, now)
 }
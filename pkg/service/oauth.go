@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"test/pkg/repository/models"
+)
+
+var ErrIdentityConflict = errors.New("username is already taken by another account")
+
+// OAuthUser is the identity returned by an external login provider.
+type OAuthUser struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OAuthSignIn signs in the user linked to the external identity. On first login
+// the identity is linked to the user whose email is verified and the same as the verified
+// email of the provider, otherwise a new user without a usable password is created.
+// A new user whose username is taken gives ErrIdentityConflict.
+func (a *AuthService) OAuthSignIn(input OAuthUser, client ClientInfo) (Tokens, error) {
+	identity, err := a.identities.GetIdentity(input.Provider, input.Subject)
+	if err == nil {
+		user, errUser := a.repository.GetUserById(identity.UserId)
+		if errUser != nil {
+			return Tokens{}, errUser
+		}
+		return a.signIn(user, client)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Tokens{}, err
+	}
+
+	var user models.User
+	err = gorm.ErrRecordNotFound
+	if input.Email != "" && input.EmailVerified {
+		user, err = a.repository.GetUserByEmail(input.Email)
+		// the address of an account nobody verified may belong to someone else
+		if err == nil && !user.EmailVerified {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = a.createOAuthUser(input)
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	err = a.identities.CreateIdentity(models.ExternalIdentity{
+		UserId:   user.Id,
+		Provider: input.Provider,
+		Subject:  input.Subject,
+		Email:    input.Email,
+	})
+	if err != nil {
+		return Tokens{}, err
+	}
+	return a.signIn(user, client)
+}
+
+func (a *AuthService) createOAuthUser(input OAuthUser) (models.User, error) {
+	// nobody knows this password, so the account can only sign in through the provider
+	hash, err := a.hasher.Hash(randomToken(32))
+	if err != nil {
+		return models.User{}, err
+	}
+	// an address the provider hasn't verified may belong to someone else, it is kept to be verified here
+	// but can't be the username
+	verified := input.Email != "" && input.EmailVerified
+	username := fmt.Sprintf("%s:%s", input.Provider, input.Subject)
+	if verified {
+		username = input.Email
+	}
+	if err = a.repository.CheckUser(username); err == nil {
+		return models.User{}, ErrIdentityConflict
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.User{}, err
+	}
+	user := models.User{Name: input.Name, Username: username, Password: hash, Email: input.Email}
+	user.Id, err = a.repository.CreateUser(user)
+	if err != nil {
+		return models.User{}, err
+	}
+	if verified {
+		if err = a.repository.SetEmailVerified(user.Id); err != nil {
+			return models.User{}, err
+		}
+		user.EmailVerified = true
+	}
+	return user, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestAuthService_OAuthSignIn(t *testing.T) {
+	type mockBehavior func(users *mockRepository.MockAuthorization, identities *mockRepository.MockIdentity)
+
+	google := OAuthUser{Provider: "google", Subject: "sub", Email: "victim@gmail.com", EmailVerified: true, Name: "Victim"}
+	identity := func(userId int) models.ExternalIdentity {
+		return models.ExternalIdentity{UserId: userId, Provider: "google", Subject: "sub", Email: "victim@gmail.com"}
+	}
+
+	testTable := []struct {
+		name         string
+		input        OAuthUser
+		mockBehavior mockBehavior
+		expectedErr  error
+	}{
+		{
+			name:  "Linked identity",
+			input: google,
+			mockBehavior: func(users *mockRepository.MockAuthorization, identities *mockRepository.MockIdentity) {
+				identities.EXPECT().GetIdentity("google", "sub").Return(identity(5), nil)
+				users.EXPECT().GetUserById(5).Return(models.User{Id: 5}, nil)
+			},
+		},
+		{
+			name:  "Verified email of a user",
+			input: google,
+			mockBehavior: func(users *mockRepository.MockAuthorization, identities *mockRepository.MockIdentity) {
+				identities.EXPECT().GetIdentity("google", "sub").Return(models.ExternalIdentity{}, gorm.ErrRecordNotFound)
+				users.EXPECT().GetUserByEmail("victim@gmail.com").Return(models.User{Id: 5, EmailVerified: true}, nil)
+				identities.EXPECT().CreateIdentity(identity(5)).Return(nil)
+			},
+		},
+		{
+			name:  "Unverified email of a user",
+			input: google,
+			mockBehavior: func(users *mockRepository.MockAuthorization, identities *mockRepository.MockIdentity) {
+				identities.EXPECT().GetIdentity("google", "sub").Return(models.ExternalIdentity{}, gorm.ErrRecordNotFound)
+				users.EXPECT().GetUserByEmail("victim@gmail.com").Return(models.User{Id: 5}, nil)
+				users.EXPECT().CheckUser("victim@gmail.com").Return(gorm.ErrRecordNotFound)
+				users.EXPECT().CreateUser(gomock.Any()).Return(6, nil)
+				users.EXPECT().SetEmailVerified(6).Return(nil)
+				identities.EXPECT().CreateIdentity(identity(6)).Return(nil)
+			},
+		},
+		{
+			name:  "Username taken by the address",
+			input: google,
+			mockBehavior: func(users *mockRepository.MockAuthorization, identities *mockRepository.MockIdentity) {
+				identities.EXPECT().GetIdentity("google", "sub").Return(models.ExternalIdentity{}, gorm.ErrRecordNotFound)
+				users.EXPECT().GetUserByEmail("victim@gmail.com").Return(models.User{}, gorm.ErrRecordNotFound)
+				users.EXPECT().CheckUser("victim@gmail.com").Return(nil)
+			},
+			expectedErr: ErrIdentityConflict,
+		},
+		{
+			name:  "Email not verified by the provider",
+			input: OAuthUser{Provider: "github", Subject: "42", Email: "victim@gmail.com", Name: "Victim"},
+			mockBehavior: func(users *mockRepository.MockAuthorization, identities *mockRepository.MockIdentity) {
+				identities.EXPECT().GetIdentity("github", "42").Return(models.ExternalIdentity{}, gorm.ErrRecordNotFound)
+				users.EXPECT().CheckUser("github:42").Return(gorm.ErrRecordNotFound)
+				users.EXPECT().CreateUser(gomock.Any()).DoAndReturn(func(user models.User) (int, error) {
+					assert.Equal(t, "github:42", user.Username)
+					assert.Equal(t, "victim@gmail.com", user.Email)
+					return 6, nil
+				})
+				identities.EXPECT().CreateIdentity(models.ExternalIdentity{UserId: 6, Provider: "github", Subject: "42",
+					Email: "victim@gmail.com"}).Return(nil)
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			users := mockRepository.NewMockAuthorization(c)
+			identities := mockRepository.NewMockIdentity(c)
+			twoFactor := mockRepository.NewMockTwoFactor(c)
+			// a challenge is the simplest sign in to check, no session is started
+			twoFactor.EXPECT().GetTwoFactor(gomock.Any()).Return(models.TwoFactor{Enabled: true}, nil).AnyTimes()
+			testCase.mockBehavior(users, identities)
+			s := NewAuthService(users, mockRepository.NewMockToken(c), identities, twoFactor, mockRepository.NewMockSession(c),
+				newTestSigningKeys(c), NewLoginThrottle(NewMemoryAttemptStore(time.Hour)))
+
+			tokens, err := s.OAuthSignIn(testCase.input, ClientInfo{IP: "10.0.0.1"})
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, err, testCase.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.ChallengeToken)
+		})
+	}
+}
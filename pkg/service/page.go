@@ -0,0 +1,181 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"test/pkg/repository"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxSortFields   = 3
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+)
+
+// PostFilter and CommentFilter narrow the lists; zero fields are not applied.
+type (
+	PostFilter    = repository.PostFilter
+	CommentFilter = repository.CommentFilter
+)
+
+// PageRequest asks for at most Limit items after the position encoded in Cursor,
+// ordered by Sort, a comma separated list of fields where "-" means descending.
+// An empty cursor starts from the first page, a zero limit and an empty sort use the defaults.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   string
+}
+
+// cursor holds the sort values of the last item of a page. Clients get it
+// as an opaque string and must not build it themselves.
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+type pageLimits struct {
+	def int
+	max int
+}
+
+// newPageLimits reads the page sizes from defaultPageSize and maxPageSize.
+func newPageLimits() pageLimits {
+	limits := pageLimits{def: defaultPageSize, max: maxPageSize}
+	if size, err := strconv.Atoi(os.Getenv("maxPageSize")); err == nil && size > 0 {
+		limits.max = size
+	}
+	if size, err := strconv.Atoi(os.Getenv("defaultPageSize")); err == nil && size > 0 {
+		limits.def = size
+	}
+	if limits.def > limits.max {
+		limits.def = limits.max
+	}
+	return limits
+}
+
+func (l pageLimits) limit(requested int) int {
+	if requested <= 0 {
+		return l.def
+	}
+	if requested > l.max {
+		return l.max
+	}
+	return requested
+}
+
+// listing turns page requests for a list of T into repository pages.
+// fields is the allow-list of sort fields, each reading its value from an item;
+// the field names are also the column names. "id" must be one of them.
+type listing[T any] struct {
+	fields      map[string]func(T) interface{}
+	defaultSort string
+	limits      pageLimits
+}
+
+// page parses the request. The returned page loads one extra item,
+// cut tells from it whether there is a next page.
+func (l listing[T]) page(request PageRequest) (repository.Page, string, error) {
+	sortBy, err := l.parseSort(request.Sort)
+	if err != nil {
+		return repository.Page{}, "", err
+	}
+	key := sortKey(sortBy)
+	page := repository.Page{Sort: sortBy, Limit: l.limits.limit(request.Limit) + 1}
+	if request.Cursor != "" {
+		page.After, err = l.decodeCursor(request.Cursor, key, sortBy)
+		if err != nil {
+			return repository.Page{}, "", err
+		}
+	}
+	return page, key, nil
+}
+
+// cut drops the extra item of the page and returns the cursor of the next page.
+func (l listing[T]) cut(items []T, page repository.Page, key string) ([]T, string) {
+	limit := page.Limit - 1
+	if len(items) <= limit {
+		return items, ""
+	}
+	items = items[:limit]
+	last := items[limit-1]
+	next := cursor{Sort: key}
+	for _, sort := range page.Sort {
+		raw, _ := json.Marshal(l.fields[sort.Column](last))
+		next.Values = append(next.Values, raw)
+	}
+	raw, _ := json.Marshal(next)
+	return items, base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// parseSort checks the fields against the allow-list and appends id,
+// so that items with equal values keep a stable order across pages.
+func (l listing[T]) parseSort(raw string) ([]repository.Sort, error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = l.defaultSort
+	}
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxSortFields {
+		return nil, fmt.Errorf("%w: at most %d fields", ErrInvalidSort, maxSortFields)
+	}
+	var sortBy []repository.Sort
+	seen := make(map[string]bool)
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		sort := repository.Sort{Column: strings.TrimLeft(part, "+-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := l.fields[sort.Column]; !ok || seen[sort.Column] || len(part)-len(sort.Column) > 1 {
+			return nil, fmt.Errorf("%w: unknown or repeated field %q", ErrInvalidSort, part)
+		}
+		seen[sort.Column] = true
+		sortBy = append(sortBy, sort)
+	}
+	if !seen["id"] {
+		sortBy = append(sortBy, repository.Sort{Column: "id", Desc: sortBy[len(sortBy)-1].Desc})
+	}
+	return sortBy, nil
+}
+
+// decodeCursor reads the sort values into the types of the fields.
+// A cursor of another sort order is rejected.
+func (l listing[T]) decodeCursor(raw, key string, sortBy []repository.Sort) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil || c.Sort != key || len(c.Values) != len(sortBy) {
+		return nil, ErrInvalidCursor
+	}
+	var zero T
+	values := make([]interface{}, len(sortBy))
+	for i, sort := range sortBy {
+		value := reflect.New(reflect.TypeOf(l.fields[sort.Column](zero)))
+		if err = json.Unmarshal(c.Values[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+func sortKey(sortBy []repository.Sort) string {
+	parts := make([]string, len(sortBy))
+	for i, sort := range sortBy {
+		parts[i] = sort.Column
+		if sort.Desc {
+			parts[i] = "-" + sort.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
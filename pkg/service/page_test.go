@@ -0,0 +1,82 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestPageLimits(t *testing.T) {
+	t.Setenv("defaultPageSize", "")
+	t.Setenv("maxPageSize", "50")
+	limits := newPageLimits()
+	assert.Equal(t, defaultPageSize, limits.limit(0))
+	assert.Equal(t, 10, limits.limit(10))
+	assert.Equal(t, 50, limits.limit(1000))
+}
+
+func TestListing_ParseSort(t *testing.T) {
+	list := newPostListing()
+
+	sortBy, err := list.parseSort("")
+	assert.NoError(t, err)
+	assert.Equal(t, []repository.Sort{{Column: "created_at", Desc: true}, {Column: "id", Desc: true}}, sortBy)
+
+	sortBy, err = list.parseSort("-created_at, title")
+	assert.NoError(t, err)
+	assert.Equal(t, []repository.Sort{{Column: "created_at", Desc: true}, {Column: "title"}, {Column: "id"}}, sortBy)
+
+	for _, invalid := range []string{"password", "title,title", "--id", "title;drop", "id,title,created_at,id"} {
+		_, err = list.parseSort(invalid)
+		assert.ErrorIs(t, err, ErrInvalidSort, invalid)
+	}
+}
+
+func TestPostService_Get(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	posts := mockRepository.NewMockPost(c)
+	s := &PostService{repository: posts, list: newPostListing()}
+	s.list.limits = pageLimits{def: 2, max: 2}
+
+	at := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := PostFilter{Tag: "news"}
+	first := repository.Page{Sort: []repository.Sort{{Column: "title"}, {Column: "id"}}, Limit: 3}
+	posts.EXPECT().Get(filter, first).
+		Return([]models.Post{{Id: 9, Title: "a", CreatedAt: at}, {Id: 8, Title: "b", CreatedAt: at}, {Id: 7, Title: "c"}}, nil)
+	page, next, err := s.Get(filter, PageRequest{Limit: 5, Sort: "title"})
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.NotEmpty(t, next)
+
+	second := first
+	second.After = []interface{}{"b", 8}
+	posts.EXPECT().Get(filter, second).Return([]models.Post{{Id: 7, Title: "c"}}, nil)
+	page, next, err = s.Get(filter, PageRequest{Cursor: next, Sort: "title"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Post{{Id: 7, Title: "c"}}, page)
+	assert.Empty(t, next)
+
+	_, _, err = s.Get(filter, PageRequest{Cursor: "e30", Sort: "title"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestListing_TimeCursor(t *testing.T) {
+	list := newPostListing()
+	at := time.Date(2022, 1, 1, 10, 0, 0, 123000000, time.UTC)
+	page, key, err := list.page(PageRequest{Limit: 1})
+	assert.NoError(t, err)
+	_, next := list.cut([]models.Post{{Id: 2, CreatedAt: at}, {Id: 1, CreatedAt: at}}, page, key)
+
+	page, _, err = list.page(PageRequest{Limit: 1, Cursor: next})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{at, 2}, page.After)
+
+	_, _, err = list.page(PageRequest{Limit: 1, Cursor: next, Sort: "title"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
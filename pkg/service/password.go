@@ -0,0 +1,194 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"os"
+	"strings"
+)
+
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher hashes new passwords and verifies stored ones.
+// NeedsRehash reports whether a stored hash should be replaced
+// with a fresh one produced by Hash.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (bool, error)
+	NeedsRehash(hash string) bool
+}
+
+// NewPasswordHasher returns the hasher selected by the passwordHasher
+// environment variable (argon2id by default). The returned hasher still
+// verifies bcrypt, argon2id and legacy SHA-1 hashes.
+func NewPasswordHasher() PasswordHasher {
+	var preferred PasswordHasher = NewArgon2idHasher()
+	if os.Getenv("passwordHasher") == HashBcrypt {
+		preferred = NewBcryptHasher(bcrypt.DefaultCost)
+	}
+	return &multiHasher{preferred: preferred}
+}
+
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Time:    1,
+		Memory:  64 * 1024,
+		Threads: 4,
+		KeyLen:  32,
+		SaltLen: 16,
+	}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(hash, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Time != h.Time || params.Memory != h.Memory || params.Threads != h.Threads ||
+		uint32(len(key)) != h.KeyLen || uint32(len(salt)) != h.SaltLen
+}
+
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashArgon2id {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	return params, salt, key, nil
+}
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{Cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h *BcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ErrUnknownHashFormat
+	}
+	return true, nil
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// legacyHasher verifies the unsalted SHA-1 hashes produced by CreatePasswordHash.
+// It never creates new hashes.
+type legacyHasher struct{}
+
+func (legacyHasher) Hash(string) (string, error) {
+	return "", errors.New("legacy password hashes can not be created")
+}
+
+func (legacyHasher) Verify(hash, password string) (bool, error) {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(CreatePasswordHash(password))) == 1, nil
+}
+
+func (legacyHasher) NeedsRehash(string) bool {
+	return true
+}
+
+// multiHasher hashes with the preferred algorithm and verifies
+// with whichever algorithm produced the stored hash.
+type multiHasher struct {
+	preferred PasswordHasher
+}
+
+func (m *multiHasher) Hash(password string) (string, error) {
+	return m.preferred.Hash(password)
+}
+
+func (m *multiHasher) Verify(hash, password string) (bool, error) {
+	return hasherFor(hash).Verify(hash, password)
+}
+
+func (m *multiHasher) NeedsRehash(hash string) bool {
+	switch m.preferred.(type) {
+	case *Argon2idHasher:
+		if !strings.HasPrefix(hash, "$"+HashArgon2id+"$") {
+			return true
+		}
+	case *BcryptHasher:
+		if !strings.HasPrefix(hash, "$2") {
+			return true
+		}
+	}
+	return m.preferred.NeedsRehash(hash)
+}
+
+func hasherFor(hash string) PasswordHasher {
+	switch {
+	case strings.HasPrefix(hash, "$"+HashArgon2id+"$"):
+		return NewArgon2idHasher()
+	case strings.HasPrefix(hash, "$2"):
+		return NewBcryptHasher(bcrypt.DefaultCost)
+	default:
+		return legacyHasher{}
+	}
+}
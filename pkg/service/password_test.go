@@ -0,0 +1,55 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	testTable := []struct {
+		name   string
+		hasher PasswordHasher
+	}{
+		{name: "argon2id", hasher: NewArgon2idHasher()},
+		{name: "bcrypt", hasher: NewBcryptHasher(4)},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			hash, err := testCase.hasher.Hash("password")
+			assert.NoError(t, err)
+
+			ok, err := testCase.hasher.Verify(hash, "password")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = testCase.hasher.Verify(hash, "wrong password")
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			assert.False(t, testCase.hasher.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestMultiHasher_Legacy(t *testing.T) {
+	hasher := &multiHasher{preferred: NewArgon2idHasher()}
+	legacy := CreatePasswordHash("password")
+
+	ok, err := hasher.Verify(legacy, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify(legacy, "wrong password")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, hasher.NeedsRehash(legacy))
+
+	bcryptHash, err := NewBcryptHasher(4).Hash("password")
+	assert.NoError(t, err)
+	ok, err = hasher.Verify(bcryptHash, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(bcryptHash))
+}
@@ -1,38 +1,320 @@
 package service
 
 import (
+	"errors"
+	"gorm.io/gorm"
 	"test/pkg/repository"
 	"test/pkg/repository/models"
+	"time"
+)
+
+const publishBatch = 100
+
+var (
+	ErrPostNotFound     = errors.New("post not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrInvalidStatus    = errors.New("invalid post status")
+	ErrPublishAtPassed  = errors.New("publish_at must be in the future")
 )
 
 type PostService struct {
 	repository repository.Post
+	comments   repository.Comment
+	index      repository.SearchIndex
+	list       listing[models.Post]
+	revisions  listing[models.PostRevision]
 }
 
-func NewPostService(repository repository.Post) *PostService {
-	return &PostService{repository: repository}
+func NewPostService(repository repository.Post, comments repository.Comment, index repository.SearchIndex) *PostService {
+	return &PostService{
+		repository: repository,
+		comments:   comments,
+		index:      index,
+		list:       newPostListing(),
+		revisions: listing[models.PostRevision]{
+			fields: map[string]func(models.PostRevision) interface{}{
+				"id":     func(revision models.PostRevision) interface{} { return revision.Id },
+				"number": func(revision models.PostRevision) interface{} { return revision.Number },
+			},
+			defaultSort: "-number",
+			limits:      newPageLimits(),
+		},
+	}
 }
 
+// newPostListing allows sorting posts by these fields, newest first by default.
+func newPostListing() listing[models.Post] {
+	return listing[models.Post]{
+		fields: map[string]func(models.Post) interface{}{
+			"id":         func(post models.Post) interface{} { return post.Id },
+			"created_at": func(post models.Post) interface{} { return post.CreatedAt },
+			"title":      func(post models.Post) interface{} { return post.Title },
+		},
+		defaultSort: "-created_at",
+		limits:      newPageLimits(),
+	}
+}
+
+// Create publishes the post right away unless it is a draft or scheduled.
 func (p *PostService) Create(post models.Post) (int, error) {
-	return p.repository.Create(post)
+	if post.Status == models.PostStatusArchived {
+		return 0, ErrInvalidStatus
+	}
+	var err error
+	post.Tags = normalizeTags(post.Tags)
+	post.Status, post.PublishAt, err = schedule(post.Status, post.PublishAt, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	id, err := p.repository.Create(post)
+	if err != nil || post.Status != models.PostStatusPublished {
+		return id, err
+	}
+	return id, indexPost(p.index, id, post)
+}
+
+// Get returns a page of the posts that match the filter and the cursor of the next page.
+func (p *PostService) Get(filter PostFilter, request PageRequest) ([]models.Post, string, error) {
+	filter.Tag = normalizeTag(filter.Tag)
+	page, key, err := p.list.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	posts, err := p.repository.Get(filter, page)
+	if err != nil {
+		return nil, "", err
+	}
+	posts, next := p.list.cut(posts, page, key)
+	return posts, next, nil
+}
+
+// GetById returns the post if it is published or belongs to the viewer.
+func (p *PostService) GetById(viewerId, id int) (models.Post, error) {
+	return visiblePost(p.repository, viewerId, id)
+}
+
+// visiblePost returns a post that is published or belongs to the viewer. Others are not found, like the posts in the trash.
+func visiblePost(posts repository.Post, viewerId, postId int) (models.Post, error) {
+	post, err := posts.GetById(postId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return post, ErrPostNotFound
+	}
+	if err != nil {
+		return post, err
+	}
+	// an anonymous viewer has the id 0 like the author of the posts of deleted accounts, it owns none of them
+	if post.Status != models.PostStatusPublished && (viewerId == 0 || post.UserId != viewerId) {
+		return models.Post{}, ErrPostNotFound
+	}
+	return post, nil
+}
+
+// Update saves a new revision of the post. The tags are kept when post.Tags is nil.
+func (p *PostService) Update(actor Principal, id int, post models.Post) error {
+	stored, err := authorizePost(actor, p.repository.GetById, id, "update", PermPostUpdateAny)
+	if err != nil {
+		return err
+	}
+	revision := models.PostRevision{UserId: actor.Id, Title: post.Title, Anons: post.Anons, Body: post.Body}
+	if err = p.repository.Update(id, revision, normalizeTags(post.Tags)); err != nil || stored.Status != models.PostStatusPublished {
+		return err
+	}
+	return indexPost(p.index, id, post)
+}
+
+// SetStatus moves the post between draft, scheduled, published and archived.
+// Only published posts are found by search.
+func (p *PostService) SetStatus(actor Principal, id int, status string, publishAt *time.Time) error {
+	stored, err := authorizePost(actor, p.repository.GetById, id, "update", PermPostUpdateAny)
+	if err != nil {
+		return err
+	}
+	switch {
+	case status == models.PostStatusArchived:
+		publishAt = stored.PublishAt
+	case status == models.PostStatusPublished && stored.Status == models.PostStatusPublished:
+		publishAt = stored.PublishAt
+	default:
+		if status, publishAt, err = schedule(status, publishAt, time.Now()); err != nil {
+			return err
+		}
+	}
+	if err = p.repository.SetStatus(id, status, publishAt); err != nil {
+		return err
+	}
+	if status != models.PostStatusPublished {
+		return p.index.RemovePost(id)
+	}
+	if err = indexPost(p.index, id, stored); err != nil {
+		return err
+	}
+	return indexComments(p.index, p.comments, id)
+}
+
+// PublishDue publishes the scheduled posts whose time has come and returns their number.
+// Posts that another instance published first are skipped.
+func (p *PostService) PublishDue(now time.Time) (int, error) {
+	published := 0
+	for {
+		posts, err := p.repository.GetDue(now, publishBatch)
+		if err != nil {
+			return published, err
+		}
+		for _, post := range posts {
+			ok, err := p.repository.PublishDue(post.Id, now)
+			if err != nil {
+				return published, err
+			}
+			if !ok {
+				continue
+			}
+			published++
+			if err = indexPost(p.index, post.Id, post); err != nil {
+				return published, err
+			}
+			if err = indexComments(p.index, p.comments, post.Id); err != nil {
+				return published, err
+			}
+		}
+		if len(posts) < publishBatch {
+			return published, nil
+		}
+	}
+}
+
+// schedule checks the status of a new or rescheduled post and tells when it is published:
+// now for published posts, at publishAt for scheduled ones and never for drafts.
+func schedule(status string, publishAt *time.Time, now time.Time) (string, *time.Time, error) {
+	switch status {
+	case "", models.PostStatusPublished:
+		return models.PostStatusPublished, &now, nil
+	case models.PostStatusDraft:
+		return models.PostStatusDraft, nil, nil
+	case models.PostStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return "", nil, ErrPublishAtPassed
+		}
+		return models.PostStatusScheduled, publishAt, nil
+	}
+	return "", nil, ErrInvalidStatus
+}
+
+// GetRevisions returns a page of the revisions of a post, the latest first by default.
+// The history is shown to the author and to principals that may review any post.
+func (p *PostService) GetRevisions(actor Principal, id int, request PageRequest) ([]models.PostRevision, string, error) {
+	if _, err := authorizePost(actor, p.repository.GetById, id, "view revisions of", PermPostRevisionsAny); err != nil {
+		return nil, "", err
+	}
+	page, key, err := p.revisions.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	revisions, err := p.repository.GetRevisions(id, page)
+	if err != nil {
+		return nil, "", err
+	}
+	revisions, next := p.revisions.cut(revisions, page, key)
+	return revisions, next, nil
+}
+
+func (p *PostService) GetRevision(actor Principal, id, number int) (models.PostRevision, error) {
+	if _, err := authorizePost(actor, p.repository.GetById, id, "view revisions of", PermPostRevisionsAny); err != nil {
+		return models.PostRevision{}, err
+	}
+	return p.getRevision(id, number)
+}
+
+// DiffRevisions compares two revisions of a post field by field.
+func (p *PostService) DiffRevisions(actor Principal, id, from, to int) (RevisionDiff, error) {
+	if _, err := authorizePost(actor, p.repository.GetById, id, "view revisions of", PermPostRevisionsAny); err != nil {
+		return RevisionDiff{}, err
+	}
+	fromRevision, err := p.getRevision(id, from)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	toRevision, err := p.getRevision(id, to)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+	return diffRevisions(fromRevision, toRevision), nil
 }
 
-func (p *PostService) Get() ([]models.Post, error) {
-	return p.repository.Get()
+// Revert sets the post back to a past revision. The history is kept,
+// the reverted state is saved as a new revision.
+func (p *PostService) Revert(actor Principal, id, number int) error {
+	stored, err := authorizePost(actor, p.repository.GetById, id, "update", PermPostUpdateAny)
+	if err != nil {
+		return err
+	}
+	revision, err := p.getRevision(id, number)
+	if err != nil {
+		return err
+	}
+	reverted := models.PostRevision{UserId: actor.Id, Title: revision.Title, Anons: revision.Anons, Body: revision.Body,
+		RevertedFrom: &number}
+	if err = p.repository.Update(id, reverted, nil); err != nil || stored.Status != models.PostStatusPublished {
+		return err
+	}
+	return indexPost(p.index, id, models.Post{Title: revision.Title, Anons: revision.Anons, Body: revision.Body})
 }
 
-func (p *PostService) GetById(id int) (models.Post, error) {
-	return p.repository.GetById(id)
+func (p *PostService) getRevision(id, number int) (models.PostRevision, error) {
+	revision, err := p.repository.GetRevision(id, number)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return revision, ErrRevisionNotFound
+	}
+	return revision, err
 }
 
-func (p *PostService) GetByUserId(userId int) ([]models.Post, error) {
-	return p.repository.GetByUserId(userId)
+// Delete moves the post to the trash. The post and its comments are no longer
+// listed or found by search until the post is restored.
+func (p *PostService) Delete(actor Principal, id int) error {
+	if _, err := authorizePost(actor, p.repository.GetById, id, "delete", PermPostDeleteAny); err != nil {
+		return err
+	}
+	if err := p.repository.Delete(id, actor.Id); err != nil {
+		return err
+	}
+	return p.index.RemovePost(id)
 }
 
-func (p *PostService) Update(id int, post models.Post) error {
-	return p.repository.Update(id, post)
+// Restore moves the post back from the trash. The author may restore only what they deleted.
+func (p *PostService) Restore(actor Principal, id int) error {
+	post, err := authorizePost(actor, p.repository.GetDeletedById, id, "restore", PermPostRestoreAny)
+	if err != nil {
+		return err
+	}
+	// the author can't undo the removal by a moderator
+	if post.DeletedBy != actor.Id && !actor.Can(PermPostRestoreAny) {
+		return &ForbiddenError{Action: "restore", Resource: "post", Id: id}
+	}
+	err = p.repository.Restore(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPostNotFound
+	}
+	if err != nil || post.Status != models.PostStatusPublished {
+		return err
+	}
+	if err = indexPost(p.index, id, post); err != nil {
+		return err
+	}
+	return indexComments(p.index, p.comments, id)
 }
 
-func (p *PostService) Delete(id int) error {
-	return p.repository.Delete(id)
+// authorizePost allows the action to the author of the post
+// and to principals that hold the permission for any post.
+func authorizePost(actor Principal, get func(id int) (models.Post, error), id int,
+	action, anyPermission string) (models.Post, error) {
+	post, err := get(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return post, ErrPostNotFound
+	}
+	if err != nil {
+		return post, err
+	}
+	if post.UserId != actor.Id && !actor.Can(anyPermission) {
+		return post, &ForbiddenError{Action: action, Resource: "post", Id: id}
+	}
+	return post, nil
 }
@@ -0,0 +1,121 @@
+package service
+
+import "strings"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+const (
+	PermPostCreate        = "post:create"
+	PermPostUpdate        = "post:update"
+	PermPostDelete        = "post:delete"
+	PermPostUpdateAny     = "post:update:any"
+	PermPostDeleteAny     = "post:delete:any"
+	PermCommentCreate     = "comment:create"
+	PermCommentUpdate     = "comment:update"
+	PermCommentDelete     = "comment:delete"
+	PermCommentUpdateAny  = "comment:update:any"
+	PermCommentDeleteAny  = "comment:delete:any"
+	PermPostRestoreAny    = "post:restore:any"
+	PermPostRevisionsAny  = "post:revisions:any"
+	PermCommentRestoreAny = "comment:restore:any"
+	PermTrashRead         = "trash:read"
+	PermTagManage         = "tag:manage"
+	PermReactionCreate    = "reaction:create"
+	PermUserRoleUpdate    = "user:role:update"
+	PermUserUnlock        = "user:unlock"
+	PermKeyRotate         = "key:rotate"
+	PermApiKeyManage      = "apikey:manage"
+	PermTwoFactorManage   = "twofactor:manage"
+	PermSessionManage     = "session:manage"
+	PermAccountUpdate     = "account:update"
+	PermAccountDelete     = "account:delete"
+)
+
+// Scopes limit what a credential may do on top of the permissions of the user's roles.
+const (
+	ScopeAll            = "*"
+	ScopePostsWrite     = "posts:write"
+	ScopeCommentsWrite  = "comments:write"
+	ScopeReactionsWrite = "reactions:write"
+	ScopeAdmin          = "admin"
+)
+
+var userPermissions = []string{
+	PermPostCreate, PermPostUpdate, PermPostDelete,
+	PermCommentCreate, PermCommentUpdate, PermCommentDelete, PermReactionCreate,
+	PermApiKeyManage, PermTwoFactorManage, PermSessionManage,
+	PermAccountUpdate, PermAccountDelete,
+}
+
+var moderatorPermissions = append([]string{
+	PermPostDeleteAny, PermCommentDeleteAny, PermPostRevisionsAny,
+}, userPermissions...)
+
+var rolePermissions = map[string][]string{
+	RoleUser:      userPermissions,
+	RoleModerator: moderatorPermissions,
+	RoleAdmin: append([]string{
+		PermPostUpdateAny, PermCommentUpdateAny, PermUserRoleUpdate, PermUserUnlock, PermKeyRotate,
+		PermPostRestoreAny, PermCommentRestoreAny, PermTrashRead, PermTagManage,
+	}, moderatorPermissions...),
+}
+
+var permissionScopes = map[string]string{
+	"post":     ScopePostsWrite,
+	"comment":  ScopeCommentsWrite,
+	"reaction": ScopeReactionsWrite,
+	"user":     ScopeAdmin,
+	"key":      ScopeAdmin,
+	"trash":    ScopeAdmin,
+	"tag":      ScopeAdmin,
+	// credentials are managed only with a full session, never with an API key
+	"apikey":    ScopeAll,
+	"twofactor": ScopeAll,
+	"session":   ScopeAll,
+	"account":   ScopeAll,
+}
+
+// keyScopes are the scopes that can be granted to an API key.
+var keyScopes = []string{ScopePostsWrite, ScopeCommentsWrite, ScopeReactionsWrite, ScopeAdmin}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Id     int      `json:"id"`
+	Roles  []string `json:"roles"`
+	Scopes []string `json:"scopes"`
+}
+
+// Can reports whether one of the principal's roles grants the permission
+// and the principal's scopes allow using it.
+func (p Principal) Can(permission string) bool {
+	resource := strings.SplitN(permission, ":", 2)[0]
+	if !p.HasScope(permissionScopes[resource]) {
+		return false
+	}
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == ScopeAll || s == scope {
+			return true
+		}
+	}
+	return false
+}
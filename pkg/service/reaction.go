@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+)
+
+// defaultReactionKinds are the emoji reactions offered next to like
+// unless reactionKinds lists others.
+const defaultReactionKinds = "love,laugh,wow,sad,angry"
+
+var ErrInvalidReaction = errors.New("unknown reaction")
+
+// ReactionTarget is the post or the comment that is reacted to.
+// For a post Id equals PostId.
+type ReactionTarget struct {
+	Type   string
+	PostId int
+	Id     int
+}
+
+func PostTarget(postId int) ReactionTarget {
+	return ReactionTarget{Type: models.ReactionTargetPost, PostId: postId, Id: postId}
+}
+
+func CommentTarget(postId, id int) ReactionTarget {
+	return ReactionTarget{Type: models.ReactionTargetComment, PostId: postId, Id: id}
+}
+
+type ReactionService struct {
+	reactions repository.Reaction
+	posts     repository.Post
+	comments  repository.Comment
+	kinds     []string
+	allowed   map[string]bool
+	list      listing[models.Reaction]
+}
+
+// NewReactionService reads the emoji reactions from reactionKinds, a comma separated list of names.
+func NewReactionService(reactions repository.Reaction, posts repository.Post, comments repository.Comment) *ReactionService {
+	raw := os.Getenv("reactionKinds")
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultReactionKinds
+	}
+	kinds := []string{models.ReactionLike}
+	allowed := map[string]bool{models.ReactionLike: true}
+	for _, kind := range strings.Split(raw, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind != "" && !allowed[kind] {
+			kinds = append(kinds, kind)
+			allowed[kind] = true
+		}
+	}
+	return &ReactionService{
+		reactions: reactions,
+		posts:     posts,
+		comments:  comments,
+		kinds:     kinds,
+		allowed:   allowed,
+		list: listing[models.Reaction]{
+			fields: map[string]func(models.Reaction) interface{}{
+				"id": func(reaction models.Reaction) interface{} { return reaction.Id },
+			},
+			defaultSort: "-id",
+			limits:      newPageLimits(),
+		},
+	}
+}
+
+// GetReactionKinds returns the kinds of reactions users can choose from.
+func (r *ReactionService) GetReactionKinds() []string {
+	return r.kinds
+}
+
+// React adds the reaction of the viewer to the target and returns the reactions to it.
+// Reacting again with the same kind changes nothing.
+func (r *ReactionService) React(viewerId int, target ReactionTarget, kind string) (models.ReactionSummary, error) {
+	if !r.allowed[kind] {
+		return models.ReactionSummary{}, ErrInvalidReaction
+	}
+	if err := r.checkTarget(viewerId, target); err != nil {
+		return models.ReactionSummary{}, err
+	}
+	_, err := r.reactions.AddReaction(models.Reaction{TargetType: target.Type, TargetId: target.Id, Kind: kind, UserId: viewerId})
+	if err != nil {
+		return models.ReactionSummary{}, err
+	}
+	return r.summary(viewerId, target)
+}
+
+// Unreact removes the reaction of the viewer from the target and returns the reactions to it.
+// Removing a reaction that is not there changes nothing.
+func (r *ReactionService) Unreact(viewerId int, target ReactionTarget, kind string) (models.ReactionSummary, error) {
+	if !r.allowed[kind] {
+		return models.ReactionSummary{}, ErrInvalidReaction
+	}
+	if err := r.checkTarget(viewerId, target); err != nil {
+		return models.ReactionSummary{}, err
+	}
+	_, err := r.reactions.RemoveReaction(models.Reaction{TargetType: target.Type, TargetId: target.Id, Kind: kind, UserId: viewerId})
+	if err != nil {
+		return models.ReactionSummary{}, err
+	}
+	return r.summary(viewerId, target)
+}
+
+// GetReactions returns a page of the reactions to the target, the latest first by default.
+// An empty kind lists the reactions of all kinds.
+func (r *ReactionService) GetReactions(viewerId int, target ReactionTarget, kind string, request PageRequest) ([]models.Reaction, string, error) {
+	if kind != "" && !r.allowed[kind] {
+		return nil, "", ErrInvalidReaction
+	}
+	if err := r.checkTarget(viewerId, target); err != nil {
+		return nil, "", err
+	}
+	page, key, err := r.list.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	reactions, err := r.reactions.GetReactions(target.Type, target.Id, kind, page)
+	if err != nil {
+		return nil, "", err
+	}
+	reactions, next := r.list.cut(reactions, page, key)
+	return reactions, next, nil
+}
+
+// GetReactionSummaries returns the reactions to the posts or comments by their ids.
+// Mine is filled only for a signed in viewer.
+func (r *ReactionService) GetReactionSummaries(viewerId int, targetType string, ids []int) (map[int]models.ReactionSummary, error) {
+	summaries := make(map[int]models.ReactionSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+	for _, id := range ids {
+		summaries[id] = models.ReactionSummary{Counts: map[string]int{}, Mine: []string{}}
+	}
+	counts, err := r.reactions.GetReactionCounts(targetType, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		summaries[count.TargetId].Counts[count.Kind] = count.Count
+	}
+	if viewerId == 0 {
+		return summaries, nil
+	}
+	mine, err := r.reactions.GetUserReactions(targetType, ids, viewerId)
+	if err != nil {
+		return nil, err
+	}
+	for _, reaction := range mine {
+		summary := summaries[reaction.TargetId]
+		summary.Mine = append(summary.Mine, reaction.Kind)
+		summaries[reaction.TargetId] = summary
+	}
+	return summaries, nil
+}
+
+func (r *ReactionService) summary(viewerId int, target ReactionTarget) (models.ReactionSummary, error) {
+	summaries, err := r.GetReactionSummaries(viewerId, target.Type, []int{target.Id})
+	if err != nil {
+		return models.ReactionSummary{}, err
+	}
+	return summaries[target.Id], nil
+}
+
+// checkTarget makes sure the target exists and the viewer may see it:
+// the post is published or belongs to the viewer.
+func (r *ReactionService) checkTarget(viewerId int, target ReactionTarget) error {
+	if _, err := visiblePost(r.posts, viewerId, target.PostId); err != nil {
+		return err
+	}
+	if target.Type != models.ReactionTargetComment {
+		return nil
+	}
+	_, err := r.comments.GetById(target.PostId, target.Id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommentNotFound
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestNewReactionService(t *testing.T) {
+	t.Setenv("reactionKinds", " fire, like ,,clap ")
+	s := NewReactionService(nil, nil, nil)
+	assert.Equal(t, []string{"like", "fire", "clap"}, s.GetReactionKinds())
+
+	t.Setenv("reactionKinds", "")
+	s = NewReactionService(nil, nil, nil)
+	assert.Equal(t, []string{"like", "love", "laugh", "wow", "sad", "angry"}, s.GetReactionKinds())
+}
+
+func TestReactionService_React(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	reactions := mockRepository.NewMockReaction(c)
+	posts := mockRepository.NewMockPost(c)
+	comments := mockRepository.NewMockComment(c)
+	s := NewReactionService(reactions, posts, comments)
+
+	_, err := s.React(3, PostTarget(1), "meh")
+	assert.ErrorIs(t, err, ErrInvalidReaction)
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 7, Status: models.PostStatusDraft}, nil)
+	_, err = s.React(3, PostTarget(1), models.ReactionLike)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 7, Status: models.PostStatusPublished}, nil)
+	comments.EXPECT().GetById(1, 5).Return(models.Comment{}, gorm.ErrRecordNotFound)
+	_, err = s.React(3, CommentTarget(1, 5), models.ReactionLike)
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+
+	posts.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 7, Status: models.PostStatusPublished}, nil)
+	reactions.EXPECT().AddReaction(models.Reaction{TargetType: models.ReactionTargetPost, TargetId: 1, Kind: "love", UserId: 3}).
+		Return(true, nil)
+	reactions.EXPECT().GetReactionCounts(models.ReactionTargetPost, []int{1}).Return([]models.ReactionCount{
+		{TargetType: models.ReactionTargetPost, TargetId: 1, Kind: models.ReactionLike, Count: 4},
+		{TargetType: models.ReactionTargetPost, TargetId: 1, Kind: "love", Count: 1},
+	}, nil)
+	reactions.EXPECT().GetUserReactions(models.ReactionTargetPost, []int{1}, 3).
+		Return([]models.Reaction{{TargetType: models.ReactionTargetPost, TargetId: 1, Kind: "love", UserId: 3}}, nil)
+	summary, err := s.React(3, PostTarget(1), "love")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReactionSummary{Counts: map[string]int{"like": 4, "love": 1}, Mine: []string{"love"}}, summary)
+}
+
+func TestReactionService_GetReactionSummaries(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	reactions := mockRepository.NewMockReaction(c)
+	s := NewReactionService(reactions, nil, nil)
+
+	reactions.EXPECT().GetReactionCounts(models.ReactionTargetComment, []int{2, 3}).Return([]models.ReactionCount{
+		{TargetType: models.ReactionTargetComment, TargetId: 3, Kind: models.ReactionLike, Count: 2},
+	}, nil)
+	summaries, err := s.GetReactionSummaries(0, models.ReactionTargetComment, []int{2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]models.ReactionSummary{
+		2: {Counts: map[string]int{}, Mine: []string{}},
+		3: {Counts: map[string]int{"like": 2}, Mine: []string{}},
+	}, summaries)
+}
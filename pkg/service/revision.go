@@ -0,0 +1,135 @@
+package service
+
+import (
+	"regexp"
+	"test/pkg/repository/models"
+)
+
+const (
+	DiffEqual  = "equal"
+	DiffDelete = "delete"
+	DiffInsert = "insert"
+
+	// maxDiffCells bounds the table of the word diff, longer texts
+	// are shown as deleted and inserted as a whole
+	maxDiffCells = 4 << 20
+)
+
+var diffTokens = regexp.MustCompile(`\s+|\S+`)
+
+// RevisionDiff tells how the fields of a post changed between two revisions.
+// Fields that didn't change are left out.
+type RevisionDiff struct {
+	From   int         `json:"from"`
+	To     int         `json:"to"`
+	Fields []FieldDiff `json:"fields"`
+}
+
+type FieldDiff struct {
+	Field   string       `json:"field"`
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Changes []DiffChange `json:"changes"`
+}
+
+// DiffChange is a run of text that is equal in both revisions, deleted or inserted.
+type DiffChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+func diffRevisions(from, to models.PostRevision) RevisionDiff {
+	diff := RevisionDiff{From: from.Number, To: to.Number, Fields: []FieldDiff{}}
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{name: "title", from: from.Title, to: to.Title},
+		{name: "anons", from: from.Anons, to: to.Anons},
+		{name: "body", from: from.Body, to: to.Body},
+	}
+	for _, field := range fields {
+		if field.from == field.to {
+			continue
+		}
+		diff.Fields = append(diff.Fields, FieldDiff{
+			Field:   field.name,
+			From:    field.from,
+			To:      field.to,
+			Changes: diffWords(field.from, field.to),
+		})
+	}
+	return diff
+}
+
+// diffWords compares the texts word by word, keeping the whitespace,
+// so that joining the equal and deleted runs gives a and joining
+// the equal and inserted runs gives b.
+func diffWords(a, b string) []DiffChange {
+	x, y := diffTokens.FindAllString(a, -1), diffTokens.FindAllString(b, -1)
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	var changes []DiffChange
+	add := func(op string, token string) {
+		if last := len(changes) - 1; last >= 0 && changes[last].Op == op {
+			changes[last].Text += token
+			return
+		}
+		changes = append(changes, DiffChange{Op: op, Text: token})
+	}
+	for _, token := range x[:prefix] {
+		add(DiffEqual, token)
+	}
+	xs, ys := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+	if (len(xs)+1)*(len(ys)+1) > maxDiffCells {
+		for _, token := range xs {
+			add(DiffDelete, token)
+		}
+		for _, token := range ys {
+			add(DiffInsert, token)
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of xs[i:] and ys[j:]
+		lcs := make([][]int, len(xs)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(ys)+1)
+		}
+		for i := len(xs) - 1; i >= 0; i-- {
+			for j := len(ys) - 1; j >= 0; j-- {
+				if xs[i] == ys[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(xs) || j < len(ys) {
+			switch {
+			case i < len(xs) && j < len(ys) && xs[i] == ys[j]:
+				add(DiffEqual, xs[i])
+				i++
+				j++
+			case j == len(ys) || (i < len(xs) && lcs[i+1][j] >= lcs[i][j+1]):
+				add(DiffDelete, xs[i])
+				i++
+			default:
+				add(DiffInsert, ys[j])
+				j++
+			}
+		}
+	}
+	for _, token := range x[len(x)-suffix:] {
+		add(DiffEqual, token)
+	}
+	return changes
+}
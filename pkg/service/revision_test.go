@@ -0,0 +1,102 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	testTable := []struct {
+		name     string
+		a, b     string
+		expected []DiffChange
+	}{
+		{
+			name: "Replaced word",
+			a:    "the quick brown fox",
+			b:    "the slow brown fox",
+			expected: []DiffChange{
+				{Op: DiffEqual, Text: "the "},
+				{Op: DiffDelete, Text: "quick"},
+				{Op: DiffInsert, Text: "slow"},
+				{Op: DiffEqual, Text: " brown fox"},
+			},
+		},
+		{
+			name: "Appended words",
+			a:    "hello",
+			b:    "hello  big world",
+			expected: []DiffChange{
+				{Op: DiffEqual, Text: "hello"},
+				{Op: DiffInsert, Text: "  big world"},
+			},
+		},
+		{
+			name:     "From empty",
+			a:        "",
+			b:        "new text",
+			expected: []DiffChange{{Op: DiffInsert, Text: "new text"}},
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			changes := diffWords(testCase.a, testCase.b)
+			assert.Equal(t, testCase.expected, changes)
+
+			var a, b string
+			for _, change := range changes {
+				if change.Op != DiffInsert {
+					a += change.Text
+				}
+				if change.Op != DiffDelete {
+					b += change.Text
+				}
+			}
+			assert.Equal(t, testCase.a, a)
+			assert.Equal(t, testCase.b, b)
+		})
+	}
+}
+
+func TestPostService_Revisions(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	posts := mockRepository.NewMockPost(c)
+	s := NewPostService(posts, mockRepository.NewMockComment(c), repository.NewMemorySearchIndex())
+	author := Principal{Id: 3, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	other := Principal{Id: 4, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	moderator := Principal{Id: 5, Roles: []string{RoleModerator}, Scopes: []string{ScopeAll}}
+	post := models.Post{Id: 1, UserId: 3, Title: "Draft", Anons: "first words"}
+	first := models.PostRevision{PostId: 1, Number: 1, UserId: 3, Title: "Draft", Anons: "first words"}
+	second := models.PostRevision{PostId: 1, Number: 2, UserId: 5, Title: "Final", Anons: "first words"}
+	posts.EXPECT().GetById(1).Return(post, nil).AnyTimes()
+
+	_, err := s.GetRevision(other, 1, 1)
+	var forbidden *ForbiddenError
+	assert.ErrorAs(t, err, &forbidden)
+
+	posts.EXPECT().GetRevision(1, 1).Return(first, nil)
+	posts.EXPECT().GetRevision(1, 2).Return(second, nil)
+	diff, err := s.DiffRevisions(moderator, 1, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, RevisionDiff{From: 1, To: 2, Fields: []FieldDiff{{
+		Field: "title", From: "Draft", To: "Final",
+		Changes: []DiffChange{{Op: DiffDelete, Text: "Draft"}, {Op: DiffInsert, Text: "Final"}},
+	}}}, diff)
+
+	posts.EXPECT().GetRevision(1, 9).Return(models.PostRevision{}, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, s.Revert(author, 1, 9), ErrRevisionNotFound)
+
+	number := 1
+	posts.EXPECT().GetRevision(1, 1).Return(first, nil)
+	posts.EXPECT().Update(1, models.PostRevision{UserId: 3, Title: "Draft", Anons: "first words", RevertedFrom: &number}, nil).Return(nil)
+	assert.NoError(t, s.Revert(author, 1, 1))
+
+	assert.ErrorAs(t, s.Revert(moderator, 1, 1), &forbidden)
+}
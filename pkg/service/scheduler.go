@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const schedulerInterval = 15 * time.Second
+
+// RunScheduler publishes the due scheduled posts until the context is done.
+// The schedule is kept in the database, so posts that came due while the
+// server was down are published on the first run after a restart.
+func RunScheduler(ctx context.Context, posts Post) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		published, err := posts.PublishDue(time.Now())
+		if err != nil {
+			log.Printf("publishing scheduled posts failed: %s", err.Error())
+		} else if published > 0 {
+			log.Printf("published %d scheduled posts", published)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	testTable := []struct {
+		name              string
+		status            string
+		publishAt         *time.Time
+		expectedStatus    string
+		expectedPublishAt *time.Time
+		expectedErr       error
+	}{
+		{name: "default", expectedStatus: models.PostStatusPublished, expectedPublishAt: &now},
+		{name: "published", status: models.PostStatusPublished, publishAt: &later, expectedStatus: models.PostStatusPublished, expectedPublishAt: &now},
+		{name: "draft", status: models.PostStatusDraft, publishAt: &later, expectedStatus: models.PostStatusDraft},
+		{name: "scheduled", status: models.PostStatusScheduled, publishAt: &later, expectedStatus: models.PostStatusScheduled, expectedPublishAt: &later},
+		{name: "scheduled in the past", status: models.PostStatusScheduled, publishAt: &earlier, expectedErr: ErrPublishAtPassed},
+		{name: "scheduled without time", status: models.PostStatusScheduled, expectedErr: ErrPublishAtPassed},
+		{name: "unknown", status: "hidden", expectedErr: ErrInvalidStatus},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			status, publishAt, err := schedule(testCase.status, testCase.publishAt, now)
+			assert.ErrorIs(t, err, testCase.expectedErr)
+			assert.Equal(t, testCase.expectedStatus, status)
+			assert.Equal(t, testCase.expectedPublishAt, publishAt)
+		})
+	}
+}
+
+func TestPostService_GetById(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	postRepository := mockRepository.NewMockPost(c)
+	posts := NewPostService(postRepository, mockRepository.NewMockComment(c), repository.NewMemorySearchIndex())
+	draft := models.Post{Id: 1, UserId: 3, Title: "Draft", Status: models.PostStatusDraft}
+
+	postRepository.EXPECT().GetById(1).Return(draft, nil).Times(3)
+	post, err := posts.GetById(3, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, draft, post)
+	_, err = posts.GetById(4, 1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+	_, err = posts.GetById(0, 1)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+
+	// the drafts of deleted accounts have no author, an anonymous viewer isn't one
+	anonymized := models.Post{Id: 2, Title: "Draft", Status: models.PostStatusDraft}
+	postRepository.EXPECT().GetById(2).Return(anonymized, nil)
+	_, err = posts.GetById(0, 2)
+	assert.ErrorIs(t, err, ErrPostNotFound)
+}
+
+func TestPostService_SetStatus(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	index := repository.NewMemorySearchIndex()
+	postRepository := mockRepository.NewMockPost(c)
+	commentRepository := mockRepository.NewMockComment(c)
+	posts := NewPostService(postRepository, commentRepository, index)
+	search := NewSearchService(index)
+	owner := Principal{Id: 3, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	publishedAt := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	post := models.Post{Id: 1, UserId: 3, Title: "Sourdough", Anons: "Feed the starter", Status: models.PostStatusDraft}
+
+	postRepository.EXPECT().GetById(1).Return(post, nil)
+	assert.ErrorIs(t, posts.SetStatus(owner, 1, models.PostStatusScheduled, &publishedAt), ErrPublishAtPassed)
+
+	postRepository.EXPECT().GetById(1).Return(post, nil)
+	postRepository.EXPECT().SetStatus(1, models.PostStatusPublished, gomock.Not(gomock.Nil())).Return(nil)
+	commentRepository.EXPECT().Get(1, CommentFilter{}, repository.Page{Sort: []repository.Sort{{Column: "id"}}, Limit: maxPageSize}).
+		Return(nil, nil)
+	assert.NoError(t, posts.SetStatus(owner, 1, models.PostStatusPublished, nil))
+	results, err := search.Search("sourdough", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	post.Status = models.PostStatusPublished
+	post.PublishAt = &publishedAt
+	postRepository.EXPECT().GetById(1).Return(post, nil)
+	postRepository.EXPECT().SetStatus(1, models.PostStatusArchived, &publishedAt).Return(nil)
+	assert.NoError(t, posts.SetStatus(owner, 1, models.PostStatusArchived, nil))
+	results, err = search.Search("sourdough", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	postRepository.EXPECT().GetById(1).Return(post, nil)
+	err = posts.SetStatus(Principal{Id: 4, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}, 1, models.PostStatusDraft, nil)
+	var forbidden *ForbiddenError
+	assert.ErrorAs(t, err, &forbidden)
+}
+
+func TestPostService_PublishDue(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	index := repository.NewMemorySearchIndex()
+	postRepository := mockRepository.NewMockPost(c)
+	commentRepository := mockRepository.NewMockComment(c)
+	posts := NewPostService(postRepository, commentRepository, index)
+	search := NewSearchService(index)
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	due := []models.Post{
+		{Id: 1, UserId: 3, Title: "Sourdough", Status: models.PostStatusScheduled, PublishAt: &now},
+		{Id: 2, UserId: 3, Title: "Sourdough again", Status: models.PostStatusScheduled, PublishAt: &now},
+	}
+
+	postRepository.EXPECT().GetDue(now, publishBatch).Return(due, nil)
+	postRepository.EXPECT().PublishDue(1, now).Return(true, nil)
+	// Another instance published the second post first.
+	postRepository.EXPECT().PublishDue(2, now).Return(false, nil)
+	commentRepository.EXPECT().Get(1, CommentFilter{}, repository.Page{Sort: []repository.Sort{{Column: "id"}}, Limit: maxPageSize}).
+		Return([]models.Comment{{Id: 7, PostId: 1, UserId: 4, Body: "Rye works too"}}, nil)
+
+	published, err := posts.PublishDue(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	results, err := search.Search("sourdough", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	results, err = search.Search("rye", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"unicode"
+)
+
+// snippetLength is the number of symbols of a snippet around the first match.
+const snippetLength = 160
+
+var ErrInvalidQuery = errors.New("query must contain a word")
+
+// SearchResult is a post or a comment that matches a query. Title and Snippet
+// are HTML escaped and the matched words are wrapped in <mark> tags.
+type SearchResult struct {
+	Kind    string  `json:"kind"`
+	Id      int     `json:"id"`
+	PostId  int     `json:"post_id"`
+	Title   string  `json:"title,omitempty"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+type SearchService struct {
+	index  repository.SearchIndex
+	limits pageLimits
+}
+
+func NewSearchService(index repository.SearchIndex) *SearchService {
+	return &SearchService{index: index, limits: newPageLimits()}
+}
+
+// Search returns the best matches first.
+func (s *SearchService) Search(query string, limit int) ([]SearchResult, error) {
+	words := repository.Terms(query)
+	if len(words) == 0 {
+		return nil, ErrInvalidQuery
+	}
+	hits, err := s.index.Search(query, s.limits.limit(limit))
+	if err != nil {
+		return nil, err
+	}
+	terms := make(map[string]bool, len(words))
+	for _, word := range words {
+		terms[word] = true
+	}
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, SearchResult{
+			Kind:    hit.Kind,
+			Id:      hit.RefId,
+			PostId:  hit.PostId,
+			Title:   highlight(hit.Title, terms, 0),
+			Snippet: highlight(hit.Body, terms, snippetLength),
+			Score:   hit.Score,
+		})
+	}
+	return results, nil
+}
+
+// indexPost indexes the anons followed by the body, so that snippets start with the anons.
+func indexPost(index repository.SearchIndex, id int, post models.Post) error {
+	body := post.Anons
+	if post.Body != "" {
+		body += "\n\n" + post.Body
+	}
+	return index.Index(models.SearchDocument{
+		Kind:   models.SearchKindPost,
+		RefId:  id,
+		PostId: id,
+		Title:  post.Title,
+		Body:   body,
+	})
+}
+
+func indexComment(index repository.SearchIndex, postId, id int, comment models.Comment) error {
+	return index.Index(models.SearchDocument{
+		Kind:   models.SearchKindComment,
+		RefId:  id,
+		PostId: postId,
+		Body:   comment.Body,
+	})
+}
+
+// indexComments indexes the comments of a post again, a batch at a time.
+func indexComments(index repository.SearchIndex, comments repository.Comment, postId int) error {
+	page := repository.Page{Sort: []repository.Sort{{Column: "id"}}, Limit: maxPageSize}
+	for {
+		batch, err := comments.Get(postId, CommentFilter{}, page)
+		if err != nil {
+			return err
+		}
+		for _, comment := range batch {
+			if err = indexComment(index, postId, comment.Id, comment); err != nil {
+				return err
+			}
+		}
+		if len(batch) < page.Limit {
+			return nil
+		}
+		page.After = []interface{}{batch[len(batch)-1].Id}
+	}
+}
+
+type wordSpan struct {
+	start int
+	end   int
+}
+
+// highlight escapes text and marks the words that are terms. If width is
+// positive, only about width symbols around the first marked word are kept.
+func highlight(text string, terms map[string]bool, width int) string {
+	runes := []rune(text)
+	var matches []wordSpan
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && terms[strings.ToLower(string(runes[start:i]))] {
+			matches = append(matches, wordSpan{start: start, end: i})
+		}
+		start = -1
+	}
+
+	from, to := 0, len(runes)
+	if width > 0 && len(runes) > width {
+		if len(matches) > 0 {
+			from = matches[0].start - width/4
+		}
+		if from+width > len(runes) {
+			from = len(runes) - width
+		}
+		if from < 0 {
+			from = 0
+		}
+		to = from + width
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	position := from
+	for _, match := range matches {
+		if match.start < from || match.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[position:match.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[match.start:match.end])))
+		b.WriteString("</mark>")
+		position = match.end
+	}
+	b.WriteString(html.EscapeString(string(runes[position:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"strings"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestSearchService_SyncWithPostsAndComments(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	index := repository.NewMemorySearchIndex()
+	postRepository := mockRepository.NewMockPost(c)
+	commentRepository := mockRepository.NewMockComment(c)
+	posts := NewPostService(postRepository, commentRepository, index)
+	comments := NewCommentService(commentRepository, postRepository, index)
+	search := NewSearchService(index)
+	owner := Principal{Id: 3}
+
+	postRepository.EXPECT().Create(gomock.Any()).Return(1, nil)
+	_, err := posts.Create(models.Post{UserId: 3, Title: "Golang generics", Anons: "Type parameters in practice"})
+	assert.NoError(t, err)
+	postRepository.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusPublished}, nil)
+	commentRepository.EXPECT().Create(gomock.Any()).Return(7, nil)
+	_, err = comments.Create(models.Comment{PostId: 1, UserId: 3, Body: "Generics made my <code> shorter"})
+	assert.NoError(t, err)
+
+	results, err := search.Search("generics", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []SearchResult{
+		{Kind: models.SearchKindPost, Id: 1, PostId: 1, Title: "Golang <mark>generics</mark>",
+			Snippet: "Type parameters in practice", Score: results[0].Score},
+		{Kind: models.SearchKindComment, Id: 7, PostId: 1,
+			Snippet: "<mark>Generics</mark> made my &lt;code&gt; shorter", Score: results[1].Score},
+	}, results)
+	assert.Greater(t, results[0].Score, results[1].Score)
+
+	postRepository.EXPECT().GetById(1).Return(models.Post{Id: 1, UserId: 3, Status: models.PostStatusPublished}, nil)
+	postRepository.EXPECT().Update(1, gomock.Any(), nil).Return(nil)
+	assert.NoError(t, posts.Update(owner, 1, models.Post{Title: "Rust traits", Anons: "Type classes"}))
+	results, err = search.Search("golang", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	commentRepository.EXPECT().GetById(1, 7).Return(models.Comment{Id: 7, PostId: 1, UserId: 3}, nil)
+	commentRepository.EXPECT().Delete(1, 7, 3).Return(nil)
+	assert.NoError(t, comments.Delete(owner, 1, 7))
+	results, err = search.Search("generics", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = search.Search(" ?! ", 10)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func TestCommentService_PostVisibility(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	index := repository.NewMemorySearchIndex()
+	postRepository := mockRepository.NewMockPost(c)
+	commentRepository := mockRepository.NewMockComment(c)
+	comments := NewCommentService(commentRepository, postRepository, index)
+	search := NewSearchService(index)
+	draft := models.Post{Id: 1, UserId: 3, Status: models.PostStatusDraft}
+
+	postRepository.EXPECT().GetById(1).Return(draft, nil)
+	_, err := comments.Create(models.Comment{PostId: 1, UserId: 4, Body: "secret draft"})
+	assert.ErrorIs(t, err, ErrPostNotFound, "a draft of another user")
+
+	postRepository.EXPECT().GetById(1).Return(draft, nil)
+	_, _, err = comments.Get(4, 1, ThreadRequest{}, CommentFilter{}, PageRequest{})
+	assert.ErrorIs(t, err, ErrPostNotFound)
+
+	postRepository.EXPECT().GetById(2).Return(models.Post{}, gorm.ErrRecordNotFound)
+	_, err = comments.Create(models.Comment{PostId: 2, UserId: 3, Body: "trashed"})
+	assert.ErrorIs(t, err, ErrPostNotFound, "a post in the trash")
+
+	// the author comments on a draft, the comment is indexed once the post is published
+	postRepository.EXPECT().GetById(1).Return(draft, nil)
+	commentRepository.EXPECT().Create(gomock.Any()).Return(7, nil)
+	_, err = comments.Create(models.Comment{PostId: 1, UserId: 3, Body: "secret draft"})
+	assert.NoError(t, err)
+
+	commentRepository.EXPECT().GetById(1, 7).Return(models.Comment{Id: 7, PostId: 1, UserId: 3}, nil)
+	commentRepository.EXPECT().Update(1, 7, gomock.Any()).Return(nil)
+	postRepository.EXPECT().GetById(1).Return(draft, nil)
+	assert.NoError(t, comments.Update(Principal{Id: 3}, 1, 7, models.Comment{Body: "secret draft again"}))
+
+	commentRepository.EXPECT().GetDeletedById(1, 7).Return(models.Comment{Id: 7, PostId: 1, UserId: 3, Body: "secret draft",
+		DeletedBy: 3}, nil)
+	commentRepository.EXPECT().Restore(1, 7).Return(nil)
+	postRepository.EXPECT().GetById(1).Return(models.Post{}, gorm.ErrRecordNotFound)
+	assert.NoError(t, comments.Restore(Principal{Id: 3}, 1, 7), "the post is in the trash")
+
+	results, err := search.Search("secret", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestHighlight(t *testing.T) {
+	terms := map[string]bool{"needle": true}
+	text := strings.Repeat("hay ", 60) + "a needle & " + strings.Repeat("straw ", 40)
+
+	snippet := highlight(text, terms, 40)
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "a <mark>needle</mark> &amp; ")
+
+	assert.Equal(t, "no match", highlight("no match", terms, 40))
+	assert.Equal(t, "Needles? <mark>Needle</mark>, <mark>needle</mark>.", highlight("Needles? Needle, needle.", terms, 0))
+}
@@ -1,46 +1,157 @@
 package service
 
 import (
+	"context"
 	"test/pkg/repository"
 	"test/pkg/repository/models"
+	"time"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
 type Authorization interface {
 	CreateUser(user models.User) (int, error)
-	GenerateToken(username, password string) (string, error)
-	ParseToken(token string) (int, error)
+	GenerateToken(username, password string, client ClientInfo) (Tokens, error)
+	RefreshToken(refreshToken string) (Tokens, error)
+	Logout(claims *TokenClaims, refreshToken string) error
+	ParseToken(token string) (*TokenClaims, error)
+	IsTokenRevoked(claims *TokenClaims) (bool, error)
+	UpdateRole(userId int, role string) error
+	UnlockUser(userId int) error
+	JWKS() (JWKSet, error)
+	RotateSigningKey() error
+	EnrollTwoFactor(userId int) (TwoFactorEnrollment, error)
+	ConfirmTwoFactor(userId int, code string) ([]string, error)
+	RegenerateRecoveryCodes(userId int, code string) ([]string, error)
+	DisableTwoFactor(userId int, code string) error
+	VerifyTwoFactor(challengeToken, code string, client ClientInfo) (Tokens, error)
+	OAuthSignIn(user OAuthUser, client ClientInfo) (Tokens, error)
 	CheckUser(username string) error
 	Testing(name string) (string, error)
 }
 
 type Post interface {
 	Create(post models.Post) (int, error)
-	Get() ([]models.Post, error)
-	GetById(id int) (models.Post, error)
-	Update(id int, post models.Post) error
-	Delete(id int) error
-	GetByUserId(userId int) ([]models.Post, error)
+	Get(filter PostFilter, page PageRequest) ([]models.Post, string, error)
+	GetById(viewerId, id int) (models.Post, error)
+	Update(actor Principal, id int, post models.Post) error
+	SetStatus(actor Principal, id int, status string, publishAt *time.Time) error
+	PublishDue(now time.Time) (int, error)
+	Delete(actor Principal, id int) error
+	Restore(actor Principal, id int) error
+	GetRevisions(actor Principal, id int, page PageRequest) ([]models.PostRevision, string, error)
+	GetRevision(actor Principal, id, number int) (models.PostRevision, error)
+	DiffRevisions(actor Principal, id, from, to int) (RevisionDiff, error)
+	Revert(actor Principal, id, number int) error
 }
 
 type Comment interface {
 	Create(comment models.Comment) (int, error)
-	Get(postId int) ([]models.Comment, error)
-	Update(postId, id int, comment models.Comment) error
-	Delete(postId, id int) error
+	Get(viewerId, postId int, thread ThreadRequest, filter CommentFilter, page PageRequest) ([]models.Comment, string, error)
+	Update(actor Principal, postId, id int, comment models.Comment) error
+	Delete(actor Principal, postId, id int) error
+	Restore(actor Principal, postId, id int) error
+}
+
+type Tag interface {
+	GetTags(page PageRequest) ([]models.TagCount, string, error)
+	GetTag(name string) (models.Tag, error)
+	SuggestTags(prefix string, limit int) ([]models.Tag, error)
+	RenameTag(id int, name string) (models.Tag, error)
+	MergeTags(id, intoId int) (models.Tag, error)
+}
+
+type Reaction interface {
+	GetReactionKinds() []string
+	React(viewerId int, target ReactionTarget, kind string) (models.ReactionSummary, error)
+	Unreact(viewerId int, target ReactionTarget, kind string) (models.ReactionSummary, error)
+	GetReactions(viewerId int, target ReactionTarget, kind string, page PageRequest) ([]models.Reaction, string, error)
+	GetReactionSummaries(viewerId int, targetType string, ids []int) (map[int]models.ReactionSummary, error)
+}
+
+type Media interface {
+	MaxUploadSize() int64
+	Upload(ctx context.Context, actor Principal, postId int, upload MediaUpload) (models.Media, error)
+	GetMedia(viewerId, postId int) ([]models.Media, error)
+	OpenMedia(ctx context.Context, viewerId, postId, id int) (MediaContent, error)
+	DeleteMedia(ctx context.Context, actor Principal, postId, id int) error
+}
+
+type Account interface {
+	CheckEmail(email string) error
+	SendVerification(userId int) error
+	VerifyEmail(token string) error
+	ForgotPassword(email string) error
+	ResetPassword(token, password string) error
+	CheckVerified(principal Principal) error
+}
+
+type ApiKey interface {
+	CreateApiKey(userId int, name string, scopes []string, expiresAt *time.Time) (string, models.ApiKey, error)
+	GetApiKeys(userId int) ([]models.ApiKey, error)
+	RevokeApiKey(userId, id int) error
+	AuthenticateApiKey(key string) (Principal, error)
+}
+
+type Session interface {
+	GetSessions(userId int) ([]models.Session, error)
+	RevokeSession(userId int, sessionId string) error
+	RevokeOtherSessions(userId int, currentSessionId string) (int, error)
+}
+
+type User interface {
+	GetUser(id int) (models.User, error)
+	UpdateProfile(id int, update ProfileUpdate) (models.User, error)
+	ChangePassword(id int, currentPassword, newPassword, currentSessionId string) error
+	DeleteAccount(id int) error
+}
+
+type Search interface {
+	Search(query string, limit int) ([]SearchResult, error)
+}
+
+type Content interface {
+	PreparePosts(posts []models.Post, format string)
+	PrepareComments(comments []models.Comment, format string)
+}
+
+type Trash interface {
+	GetDeletedPosts(page PageRequest) ([]models.Post, string, error)
+	GetDeletedComments(page PageRequest) ([]models.Comment, string, error)
+	Purge(ctx context.Context, now time.Time) (PurgeResult, error)
 }
 
 type Service struct {
 	Authorization
 	Post
 	Comment
+	Tag
+	Reaction
+	Media
+	Account
+	ApiKey
+	Session
+	User
+	Search
+	Trash
+	Content
 }
 
 func NewService(repos *repository.Repository) *Service {
 	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		Post:          NewPostService(repos.Post),
-		Comment:       NewCommentService(repos.Comment),
+		Authorization: NewAuthService(repos.Authorization, repos.Token, repos.Identity, repos.TwoFactor, repos.Session,
+			NewSigningKeys(repos.SigningKey), NewLoginThrottle(NewMemoryAttemptStore(time.Hour))),
+		Post:     NewPostService(repos.Post, repos.Comment, repos.SearchIndex),
+		Comment:  NewCommentService(repos.Comment, repos.Post, repos.SearchIndex),
+		Tag:      NewTagService(repos.Tag),
+		Reaction: NewReactionService(repos.Reaction, repos.Post, repos.Comment),
+		Media:    NewMediaService(repos.Media, repos.Post, repos.Blobs),
+		Account:  NewAccountService(repos.Authorization, repos.UserToken, repos.Token, repos.ApiKey, NewMailer()),
+		ApiKey:   NewApiKeyService(repos.ApiKey, repos.Authorization),
+		Session:  NewSessionService(repos.Session, repos.Token),
+		User:     NewUserService(repos.User, repos.Authorization, repos.Token, repos.Session, repos.SearchIndex),
+		Search:   NewSearchService(repos.SearchIndex),
+		Trash:    NewTrashService(repos.Post, repos.Comment, repos.Media, repos.Blobs),
+		Content:  NewContentService(),
 	}
 }
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService struct {
+	sessions repository.Session
+	tokens   repository.Token
+}
+
+func NewSessionService(sessions repository.Session, tokens repository.Token) *SessionService {
+	return &SessionService{sessions: sessions, tokens: tokens}
+}
+
+func (s *SessionService) GetSessions(userId int) ([]models.Session, error) {
+	return s.sessions.GetSessions(userId)
+}
+
+// RevokeSession signs the device out: its refresh tokens stop working
+// and its access tokens are rejected.
+func (s *SessionService) RevokeSession(userId int, sessionId string) error {
+	session, err := s.sessions.GetSession(sessionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserId != userId || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	return s.tokens.RevokeFamily(session.Id)
+}
+
+// RevokeOtherSessions revokes every session of the user except the current one
+// and returns how many were revoked.
+func (s *SessionService) RevokeOtherSessions(userId int, currentSessionId string) (int, error) {
+	sessions, err := s.sessions.GetSessions(userId)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, session := range sessions {
+		if session.Id == currentSessionId {
+			continue
+		}
+		if err = s.tokens.RevokeFamily(session.Id); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
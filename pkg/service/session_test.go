@@ -0,0 +1,119 @@
+package service
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestAuthService_IsTokenRevoked(t *testing.T) {
+	revokedAt := time.Now()
+
+	testTable := []struct {
+		name       string
+		claims     TokenClaims
+		jtiRevoked bool
+		session    models.Session
+		sessionErr error
+		touch      bool
+		expected   bool
+	}{
+		{
+			name:     "Active session",
+			claims:   TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 3, SessionId: "sid"},
+			session:  models.Session{Id: "sid", UserId: 3},
+			touch:    true,
+			expected: false,
+		},
+		{
+			name:       "Revoked token",
+			claims:     TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 3, SessionId: "sid"},
+			jtiRevoked: true,
+			expected:   true,
+		},
+		{
+			name:     "Revoked session",
+			claims:   TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 3, SessionId: "sid"},
+			session:  models.Session{Id: "sid", UserId: 3, RevokedAt: &revokedAt},
+			expected: true,
+		},
+		{
+			name:       "Unknown session",
+			claims:     TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 3, SessionId: "sid"},
+			sessionErr: gorm.ErrRecordNotFound,
+			expected:   true,
+		},
+		{
+			name:     "Session of another user",
+			claims:   TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 3, SessionId: "sid"},
+			session:  models.Session{Id: "sid", UserId: 4},
+			expected: true,
+		},
+		{
+			name:     "No session",
+			claims:   TokenClaims{StandardClaims: jwt.StandardClaims{Id: "jti"}, UserId: 3},
+			expected: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tokens := mockRepository.NewMockToken(c)
+			sessions := mockRepository.NewMockSession(c)
+			tokens.EXPECT().IsRevoked("jti").Return(testCase.jtiRevoked, nil)
+			if !testCase.jtiRevoked && testCase.claims.SessionId != "" {
+				sessions.EXPECT().GetSession("sid").Return(testCase.session, testCase.sessionErr)
+			}
+			if testCase.touch {
+				sessions.EXPECT().TouchSession("sid", gomock.Any()).Return(nil)
+			}
+			s := NewAuthService(mockRepository.NewMockAuthorization(c), tokens, mockRepository.NewMockIdentity(c),
+				mockRepository.NewMockTwoFactor(c), sessions, nil, nil)
+
+			revoked, err := s.IsTokenRevoked(&testCase.claims)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, revoked)
+		})
+	}
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	sessions := mockRepository.NewMockSession(c)
+	tokens := mockRepository.NewMockToken(c)
+	s := NewSessionService(sessions, tokens)
+
+	sessions.EXPECT().GetSession("other").Return(models.Session{Id: "other", UserId: 4}, nil)
+	assert.ErrorIs(t, s.RevokeSession(3, "other"), ErrSessionNotFound)
+
+	sessions.EXPECT().GetSession("sid").Return(models.Session{Id: "sid", UserId: 3}, nil)
+	tokens.EXPECT().RevokeFamily("sid").Return(nil)
+	assert.NoError(t, s.RevokeSession(3, "sid"))
+}
+
+func TestSessionService_RevokeOtherSessions(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	sessions := mockRepository.NewMockSession(c)
+	tokens := mockRepository.NewMockToken(c)
+	s := NewSessionService(sessions, tokens)
+
+	sessions.EXPECT().GetSessions(3).Return([]models.Session{{Id: "current"}, {Id: "phone"}, {Id: "laptop"}}, nil)
+	tokens.EXPECT().RevokeFamily("phone").Return(nil)
+	tokens.EXPECT().RevokeFamily("laptop").Return(nil)
+
+	revoked, err := s.RevokeOtherSessions(3, "current")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+}
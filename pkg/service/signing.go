@@ -0,0 +1,244 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"os"
+	"sync"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"time"
+)
+
+const (
+	defaultKeyRotation = 30 * 24 * time.Hour
+	// keyRetention keeps a retired key published until the tokens it signed expire.
+	keyRetention = accessTokenTTL + time.Hour
+	// keyReload is how often the key set is re-read, so keys rotated
+	// by another instance are picked up.
+	keyReload  = time.Minute
+	rsaKeyBits = 2048
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type signingKey struct {
+	kid         string
+	private     *rsa.PrivateKey
+	public      *rsa.PublicKey
+	signUntil   time.Time
+	verifyUntil time.Time
+}
+
+// SigningKeys signs JWTs with RS256 keys kept in the repository. A new key
+// is created when the newest one is older than the rotation period; old keys
+// still verify tokens until those expire.
+type SigningKeys struct {
+	repo     repository.SigningKey
+	rotation time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	keys     []signingKey
+	loadedAt time.Time
+}
+
+// NewSigningKeys reads the rotation period from jwtKeyRotation (e.g. "720h").
+func NewSigningKeys(repo repository.SigningKey) *SigningKeys {
+	rotation, err := time.ParseDuration(os.Getenv("jwtKeyRotation"))
+	if err != nil || rotation <= 0 {
+		rotation = defaultKeyRotation
+	}
+	return &SigningKeys{repo: repo, rotation: rotation, now: time.Now}
+}
+
+// Sign signs the claims with the current key and puts its id into the kid header.
+func (s *SigningKeys) Sign(claims jwt.Claims) (string, error) {
+	key, err := s.current()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Keyfunc returns the public key of the token's kid for jwt.Parse.
+func (s *SigningKeys) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, errors.New("invalid signing method")
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(false); err != nil {
+		return nil, err
+	}
+	key, ok := s.find(kid)
+	if !ok && s.now().Sub(s.loadedAt) > time.Second {
+		// the key may have just been created by another instance
+		if err := s.load(true); err != nil {
+			return nil, err
+		}
+		key, ok = s.find(kid)
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key.public, nil
+}
+
+// Rotate creates a new signing key right away. The previous keys stop signing
+// but keep verifying the tokens they signed.
+func (s *SigningKeys) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(false); err != nil {
+		return err
+	}
+	// keys are ordered newest first, so the new key is used for signing
+	_, err := s.create(s.now())
+	return err
+}
+
+// JWKS returns the public keys that verify tokens, for /.well-known/jwks.json.
+func (s *SigningKeys) JWKS() (JWKSet, error) {
+	if _, err := s.current(); err != nil {
+		return JWKSet{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := JWKSet{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: key.kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.public.E)).Bytes()),
+		})
+	}
+	return set, nil
+}
+
+func (s *SigningKeys) current() (signingKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(false); err != nil {
+		return signingKey{}, err
+	}
+	now := s.now()
+	for _, key := range s.keys {
+		if key.signUntil.After(now) {
+			return key, nil
+		}
+	}
+	return s.create(now)
+}
+
+func (s *SigningKeys) find(kid string) (signingKey, bool) {
+	now := s.now()
+	for _, key := range s.keys {
+		if key.kid == kid && key.verifyUntil.After(now) {
+			return key, true
+		}
+	}
+	return signingKey{}, false
+}
+
+// load re-reads the keys when forced or when the cached set is stale. s.mu must be held.
+func (s *SigningKeys) load(force bool) error {
+	now := s.now()
+	if !force && !s.loadedAt.IsZero() && now.Sub(s.loadedAt) < keyReload {
+		return nil
+	}
+	stored, err := s.repo.GetSigningKeys(now)
+	if err != nil {
+		return err
+	}
+	keys := make([]signingKey, 0, len(stored))
+	for _, k := range stored {
+		key, errParse := parseSigningKey(k)
+		if errParse != nil {
+			return errParse
+		}
+		keys = append(keys, key)
+	}
+	s.keys = keys
+	s.loadedAt = now
+	return nil
+}
+
+// create generates and stores a new key. s.mu must be held.
+func (s *SigningKeys) create(now time.Time) (signingKey, error) {
+	private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return signingKey{}, err
+	}
+	public, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		return signingKey{}, err
+	}
+	key := signingKey{
+		kid:         randomToken(12),
+		private:     private,
+		public:      &private.PublicKey,
+		signUntil:   now.Add(s.rotation),
+		verifyUntil: now.Add(s.rotation + keyRetention),
+	}
+	err = s.repo.CreateSigningKey(models.SigningKey{
+		Kid:         key.kid,
+		Algorithm:   jwt.SigningMethodRS256.Alg(),
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})),
+		PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})),
+		SignUntil:   key.signUntil,
+		VerifyUntil: key.verifyUntil,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return signingKey{}, err
+	}
+	if err = s.repo.DeleteSigningKeys(now); err != nil {
+		return signingKey{}, err
+	}
+	s.keys = append([]signingKey{key}, s.keys...)
+	return key, nil
+}
+
+func parseSigningKey(k models.SigningKey) (signingKey, error) {
+	private, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(k.PrivateKey))
+	if err != nil {
+		return signingKey{}, err
+	}
+	return signingKey{
+		kid:         k.Kid,
+		private:     private,
+		public:      &private.PublicKey,
+		signUntil:   k.SignUntil,
+		verifyUntil: k.VerifyUntil,
+	}, nil
+}
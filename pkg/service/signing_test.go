@@ -0,0 +1,129 @@
+package service
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+// newTestSigningKeys returns signing keys backed by an in-memory repository mock.
+func newTestSigningKeys(c *gomock.Controller) *SigningKeys {
+	var stored []models.SigningKey
+	repo := mockRepository.NewMockSigningKey(c)
+	repo.EXPECT().GetSigningKeys(gomock.Any()).DoAndReturn(func(at time.Time) ([]models.SigningKey, error) {
+		var keys []models.SigningKey
+		for i := len(stored) - 1; i >= 0; i-- {
+			if stored[i].VerifyUntil.After(at) {
+				keys = append(keys, stored[i])
+			}
+		}
+		return keys, nil
+	}).AnyTimes()
+	repo.EXPECT().CreateSigningKey(gomock.Any()).DoAndReturn(func(key models.SigningKey) error {
+		stored = append(stored, key)
+		return nil
+	}).AnyTimes()
+	repo.EXPECT().DeleteSigningKeys(gomock.Any()).Return(nil).AnyTimes()
+	return NewSigningKeys(repo)
+}
+
+func TestSigningKeys_Rotation(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	keys := newTestSigningKeys(c)
+	keys.now = func() time.Time { return now }
+
+	parse := func(token string) error {
+		_, err := jwt.ParseWithClaims(token, &TokenClaims{}, keys.Keyfunc)
+		return err
+	}
+	claims := &TokenClaims{StandardClaims: jwt.StandardClaims{ExpiresAt: now.Add(time.Hour).Unix()}}
+
+	first, err := keys.Sign(claims)
+	assert.NoError(t, err)
+	assert.NoError(t, parse(first))
+
+	set, err := keys.JWKS()
+	assert.NoError(t, err)
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, "RS256", set.Keys[0].Alg)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+
+	// a new key is created once the rotation period is over, the old one still verifies
+	now = now.Add(keys.rotation + time.Minute)
+	second, err := keys.Sign(claims)
+	assert.NoError(t, err)
+	firstToken, _ := jwt.Parse(first, nil)
+	secondToken, _ := jwt.Parse(second, nil)
+	assert.NotEqual(t, firstToken.Header["kid"], secondToken.Header["kid"])
+	assert.NoError(t, parse(first))
+	assert.NoError(t, parse(second))
+
+	set, err = keys.JWKS()
+	assert.NoError(t, err)
+	assert.Len(t, set.Keys, 2)
+
+	// after the retention the old key is gone
+	now = now.Add(keyRetention)
+	set, err = keys.JWKS()
+	assert.NoError(t, err)
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, secondToken.Header["kid"], set.Keys[0].Kid)
+	assert.EqualError(t, parse(first), ErrUnknownKey.Error())
+
+	assert.NoError(t, keys.Rotate())
+	third, err := keys.Sign(claims)
+	assert.NoError(t, err)
+	thirdToken, _ := jwt.Parse(third, nil)
+	assert.NotEqual(t, secondToken.Header["kid"], thirdToken.Header["kid"])
+}
+
+func TestSigningKeys_RejectsHMAC(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	keys := newTestSigningKeys(c)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{})
+	token.Header["kid"] = "kid"
+	signed, err := token.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	_, err = jwt.ParseWithClaims(signed, &TokenClaims{}, keys.Keyfunc)
+	assert.Error(t, err)
+}
+
+func TestAuthService_ParseToken(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tokens := mockRepository.NewMockToken(c)
+	tokens.EXPECT().CreateRefreshToken(gomock.Any()).Return(nil)
+	keys := newTestSigningKeys(c)
+	s := NewAuthService(mockRepository.NewMockAuthorization(c), tokens, mockRepository.NewMockIdentity(c),
+		mockRepository.NewMockTwoFactor(c), mockRepository.NewMockSession(c), keys, NewLoginThrottle(NewMemoryAttemptStore(time.Hour)))
+
+	issued, err := s.issueTokens(models.User{Id: 3, Role: RoleAdmin}, "family")
+	assert.NoError(t, err)
+	claims, err := s.ParseToken(issued.AccessToken)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, claims.UserId)
+		assert.Equal(t, defaultIssuer, claims.Issuer)
+		assert.Equal(t, defaultAudience, claims.Audience)
+		assert.Equal(t, []string{RoleAdmin}, claims.Roles)
+		assert.Equal(t, "family", claims.SessionId)
+	}
+
+	s.audience = "other-service"
+	_, err = s.ParseToken(issued.AccessToken)
+	assert.EqualError(t, err, "invalid token audience")
+
+	s.audience, s.issuer = defaultAudience, "other-issuer"
+	_, err = s.ParseToken(issued.AccessToken)
+	assert.EqualError(t, err, "invalid token issuer")
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+)
+
+const (
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+)
+
+var (
+	ErrTagNotFound   = errors.New("tag not found")
+	ErrTagExists     = errors.New("tag already exists, merge the tags instead")
+	ErrInvalidTag    = errors.New("tag name must not be empty")
+	ErrMergeIntoSelf = errors.New("tag cannot be merged into itself")
+)
+
+type TagService struct {
+	tags repository.Tag
+	list listing[models.TagCount]
+}
+
+func NewTagService(tags repository.Tag) *TagService {
+	return &TagService{
+		tags: tags,
+		list: listing[models.TagCount]{
+			fields: map[string]func(models.TagCount) interface{}{
+				"id":    func(tag models.TagCount) interface{} { return tag.Id },
+				"name":  func(tag models.TagCount) interface{} { return tag.Name },
+				"posts": func(tag models.TagCount) interface{} { return tag.Posts },
+			},
+			defaultSort: "-posts",
+			limits:      newPageLimits(),
+		},
+	}
+}
+
+// GetTags returns a page of the tags with their numbers of published posts, the most used first by default.
+func (t *TagService) GetTags(request PageRequest) ([]models.TagCount, string, error) {
+	page, key, err := t.list.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	tags, err := t.tags.GetTags(page)
+	if err != nil {
+		return nil, "", err
+	}
+	tags, next := t.list.cut(tags, page, key)
+	return tags, next, nil
+}
+
+func (t *TagService) GetTag(name string) (models.Tag, error) {
+	tag, err := t.tags.GetTag(normalizeTag(name))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tag, ErrTagNotFound
+	}
+	return tag, err
+}
+
+// SuggestTags completes a tag name from its beginning.
+func (t *TagService) SuggestTags(prefix string, limit int) ([]models.Tag, error) {
+	if limit <= 0 {
+		limit = defaultTagSuggestions
+	}
+	if limit > maxTagSuggestions {
+		limit = maxTagSuggestions
+	}
+	return t.tags.FindTags(normalizeTag(prefix), limit)
+}
+
+// RenameTag renames the tag on all of its posts. A tag can't take the name of another one,
+// they are merged instead.
+func (t *TagService) RenameTag(id int, name string) (models.Tag, error) {
+	name = normalizeTag(name)
+	if name == "" {
+		return models.Tag{}, ErrInvalidTag
+	}
+	tag, err := t.getTagById(id)
+	if err != nil {
+		return tag, err
+	}
+	existing, err := t.tags.GetTag(name)
+	if err == nil && existing.Id != id {
+		return models.Tag{}, ErrTagExists
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Tag{}, err
+	}
+	if tag.Name == name {
+		return tag, nil
+	}
+	if err = t.tags.RenameTag(id, name); err != nil {
+		return models.Tag{}, err
+	}
+	tag.Name = name
+	return tag, nil
+}
+
+// MergeTags moves the posts of the tag to the other tag, deletes the tag and returns the other one.
+func (t *TagService) MergeTags(id, intoId int) (models.Tag, error) {
+	if id == intoId {
+		return models.Tag{}, ErrMergeIntoSelf
+	}
+	if _, err := t.getTagById(id); err != nil {
+		return models.Tag{}, err
+	}
+	into, err := t.getTagById(intoId)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	err = t.tags.MergeTags(id, intoId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Tag{}, ErrTagNotFound
+	}
+	return into, err
+}
+
+func (t *TagService) getTagById(id int) (models.Tag, error) {
+	tag, err := t.tags.GetTagById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tag, ErrTagNotFound
+	}
+	return tag, err
+}
+
+// normalizeTag lowercases the name and collapses its whitespace,
+// so that "Go", "go " and "GO" are the same tag.
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// normalizeTags normalizes the names and drops the empty and repeated ones.
+// Nil stays nil, which means the tags of a post are left as they are.
+func normalizeTags(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Nil(t, normalizeTags(nil))
+	assert.Equal(t, []string{}, normalizeTags([]string{" ", ""}))
+	assert.Equal(t, []string{"go", "web dev"}, normalizeTags([]string{"Go", " web   Dev ", "GO"}))
+}
+
+func TestTagService_RenameTag(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tags := mockRepository.NewMockTag(c)
+	s := NewTagService(tags)
+
+	tags.EXPECT().GetTagById(1).Return(models.Tag{Id: 1, Name: "golang"}, nil)
+	tags.EXPECT().GetTag("go").Return(models.Tag{}, gorm.ErrRecordNotFound)
+	tags.EXPECT().RenameTag(1, "go").Return(nil)
+	tag, err := s.RenameTag(1, " Go ")
+	assert.NoError(t, err)
+	assert.Equal(t, models.Tag{Id: 1, Name: "go"}, tag)
+
+	tags.EXPECT().GetTagById(1).Return(models.Tag{Id: 1, Name: "golang"}, nil)
+	tags.EXPECT().GetTag("rust").Return(models.Tag{Id: 2, Name: "rust"}, nil)
+	_, err = s.RenameTag(1, "rust")
+	assert.ErrorIs(t, err, ErrTagExists)
+
+	tags.EXPECT().GetTagById(5).Return(models.Tag{}, gorm.ErrRecordNotFound)
+	_, err = s.RenameTag(5, "rust")
+	assert.ErrorIs(t, err, ErrTagNotFound)
+
+	_, err = s.RenameTag(1, "   ")
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
+
+func TestTagService_MergeTags(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tags := mockRepository.NewMockTag(c)
+	s := NewTagService(tags)
+
+	_, err := s.MergeTags(1, 1)
+	assert.ErrorIs(t, err, ErrMergeIntoSelf)
+
+	tags.EXPECT().GetTagById(1).Return(models.Tag{Id: 1, Name: "golang"}, nil)
+	tags.EXPECT().GetTagById(2).Return(models.Tag{}, gorm.ErrRecordNotFound)
+	_, err = s.MergeTags(1, 2)
+	assert.ErrorIs(t, err, ErrTagNotFound)
+
+	tags.EXPECT().GetTagById(1).Return(models.Tag{Id: 1, Name: "golang"}, nil)
+	tags.EXPECT().GetTagById(2).Return(models.Tag{Id: 2, Name: "go"}, nil)
+	tags.EXPECT().MergeTags(1, 2).Return(nil)
+	tag, err := s.MergeTags(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Tag{Id: 2, Name: "go"}, tag)
+}
+
+func TestTagService_SuggestTags(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	tags := mockRepository.NewMockTag(c)
+	s := NewTagService(tags)
+
+	tags.EXPECT().FindTags("web d", defaultTagSuggestions).Return([]models.Tag{{Id: 3, Name: "web dev"}}, nil)
+	suggestions, err := s.SuggestTags("Web  D", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Tag{{Id: 3, Name: "web dev"}}, suggestions)
+
+	tags.EXPECT().FindTags("go", maxTagSuggestions).Return(nil, nil)
+	_, err = s.SuggestTags("go", 1000)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+// publishedPosts returns a repository with a published post of the user 3.
+func publishedPosts(c *gomock.Controller, id int) *mockRepository.MockPost {
+	posts := mockRepository.NewMockPost(c)
+	posts.EXPECT().GetById(id).Return(models.Post{Id: id, UserId: 3, Status: models.PostStatusPublished}, nil).AnyTimes()
+	return posts
+}
+
+func TestCommentService_CreateReply(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	t.Setenv("commentMaxDepth", "2")
+	comments := mockRepository.NewMockComment(c)
+	s := NewCommentService(comments, publishedPosts(c, 5), repository.NewMemorySearchIndex())
+
+	comments.EXPECT().GetById(5, 4).Return(models.Comment{Id: 4, PostId: 5, ParentId: 1, Depth: 1, Path: "1/"}, nil)
+	comments.EXPECT().Create(models.Comment{PostId: 5, ParentId: 4, UserId: 3, Body: "reply", Depth: 2, Path: "1/4/"}).Return(9, nil)
+	id, err := s.Create(models.Comment{PostId: 5, ParentId: 4, UserId: 3, Body: "reply"})
+	assert.NoError(t, err)
+	assert.Equal(t, 9, id)
+
+	comments.EXPECT().GetById(5, 9).Return(models.Comment{Id: 9, PostId: 5, ParentId: 4, Depth: 2, Path: "1/4/"}, nil)
+	_, err = s.Create(models.Comment{PostId: 5, ParentId: 9, UserId: 3, Body: "reply"})
+	assert.ErrorIs(t, err, ErrReplyTooDeep)
+
+	comments.EXPECT().GetById(5, 7).Return(models.Comment{}, gorm.ErrRecordNotFound)
+	_, err = s.Create(models.Comment{PostId: 5, ParentId: 7, UserId: 3, Body: "reply"})
+	assert.ErrorIs(t, err, ErrParentNotFound)
+}
+
+func TestCommentService_GetTree(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	comments := mockRepository.NewMockComment(c)
+	s := NewCommentService(comments, publishedPosts(c, 5), repository.NewMemorySearchIndex())
+	created := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	top := 0
+
+	comments.EXPECT().Get(5, CommentFilter{Parent: &top, Placeholders: true}, gomock.Any()).Return([]models.Comment{
+		{Id: 1, PostId: 5, UserId: 2, Body: "deleted", DeletedAt: gorm.DeletedAt{Time: created, Valid: true}},
+		{Id: 2, PostId: 5, UserId: 3, Body: "top"},
+	}, nil)
+	comments.EXPECT().GetReplies(5, []int{1, 2}, 2).Return([]models.Comment{
+		{Id: 3, PostId: 5, ParentId: 1, UserId: 4, Body: "first", Depth: 1, Path: "1/", CreatedAt: created},
+		{Id: 4, PostId: 5, ParentId: 1, UserId: 4, Body: "second", Depth: 1, Path: "1/", CreatedAt: created},
+	}, nil)
+	comments.EXPECT().GetReplies(5, []int{3}, 2).Return([]models.Comment{
+		{Id: 6, PostId: 5, ParentId: 3, UserId: 2, Body: "deep", Depth: 2, Path: "1/3/", CreatedAt: created},
+	}, nil)
+	comments.EXPECT().CountReplies(5, []int{1, 2, 3, 6}).Return(map[int]int{1: 2, 3: 1}, nil)
+
+	tree, next, err := s.Get(0, 5, ThreadRequest{Tree: true, Replies: 1}, CommentFilter{}, PageRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	if assert.Len(t, tree, 2) {
+		assert.Equal(t, 0, tree[0].UserId)
+		assert.Equal(t, models.DeletedCommentBody, tree[0].Body)
+		assert.Equal(t, 2, tree[0].ReplyCount)
+		assert.NotEmpty(t, tree[0].RepliesCursor)
+		if assert.Len(t, tree[0].Replies, 1) {
+			assert.Equal(t, "first", tree[0].Replies[0].Body)
+			assert.Equal(t, 1, tree[0].Replies[0].ReplyCount)
+			assert.Empty(t, tree[0].Replies[0].RepliesCursor)
+			assert.Equal(t, []models.Comment{{Id: 6, PostId: 5, ParentId: 3, UserId: 2, Body: "deep", Depth: 2, Path: "1/3/", CreatedAt: created}},
+				tree[0].Replies[0].Replies)
+		}
+		assert.Empty(t, tree[1].Replies)
+	}
+
+	// the cursor of the replies continues them after the first one
+	comments.EXPECT().GetById(5, 1).Return(models.Comment{}, gorm.ErrRecordNotFound)
+	comments.EXPECT().GetDeletedById(5, 1).Return(models.Comment{Id: 1, PostId: 5}, nil)
+	parent := 1
+	comments.EXPECT().Get(5, CommentFilter{Parent: &parent, Placeholders: true}, repository.Page{
+		Sort:  []repository.Sort{{Column: "created_at"}, {Column: "id"}},
+		After: []interface{}{created, 3},
+		Limit: defaultPageSize + 1,
+	}).Return(nil, nil)
+	_, _, err = s.Get(0, 5, ThreadRequest{Parent: 1, Tree: true}, CommentFilter{}, PageRequest{Cursor: tree[0].RepliesCursor})
+	assert.NoError(t, err)
+}
+
+func TestCommentService_GetThread(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	comments := mockRepository.NewMockComment(c)
+	s := NewCommentService(comments, publishedPosts(c, 5), repository.NewMemorySearchIndex())
+
+	comments.EXPECT().GetById(5, 4).Return(models.Comment{Id: 4, PostId: 5, ParentId: 1, Depth: 1, Path: "1/"}, nil)
+	comments.EXPECT().Get(5, CommentFilter{UserId: 2, Thread: "1/4/"}, gomock.Any()).Return(nil, nil)
+	_, _, err := s.Get(0, 5, ThreadRequest{Parent: 4}, CommentFilter{UserId: 2}, PageRequest{})
+	assert.NoError(t, err)
+
+	comments.EXPECT().GetById(5, 8).Return(models.Comment{}, gorm.ErrRecordNotFound)
+	comments.EXPECT().GetDeletedById(5, 8).Return(models.Comment{}, gorm.ErrRecordNotFound)
+	_, _, err = s.Get(0, 5, ThreadRequest{Parent: 8}, CommentFilter{}, PageRequest{})
+	assert.ErrorIs(t, err, ErrCommentNotFound)
+}
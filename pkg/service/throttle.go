@@ -0,0 +1,249 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attempts is the failed sign-in history of one key (an account or an IP address).
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore keeps failed sign-in attempts. Update changes the attempts of a key
+// atomically, so the attempts running at the same time see each other.
+type AttemptStore interface {
+	Update(key string, update func(attempts Attempts) Attempts) error
+	Delete(key string) error
+}
+
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+	ttl      time.Duration
+	updates  int
+}
+
+// NewMemoryAttemptStore keeps attempts in memory. Entries without failures
+// for longer than ttl are dropped.
+func NewMemoryAttemptStore(ttl time.Duration) *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: map[string]Attempts{}, ttl: ttl}
+}
+
+func (m *MemoryAttemptStore) Update(key string, update func(attempts Attempts) Attempts) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[key] = update(m.attempts[key])
+	m.updates++
+	if m.updates%1000 == 0 {
+		m.prune(time.Now())
+	}
+	return nil
+}
+
+func (m *MemoryAttemptStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *MemoryAttemptStore) prune(now time.Time) {
+	for key, attempts := range m.attempts {
+		if now.Sub(attempts.LastFailure) > m.ttl && now.After(attempts.LockedUntil) {
+			delete(m.attempts, key)
+		}
+	}
+}
+
+// TooManyAttemptsError is returned while a key is backed off or locked.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed sign-in attempts, retry in %d seconds", int(e.RetryAfter.Seconds()+0.5))
+}
+
+// ThrottlePolicy describes the backoff of one kind of key. The first FreeFailures
+// failures are not delayed, every next one doubles the delay starting at BaseDelay.
+// After MaxFailures the key is locked for Lockout. Failures older than Window are forgotten.
+type ThrottlePolicy struct {
+	FreeFailures int
+	MaxFailures  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Lockout      time.Duration
+	Window       time.Duration
+}
+
+var (
+	accountThrottle = ThrottlePolicy{
+		FreeFailures: 3,
+		MaxFailures:  10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+	ipThrottle = ThrottlePolicy{
+		FreeFailures: 10,
+		MaxFailures:  50,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+)
+
+// LoginThrottle tracks failed sign-ins per account and per IP address.
+type LoginThrottle struct {
+	store   AttemptStore
+	account ThrottlePolicy
+	ip      ThrottlePolicy
+	now     func() time.Time
+}
+
+type throttleKey struct {
+	name   string
+	policy ThrottlePolicy
+}
+
+func NewLoginThrottle(store AttemptStore) *LoginThrottle {
+	return &LoginThrottle{store: store, account: accountThrottle, ip: ipThrottle, now: time.Now}
+}
+
+// Check returns a *TooManyAttemptsError if the account or the IP address must wait.
+// Otherwise the attempt is recorded as failed until Release or Succeed takes it back,
+// so attempts made at the same time can't get past the limits together.
+func (l *LoginThrottle) Check(username, ip string) error {
+	now := l.now()
+	var wait time.Duration
+	var recorded []throttleKey
+	for _, key := range l.keys(username, ip) {
+		var keyWait time.Duration
+		err := l.store.Update(key.name, func(attempts Attempts) Attempts {
+			attempts = key.policy.expire(attempts, now)
+			if keyWait = key.policy.wait(attempts, now); keyWait > 0 {
+				return attempts
+			}
+			return key.policy.fail(attempts, now)
+		})
+		if err != nil {
+			_ = l.release(recorded, now)
+			return err
+		}
+		if keyWait == 0 {
+			recorded = append(recorded, key)
+		} else if keyWait > wait {
+			wait = keyWait
+		}
+	}
+	if wait > 0 {
+		// a refused attempt is not made, it doesn't count for the other key
+		_ = l.release(recorded, now)
+		return &TooManyAttemptsError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Release takes back the attempt recorded by Check when it didn't fail,
+// like a right password before the second factor or an error of the server.
+func (l *LoginThrottle) Release(username, ip string) error {
+	return l.release(l.keys(username, ip), l.now())
+}
+
+// Succeed forgets the failures of the account. The IP address keeps its
+// failures, so a valid account can't be used to reset them.
+func (l *LoginThrottle) Succeed(username, ip string) error {
+	if err := l.Unlock(username); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return l.release([]throttleKey{l.ipKey(ip)}, l.now())
+}
+
+func (l *LoginThrottle) Unlock(username string) error {
+	return l.store.Delete(accountKey(username))
+}
+
+func (l *LoginThrottle) release(keys []throttleKey, now time.Time) error {
+	var errRelease error
+	for _, key := range keys {
+		err := l.store.Update(key.name, func(attempts Attempts) Attempts {
+			return key.policy.release(attempts, now)
+		})
+		if err != nil && errRelease == nil {
+			errRelease = err
+		}
+	}
+	return errRelease
+}
+
+func (l *LoginThrottle) keys(username, ip string) []throttleKey {
+	keys := []throttleKey{{name: accountKey(username), policy: l.account}}
+	if ip != "" {
+		keys = append(keys, l.ipKey(ip))
+	}
+	return keys
+}
+
+func (l *LoginThrottle) ipKey(ip string) throttleKey {
+	return throttleKey{name: "ip:" + ip, policy: l.ip}
+}
+
+func accountKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+// expire forgets the failures older than Window and the lockout that is over.
+func (p ThrottlePolicy) expire(attempts Attempts, now time.Time) Attempts {
+	if now.Before(attempts.LockedUntil) {
+		return attempts
+	}
+	if !attempts.LockedUntil.IsZero() || now.Sub(attempts.LastFailure) > p.Window {
+		return Attempts{}
+	}
+	return attempts
+}
+
+func (p ThrottlePolicy) fail(attempts Attempts, now time.Time) Attempts {
+	attempts.Failures++
+	attempts.LastFailure = now
+	if attempts.Failures >= p.MaxFailures {
+		attempts.LockedUntil = now.Add(p.Lockout)
+	}
+	return attempts
+}
+
+// release takes back one failure, and the lockout if it was that failure that locked the key.
+func (p ThrottlePolicy) release(attempts Attempts, now time.Time) Attempts {
+	attempts = p.expire(attempts, now)
+	if attempts.Failures > 0 {
+		attempts.Failures--
+	}
+	if attempts.Failures < p.MaxFailures {
+		attempts.LockedUntil = time.Time{}
+	}
+	return attempts
+}
+
+func (p ThrottlePolicy) wait(attempts Attempts, now time.Time) time.Duration {
+	if now.Before(attempts.LockedUntil) {
+		return attempts.LockedUntil.Sub(now)
+	}
+	if attempts.Failures < p.FreeFailures || now.Sub(attempts.LastFailure) > p.Window {
+		return 0
+	}
+	delay := p.MaxDelay
+	if shift := attempts.Failures - p.FreeFailures; shift < 32 && p.BaseDelay<<uint(shift) < p.MaxDelay {
+		delay = p.BaseDelay << uint(shift)
+	}
+	return attempts.LastFailure.Add(delay).Sub(now)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle(NewMemoryAttemptStore(time.Hour))
+	throttle.now = func() time.Time { return now }
+
+	// an attempt counts as failed from the check on
+	for i := 0; i < accountThrottle.FreeFailures; i++ {
+		assert.NoError(t, throttle.Check("Test", "10.0.0.1"))
+	}
+
+	var tooMany *TooManyAttemptsError
+	err := throttle.Check("test", "10.0.0.2")
+	if assert.True(t, errors.As(err, &tooMany)) {
+		assert.Equal(t, time.Second, tooMany.RetryAfter)
+	}
+
+	now = now.Add(time.Second)
+	for i := accountThrottle.FreeFailures; i < accountThrottle.MaxFailures; i++ {
+		assert.NoError(t, throttle.Check("test", "10.0.0.1"))
+		now = now.Add(accountThrottle.MaxDelay)
+	}
+	err = throttle.Check("test", "10.0.0.1")
+	if assert.True(t, errors.As(err, &tooMany)) {
+		assert.Equal(t, accountThrottle.Lockout-accountThrottle.MaxDelay, tooMany.RetryAfter)
+	}
+	assert.NoError(t, throttle.Check("other", "10.0.0.3"))
+
+	assert.NoError(t, throttle.Unlock("TEST"))
+	assert.NoError(t, throttle.Check("test", "10.0.0.3"))
+}
+
+func TestLoginThrottle_Release(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle(NewMemoryAttemptStore(time.Hour))
+	throttle.now = func() time.Time { return now }
+
+	// attempts that didn't fail are taken back and don't add up
+	for i := 0; i < 2*accountThrottle.MaxFailures; i++ {
+		assert.NoError(t, throttle.Check("test", "10.0.0.1"))
+		assert.NoError(t, throttle.Release("test", "10.0.0.1"))
+	}
+
+	// the IP address keeps its failures after a sign-in
+	for i := 0; i < ipThrottle.FreeFailures-1; i++ {
+		assert.NoError(t, throttle.Check(fmt.Sprintf("user%d", i), "10.0.0.1"))
+	}
+	assert.NoError(t, throttle.Check("test", "10.0.0.1"))
+	assert.NoError(t, throttle.Succeed("test", "10.0.0.1"))
+	assert.NoError(t, throttle.Check("other", "10.0.0.1"))
+	var tooMany *TooManyAttemptsError
+	assert.True(t, errors.As(throttle.Check("test", "10.0.0.1"), &tooMany))
+
+	// a refused attempt doesn't count for the account
+	for i := 0; i < accountThrottle.FreeFailures-1; i++ {
+		assert.NoError(t, throttle.Check("victim", ""))
+	}
+	assert.True(t, errors.As(throttle.Check("victim", "10.0.0.1"), &tooMany))
+	assert.NoError(t, throttle.Check("victim", "10.0.0.2"))
+}
+
+func TestLoginThrottle_Concurrent(t *testing.T) {
+	throttle := NewLoginThrottle(NewMemoryAttemptStore(time.Hour))
+
+	var passed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if throttle.Check("test", "") == nil {
+				atomic.AddInt32(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(accountThrottle.FreeFailures), passed)
+}
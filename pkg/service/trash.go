@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"time"
+)
+
+const (
+	defaultTrashRetention = 30 * 24 * time.Hour
+	trashPurgeInterval    = time.Hour
+	orphanBatch           = 100
+)
+
+// PurgeResult counts the posts, comments and media that were deleted for good.
+// Comments under purged posts are not counted.
+type PurgeResult struct {
+	Posts    int64 `json:"posts"`
+	Comments int64 `json:"comments"`
+	Media    int64 `json:"media"`
+}
+
+// TrashService lists deleted posts and comments and purges them
+// once they have been in the trash for the retention period.
+type TrashService struct {
+	posts       repository.Post
+	comments    repository.Comment
+	media       repository.Media
+	blobs       repository.BlobStore
+	retention   time.Duration
+	postList    listing[models.Post]
+	commentList listing[models.Comment]
+}
+
+// NewTrashService reads the retention period from trashRetention (e.g. "720h").
+func NewTrashService(posts repository.Post, comments repository.Comment, media repository.Media,
+	blobs repository.BlobStore) *TrashService {
+	retention, err := time.ParseDuration(os.Getenv("trashRetention"))
+	if err != nil || retention <= 0 {
+		retention = defaultTrashRetention
+	}
+	limits := newPageLimits()
+	return &TrashService{
+		posts:     posts,
+		comments:  comments,
+		media:     media,
+		blobs:     blobs,
+		retention: retention,
+		postList: listing[models.Post]{
+			fields: map[string]func(models.Post) interface{}{
+				"id":         func(post models.Post) interface{} { return post.Id },
+				"deleted_at": func(post models.Post) interface{} { return post.DeletedAt.Time },
+			},
+			defaultSort: "-deleted_at",
+			limits:      limits,
+		},
+		commentList: listing[models.Comment]{
+			fields: map[string]func(models.Comment) interface{}{
+				"id":         func(comment models.Comment) interface{} { return comment.Id },
+				"deleted_at": func(comment models.Comment) interface{} { return comment.DeletedAt.Time },
+			},
+			defaultSort: "-deleted_at",
+			limits:      limits,
+		},
+	}
+}
+
+// GetDeletedPosts returns a page of the posts in the trash, recently deleted first by default.
+func (t *TrashService) GetDeletedPosts(request PageRequest) ([]models.Post, string, error) {
+	page, key, err := t.postList.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	posts, err := t.posts.GetDeleted(page)
+	if err != nil {
+		return nil, "", err
+	}
+	posts, next := t.postList.cut(posts, page, key)
+	return posts, next, nil
+}
+
+// GetDeletedComments returns a page of the comments in the trash, recently deleted first by default.
+func (t *TrashService) GetDeletedComments(request PageRequest) ([]models.Comment, string, error) {
+	page, key, err := t.commentList.page(request)
+	if err != nil {
+		return nil, "", err
+	}
+	comments, err := t.comments.GetDeleted(page)
+	if err != nil {
+		return nil, "", err
+	}
+	comments, next := t.commentList.cut(comments, page, key)
+	return comments, next, nil
+}
+
+// Purge deletes the posts and comments that were deleted longer than the retention period ago.
+// They were removed from the search index when they were moved to the trash.
+// The media of the posts that are gone, purged or deleted with their author, are deleted too.
+func (t *TrashService) Purge(ctx context.Context, now time.Time) (PurgeResult, error) {
+	var result PurgeResult
+	var err error
+	before := now.Add(-t.retention)
+	if result.Posts, err = t.posts.Purge(before); err != nil {
+		return result, err
+	}
+	if result.Comments, err = t.comments.Purge(before); err != nil {
+		return result, err
+	}
+	result.Media, err = t.purgeOrphanedMedia(ctx)
+	return result, err
+}
+
+func (t *TrashService) purgeOrphanedMedia(ctx context.Context) (int64, error) {
+	var purged int64
+	for {
+		media, err := t.media.GetOrphanedMedia(orphanBatch)
+		if err != nil {
+			return purged, err
+		}
+		if err = deleteMedia(ctx, t.media, t.blobs, media); err != nil {
+			return purged, err
+		}
+		purged += int64(len(media))
+		if len(media) < orphanBatch {
+			return purged, nil
+		}
+	}
+}
+
+// RunTrashPurge purges the trash every hour until the context is done.
+func RunTrashPurge(ctx context.Context, trash Trash) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		result, err := trash.Purge(ctx, time.Now())
+		if err != nil {
+			log.Printf("trash purge failed: %s", err.Error())
+		} else if result.Posts > 0 || result.Comments > 0 || result.Media > 0 {
+			log.Printf("trash purge deleted %d posts, %d comments and %d media", result.Posts, result.Comments, result.Media)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
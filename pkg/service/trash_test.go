@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"io/fs"
+	"strings"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestPostService_DeleteAndRestore(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	index := repository.NewMemorySearchIndex()
+	postRepository := mockRepository.NewMockPost(c)
+	commentRepository := mockRepository.NewMockComment(c)
+	posts := NewPostService(postRepository, commentRepository, index)
+	search := NewSearchService(index)
+	owner := Principal{Id: 3, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	post := models.Post{Id: 1, UserId: 3, Title: "Sourdough", Anons: "Feed the starter", Status: models.PostStatusPublished}
+	comment := models.Comment{Id: 7, PostId: 1, UserId: 4, Body: "My sourdough starter died"}
+
+	assert.NoError(t, indexPost(index, 1, post))
+	assert.NoError(t, indexComment(index, 1, 7, comment))
+
+	postRepository.EXPECT().GetById(1).Return(post, nil)
+	postRepository.EXPECT().Delete(1, 3).Return(nil)
+	assert.NoError(t, posts.Delete(owner, 1))
+	results, err := search.Search("sourdough", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	deleted := post
+	deleted.DeletedBy = 3
+	postRepository.EXPECT().GetDeletedById(1).Return(deleted, nil)
+	err = posts.Restore(Principal{Id: 4, Roles: []string{RoleModerator}, Scopes: []string{ScopeAll}}, 1)
+	var forbidden *ForbiddenError
+	assert.ErrorAs(t, err, &forbidden)
+
+	// the author can't undo the removal by a moderator
+	removed := post
+	removed.DeletedBy = 4
+	postRepository.EXPECT().GetDeletedById(1).Return(removed, nil)
+	assert.ErrorAs(t, posts.Restore(owner, 1), &forbidden)
+
+	postRepository.EXPECT().GetDeletedById(2).Return(models.Post{}, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, posts.Restore(owner, 2), ErrPostNotFound)
+
+	postRepository.EXPECT().GetDeletedById(1).Return(deleted, nil)
+	postRepository.EXPECT().Restore(1).Return(nil)
+	commentRepository.EXPECT().Get(1, CommentFilter{}, repository.Page{Sort: []repository.Sort{{Column: "id"}}, Limit: maxPageSize}).
+		Return([]models.Comment{comment}, nil)
+	assert.NoError(t, posts.Restore(owner, 1))
+	results, err = search.Search("sourdough", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestCommentService_Restore(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	commentRepository := mockRepository.NewMockComment(c)
+	comments := NewCommentService(commentRepository, publishedPosts(c, 1), repository.NewMemorySearchIndex())
+	owner := Principal{Id: 3, Roles: []string{RoleUser}, Scopes: []string{ScopeAll}}
+	admin := Principal{Id: 1, Roles: []string{RoleAdmin}, Scopes: []string{ScopeAll}}
+	removed := models.Comment{Id: 7, PostId: 1, UserId: 3, Body: "spam", DeletedBy: 4}
+
+	commentRepository.EXPECT().Delete(1, 7, 3).Return(nil)
+	commentRepository.EXPECT().GetById(1, 7).Return(models.Comment{Id: 7, PostId: 1, UserId: 3}, nil)
+	assert.NoError(t, comments.Delete(owner, 1, 7))
+
+	// the author can't undo the removal by a moderator
+	commentRepository.EXPECT().GetDeletedById(1, 7).Return(removed, nil)
+	var forbidden *ForbiddenError
+	assert.ErrorAs(t, comments.Restore(owner, 1, 7), &forbidden)
+
+	commentRepository.EXPECT().GetDeletedById(1, 7).Return(removed, nil)
+	commentRepository.EXPECT().Restore(1, 7).Return(nil)
+	assert.NoError(t, comments.Restore(admin, 1, 7))
+
+	removed.DeletedBy = 3
+	commentRepository.EXPECT().GetDeletedById(1, 7).Return(removed, nil)
+	commentRepository.EXPECT().Restore(1, 7).Return(nil)
+	assert.NoError(t, comments.Restore(owner, 1, 7))
+}
+
+func TestTrashService_Purge(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	t.Setenv("trashRetention", "48h")
+	posts := mockRepository.NewMockPost(c)
+	comments := mockRepository.NewMockComment(c)
+	media := mockRepository.NewMockMedia(c)
+	blobs, err := repository.NewLocalBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	trash := NewTrashService(posts, comments, media, blobs)
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	orphans := make([]models.Media, orphanBatch)
+	ids := make([]int, orphanBatch)
+	for i := range orphans {
+		orphans[i] = models.Media{Id: i + 1, PostId: 4, Key: fmt.Sprintf("posts/4/%d", i+1)}
+		ids[i] = i + 1
+		assert.NoError(t, blobs.Put(context.Background(), orphans[i].Key, strings.NewReader("x"), 1, "text/plain"))
+	}
+	last := models.Media{Id: 101, PostId: 5, Key: "posts/5/101"}
+
+	posts.EXPECT().Purge(now.Add(-48*time.Hour)).Return(int64(2), nil)
+	comments.EXPECT().Purge(now.Add(-48*time.Hour)).Return(int64(5), nil)
+	gomock.InOrder(
+		media.EXPECT().GetOrphanedMedia(orphanBatch).Return(orphans, nil),
+		media.EXPECT().DeleteMedia(ids).Return(nil),
+		media.EXPECT().GetOrphanedMedia(orphanBatch).Return([]models.Media{last}, nil),
+		media.EXPECT().DeleteMedia([]int{101}).Return(nil),
+	)
+	result, err := trash.Purge(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, PurgeResult{Posts: 2, Comments: 5, Media: 101}, result)
+	_, err = blobs.Open(context.Background(), orphans[0].Key)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestTrashService_GetDeletedPosts(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	posts := mockRepository.NewMockPost(c)
+	trash := NewTrashService(posts, mockRepository.NewMockComment(c), mockRepository.NewMockMedia(c), nil)
+	deletedAt := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	deleted := func(id int) models.Post {
+		return models.Post{Id: id, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}
+	}
+
+	sortBy := []repository.Sort{{Column: "deleted_at", Desc: true}, {Column: "id", Desc: true}}
+	posts.EXPECT().GetDeleted(repository.Page{Sort: sortBy, Limit: 3}).
+		Return([]models.Post{deleted(9), deleted(8), deleted(4)}, nil)
+	page, next, err := trash.GetDeletedPosts(PageRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+	assert.NotEmpty(t, next)
+
+	posts.EXPECT().GetDeleted(repository.Page{Sort: sortBy, After: []interface{}{deletedAt, 8}, Limit: 3}).
+		Return([]models.Post{deleted(4)}, nil)
+	page, next, err = trash.GetDeletedPosts(PageRequest{Limit: 2, Cursor: next})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Post{deleted(4)}, page)
+	assert.Empty(t, next)
+
+	_, _, err = trash.GetDeletedPosts(PageRequest{Sort: "title"})
+	assert.ErrorIs(t, err, ErrInvalidSort)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"test/pkg/repository/models"
+	"time"
+)
+
+const (
+	challengeTTL      = 5 * time.Minute
+	challengeAudience = "two-factor"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment is not started")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired challenge token")
+)
+
+// TwoFactorEnrollment is the pending TOTP secret of a user. The secret is
+// active only after a code generated from it is confirmed.
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// EnrollTwoFactor creates a new pending TOTP secret, replacing a pending one.
+// The issuer in the provisioning URI is read from totpIssuer.
+func (a *AuthService) EnrollTwoFactor(userId int) (TwoFactorEnrollment, error) {
+	twoFactor, err := a.twoFactor.GetTwoFactor(userId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return TwoFactorEnrollment{}, err
+	}
+	if err == nil && twoFactor.Enabled {
+		return TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+	user, err := a.repository.GetUserById(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return TwoFactorEnrollment{}, ErrUserNotFound
+	}
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	secret := newTotpSecret()
+	err = a.twoFactor.SaveTwoFactor(models.TwoFactor{UserId: userId, Secret: secret, CreatedAt: time.Now()})
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	issuer := os.Getenv("totpIssuer")
+	if issuer == "" {
+		issuer = "Server"
+	}
+	return TwoFactorEnrollment{Secret: secret, URI: totpURI(issuer, user.Username, secret)}, nil
+}
+
+// ConfirmTwoFactor enables the pending secret if the code matches it and
+// returns new recovery codes. They are shown only once.
+func (a *AuthService) ConfirmTwoFactor(userId int, code string) ([]string, error) {
+	twoFactor, err := a.twoFactor.GetTwoFactor(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	step, ok := totpMatch(twoFactor.Secret, normalizeCode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	ok, err = a.twoFactor.UseTotpStep(userId, step)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	if err = a.twoFactor.EnableTwoFactor(userId); err != nil {
+		return nil, err
+	}
+	return a.newRecoveryCodes(userId)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after checking a second factor.
+func (a *AuthService) RegenerateRecoveryCodes(userId int, code string) ([]string, error) {
+	if err := a.requireSecondFactor(userId, code); err != nil {
+		return nil, err
+	}
+	return a.newRecoveryCodes(userId)
+}
+
+// DisableTwoFactor removes the secret and the recovery codes after checking a second factor.
+func (a *AuthService) DisableTwoFactor(userId int, code string) error {
+	if err := a.requireSecondFactor(userId, code); err != nil {
+		return err
+	}
+	return a.twoFactor.DeleteTwoFactor(userId)
+}
+
+// VerifyTwoFactor finishes a sign-in started by GenerateToken. The challenge
+// token can be used once; wrong codes count as failed sign-in attempts.
+func (a *AuthService) VerifyTwoFactor(challengeToken, code string, client ClientInfo) (Tokens, error) {
+	claims, err := a.parseChallenge(challengeToken)
+	if err != nil {
+		return Tokens{}, ErrInvalidChallenge
+	}
+	revoked, err := a.tokens.IsRevoked(claims.Id)
+	if err != nil {
+		return Tokens{}, err
+	}
+	if revoked {
+		return Tokens{}, ErrInvalidChallenge
+	}
+	user, err := a.repository.GetUserById(claims.UserId)
+	if err != nil {
+		return Tokens{}, ErrInvalidChallenge
+	}
+	if err = a.throttle.Check(user.Username, client.IP); err != nil {
+		return Tokens{}, err
+	}
+	ok, err := a.checkSecondFactor(user.Id, code)
+	if err == nil && !ok {
+		// the attempt stays recorded as failed
+		return Tokens{}, ErrInvalidTwoFactorCode
+	}
+	if err == nil {
+		err = a.tokens.RevokeToken(claims.Id, time.Unix(claims.ExpiresAt, 0))
+	}
+	if err != nil {
+		_ = a.throttle.Release(user.Username, client.IP)
+		return Tokens{}, err
+	}
+	if err = a.throttle.Succeed(user.Username, client.IP); err != nil {
+		return Tokens{}, err
+	}
+	return a.startSession(user, client)
+}
+
+// signIn issues tokens to a user whose first factor is checked, or a challenge
+// token when the user has two-factor authentication enabled.
+func (a *AuthService) signIn(user models.User, client ClientInfo) (Tokens, error) {
+	twoFactor, err := a.twoFactor.GetTwoFactor(user.Id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Tokens{}, err
+	}
+	if err == nil && twoFactor.Enabled {
+		return a.issueChallenge(user)
+	}
+	return a.startSession(user, client)
+}
+
+func (a *AuthService) issueChallenge(user models.User) (Tokens, error) {
+	now := time.Now()
+	challenge, err := a.keys.Sign(&TokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        randomToken(16),
+			Issuer:    a.issuer,
+			Audience:  challengeAudience,
+			ExpiresAt: now.Add(challengeTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+		UserId: user.Id,
+	})
+	if err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{ChallengeToken: challenge, ExpiresIn: int64(challengeTTL.Seconds())}, nil
+}
+
+func (a *AuthService) parseChallenge(challengeToken string) (*TokenClaims, error) {
+	return a.parseClaims(challengeToken, challengeAudience)
+}
+
+func (a *AuthService) requireSecondFactor(userId int, code string) error {
+	twoFactor, err := a.twoFactor.GetTwoFactor(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !twoFactor.Enabled) {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	// codes are guessed at the same pace as at sign-in, the account may be used by a stolen token
+	user, err := a.repository.GetUserById(userId)
+	if err != nil {
+		return err
+	}
+	if err = a.throttle.Check(user.Username, ""); err != nil {
+		return err
+	}
+	ok, err := a.checkSecondFactor(userId, code)
+	if err != nil {
+		_ = a.throttle.Release(user.Username, "")
+		return err
+	}
+	if !ok {
+		// the attempt stays recorded as failed
+		return ErrInvalidTwoFactorCode
+	}
+	return a.throttle.Succeed(user.Username, "")
+}
+
+// checkSecondFactor accepts a TOTP code or an unused recovery code.
+func (a *AuthService) checkSecondFactor(userId int, code string) (bool, error) {
+	twoFactor, err := a.twoFactor.GetTwoFactor(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil || !twoFactor.Enabled {
+		return false, err
+	}
+	code = normalizeCode(code)
+	if step, ok := totpMatch(twoFactor.Secret, code, time.Now()); ok {
+		return a.twoFactor.UseTotpStep(userId, step)
+	}
+	if len(code) != recoveryCodeLength {
+		return false, nil
+	}
+	return a.twoFactor.UseRecoveryCode(userId, hashToken(code))
+}
+
+const (
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+)
+
+func (a *AuthService) newRecoveryCodes(userId int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := randomCode(recoveryCodeLength)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(code)
+	}
+	if err := a.twoFactor.ReplaceRecoveryCodes(userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func randomCode(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[b[i]&31]
+	}
+	return string(b)
+}
+
+// normalizeCode drops the separators users type or copy with a code.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
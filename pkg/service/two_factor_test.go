@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/base32"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"strings"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+	"time"
+)
+
+func TestTotpCode(t *testing.T) {
+	// test vectors of RFC 6238 for SHA1, truncated to 6 digits
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	testTable := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 20000000000, expected: "353130"},
+	}
+	for _, testCase := range testTable {
+		code, err := totpCode(secret, testCase.unix/totpPeriod)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, code)
+	}
+
+	step, ok := totpMatch(secret, "287082", time.Unix(59+totpPeriod, 0))
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), step)
+	_, ok = totpMatch(secret, "287082", time.Unix(59+3*totpPeriod, 0))
+	assert.False(t, ok)
+}
+
+func TestTotpURI(t *testing.T) {
+	uri := totpURI("Server", "test user", "JBSWY3DPEHPK3PXP")
+	assert.Equal(t, "otpauth://totp/Server:test%20user?algorithm=SHA1&digits=6&issuer=Server&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+	_, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(newTotpSecret())
+	assert.NoError(t, err)
+}
+
+func TestAuthService_TwoFactorSignIn(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockRepository.NewMockAuthorization(c)
+	tokens := mockRepository.NewMockToken(c)
+	twoFactor := mockRepository.NewMockTwoFactor(c)
+	sessions := mockRepository.NewMockSession(c)
+	s := NewAuthService(users, tokens, mockRepository.NewMockIdentity(c), twoFactor, sessions, newTestSigningKeys(c),
+		NewLoginThrottle(NewMemoryAttemptStore(time.Hour)))
+
+	hash, err := s.hasher.Hash("password")
+	assert.NoError(t, err)
+	user := models.User{Id: 3, Username: "test", Password: hash, Role: RoleUser}
+	secret := newTotpSecret()
+	enabled := models.TwoFactor{UserId: 3, Secret: secret, Enabled: true}
+
+	users.EXPECT().GetUser("test").Return(user, nil)
+	twoFactor.EXPECT().GetTwoFactor(3).Return(enabled, nil).AnyTimes()
+
+	result, err := s.GenerateToken("test", "password", ClientInfo{IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	assert.Empty(t, result.AccessToken)
+	assert.NotEmpty(t, result.ChallengeToken)
+
+	_, err = s.ParseToken(result.ChallengeToken)
+	assert.Error(t, err)
+
+	users.EXPECT().GetUserById(3).Return(user, nil).Times(2)
+	tokens.EXPECT().IsRevoked(gomock.Any()).Return(false, nil).Times(2)
+	_, err = s.VerifyTwoFactor(result.ChallengeToken, "000000x", ClientInfo{IP: "10.0.0.1"})
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	code, err := totpCode(secret, time.Now().Unix()/totpPeriod)
+	assert.NoError(t, err)
+	twoFactor.EXPECT().UseTotpStep(3, time.Now().Unix()/totpPeriod).Return(true, nil)
+	tokens.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Return(nil)
+	tokens.EXPECT().CreateRefreshToken(gomock.Any()).Return(nil)
+	sessions.EXPECT().CreateSession(gomock.Any()).Return(nil)
+	issued, err := s.VerifyTwoFactor(result.ChallengeToken, code[:3]+" "+code[3:], ClientInfo{IP: "10.0.0.1"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, issued.AccessToken)
+	assert.Empty(t, issued.ChallengeToken)
+}
+
+func TestAuthService_ConfirmTwoFactor(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockRepository.NewMockAuthorization(c)
+	twoFactor := mockRepository.NewMockTwoFactor(c)
+	s := NewAuthService(users, mockRepository.NewMockToken(c),
+		mockRepository.NewMockIdentity(c), twoFactor, mockRepository.NewMockSession(c), newTestSigningKeys(c), NewLoginThrottle(NewMemoryAttemptStore(time.Hour)))
+
+	secret := newTotpSecret()
+	step := time.Now().Unix() / totpPeriod
+	code, err := totpCode(secret, step)
+	assert.NoError(t, err)
+
+	twoFactor.EXPECT().GetTwoFactor(3).Return(models.TwoFactor{}, gorm.ErrRecordNotFound)
+	_, err = s.ConfirmTwoFactor(3, code)
+	assert.ErrorIs(t, err, ErrTwoFactorNotEnrolled)
+
+	var stored []string
+	twoFactor.EXPECT().GetTwoFactor(3).Return(models.TwoFactor{UserId: 3, Secret: secret}, nil)
+	twoFactor.EXPECT().UseTotpStep(3, step).Return(true, nil)
+	twoFactor.EXPECT().EnableTwoFactor(3).Return(nil)
+	twoFactor.EXPECT().ReplaceRecoveryCodes(3, gomock.Any()).DoAndReturn(func(userId int, hashes []string) error {
+		stored = hashes
+		return nil
+	})
+	codes, err := s.ConfirmTwoFactor(3, code)
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Equal(t, hashToken(strings.ReplaceAll(codes[0], "-", "")), stored[0])
+
+	users.EXPECT().GetUserById(3).Return(models.User{Id: 3, Username: "test"}, nil)
+	twoFactor.EXPECT().GetTwoFactor(3).Return(models.TwoFactor{UserId: 3, Secret: secret, Enabled: true}, nil).Times(2)
+	twoFactor.EXPECT().UseRecoveryCode(3, stored[1]).Return(true, nil)
+	twoFactor.EXPECT().DeleteTwoFactor(3).Return(nil)
+	assert.NoError(t, s.DisableTwoFactor(3, strings.ToUpper(codes[1])))
+}
+
+func TestAuthService_DisableTwoFactorThrottle(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockRepository.NewMockAuthorization(c)
+	twoFactor := mockRepository.NewMockTwoFactor(c)
+	s := NewAuthService(users, mockRepository.NewMockToken(c),
+		mockRepository.NewMockIdentity(c), twoFactor, mockRepository.NewMockSession(c), newTestSigningKeys(c), NewLoginThrottle(NewMemoryAttemptStore(time.Hour)))
+
+	users.EXPECT().GetUserById(3).Return(models.User{Id: 3, Username: "test"}, nil).AnyTimes()
+	twoFactor.EXPECT().GetTwoFactor(3).Return(models.TwoFactor{UserId: 3, Secret: newTotpSecret(), Enabled: true}, nil).AnyTimes()
+	twoFactor.EXPECT().UseRecoveryCode(3, gomock.Any()).Return(false, nil).AnyTimes()
+
+	// a stolen token can't be used to guess the codes
+	for i := 0; i < accountThrottle.FreeFailures; i++ {
+		assert.ErrorIs(t, s.DisableTwoFactor(3, "wrongcode"), ErrInvalidTwoFactorCode)
+	}
+	var tooMany *TooManyAttemptsError
+	assert.ErrorAs(t, s.DisableTwoFactor(3, "wrongcode"), &tooMany)
+	_, err := s.RegenerateRecoveryCodes(3, "wrongcode")
+	assert.ErrorAs(t, err, &tooMany)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"net/url"
+	"os"
+	"strings"
+	"test/pkg/repository"
+	"test/pkg/repository/models"
+	"unicode/utf8"
+)
+
+// Policies for the posts and comments of a deleted account.
+const (
+	DeletePolicyAnonymize = "anonymize"
+	DeletePolicyCascade   = "cascade"
+)
+
+const (
+	maxNameLength      = 100
+	maxBioLength       = 500
+	maxAvatarUrlLength = 500
+)
+
+var (
+	ErrInvalidName      = errors.New("name must be 1 to 100 symbols")
+	ErrBioTooLong       = errors.New("bio must be at most 500 symbols")
+	ErrInvalidAvatarUrl = errors.New("avatar url must be an http or https url")
+	ErrWrongPassword    = errors.New("current password is incorrect")
+)
+
+// ProfileUpdate holds the profile fields to change; nil fields are kept.
+type ProfileUpdate struct {
+	Name      *string
+	Bio       *string
+	AvatarUrl *string
+}
+
+type UserService struct {
+	users        repository.User
+	auth         repository.Authorization
+	tokens       repository.Token
+	sessions     repository.Session
+	index        repository.SearchIndex
+	hasher       PasswordHasher
+	deletePolicy string
+}
+
+// NewUserService reads what happens to the content of deleted accounts
+// from userDeletePolicy: "anonymize" (default) or "cascade".
+func NewUserService(users repository.User, auth repository.Authorization, tokens repository.Token,
+	sessions repository.Session, index repository.SearchIndex) *UserService {
+	policy := os.Getenv("userDeletePolicy")
+	if policy != DeletePolicyCascade {
+		policy = DeletePolicyAnonymize
+	}
+	return &UserService{
+		users:        users,
+		auth:         auth,
+		tokens:       tokens,
+		sessions:     sessions,
+		index:        index,
+		hasher:       NewPasswordHasher(),
+		deletePolicy: policy,
+	}
+}
+
+func (u *UserService) GetUser(id int) (models.User, error) {
+	user, err := u.users.GetById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (u *UserService) UpdateProfile(id int, update ProfileUpdate) (models.User, error) {
+	var user models.User
+	var fields []string
+	if update.Name != nil {
+		user.Name = strings.TrimSpace(*update.Name)
+		if user.Name == "" || utf8.RuneCountInString(user.Name) > maxNameLength {
+			return models.User{}, ErrInvalidName
+		}
+		fields = append(fields, "name")
+	}
+	if update.Bio != nil {
+		user.Bio = strings.TrimSpace(*update.Bio)
+		if utf8.RuneCountInString(user.Bio) > maxBioLength {
+			return models.User{}, ErrBioTooLong
+		}
+		fields = append(fields, "bio")
+	}
+	if update.AvatarUrl != nil {
+		user.AvatarUrl = strings.TrimSpace(*update.AvatarUrl)
+		if user.AvatarUrl != "" && !isHttpUrl(user.AvatarUrl) {
+			return models.User{}, ErrInvalidAvatarUrl
+		}
+		fields = append(fields, "avatar_url")
+	}
+	if len(fields) > 0 {
+		err := u.users.UpdateProfile(id, user, fields...)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.User{}, ErrUserNotFound
+		}
+		if err != nil {
+			return models.User{}, err
+		}
+	}
+	return u.GetUser(id)
+}
+
+// ChangePassword sets a new password after checking the current one
+// and signs out every other session of the user.
+func (u *UserService) ChangePassword(id int, currentPassword, newPassword, currentSessionId string) error {
+	user, err := u.GetUser(id)
+	if err != nil {
+		return err
+	}
+	ok, err := u.hasher.Verify(user.Password, currentPassword)
+	if err != nil || !ok {
+		return ErrWrongPassword
+	}
+	if len(newPassword) < minimumPasswordLength {
+		return ErrPasswordTooShort
+	}
+	hash, err := u.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err = u.auth.UpdatePasswordHash(id, hash); err != nil {
+		return err
+	}
+	sessions, err := u.sessions.GetSessions(id)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.Id == currentSessionId {
+			continue
+		}
+		if err = u.tokens.RevokeFamily(session.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAccount deletes the user and handles the user's content by the configured policy.
+func (u *UserService) DeleteAccount(id int) error {
+	deleted, err := u.users.DeleteUser(id, u.deletePolicy == DeletePolicyAnonymize)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	for _, postId := range deleted.Posts {
+		if err = u.index.RemovePost(postId); err != nil {
+			return err
+		}
+	}
+	for _, commentId := range deleted.Comments {
+		if err = u.index.Remove(models.SearchKindComment, commentId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isHttpUrl(raw string) bool {
+	if len(raw) > maxAvatarUrlLength {
+		return false
+	}
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"strings"
+	"test/pkg/repository"
+	mockRepository "test/pkg/repository/mocks"
+	"test/pkg/repository/models"
+	"testing"
+)
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockRepository.NewMockUser(c)
+	s := NewUserService(users, mockRepository.NewMockAuthorization(c), mockRepository.NewMockToken(c),
+		mockRepository.NewMockSession(c), repository.NewMemorySearchIndex())
+
+	empty := "  "
+	_, err := s.UpdateProfile(3, ProfileUpdate{Name: &empty})
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	long := strings.Repeat("a", maxBioLength+1)
+	_, err = s.UpdateProfile(3, ProfileUpdate{Bio: &long})
+	assert.ErrorIs(t, err, ErrBioTooLong)
+
+	script := "javascript:alert(1)"
+	_, err = s.UpdateProfile(3, ProfileUpdate{AvatarUrl: &script})
+	assert.ErrorIs(t, err, ErrInvalidAvatarUrl)
+
+	name, avatar := " Test ", "https://example.com/a.png"
+	users.EXPECT().UpdateProfile(3, models.User{Name: "Test", AvatarUrl: avatar}, "name", "avatar_url").Return(nil)
+	users.EXPECT().GetById(3).Return(models.User{Id: 3, Name: "Test", AvatarUrl: avatar}, nil)
+	user, err := s.UpdateProfile(3, ProfileUpdate{Name: &name, AvatarUrl: &avatar})
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", user.Name)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockRepository.NewMockUser(c)
+	auth := mockRepository.NewMockAuthorization(c)
+	tokens := mockRepository.NewMockToken(c)
+	sessions := mockRepository.NewMockSession(c)
+	s := NewUserService(users, auth, tokens, sessions, repository.NewMemorySearchIndex())
+
+	hash, err := s.hasher.Hash("password")
+	assert.NoError(t, err)
+	users.EXPECT().GetById(3).Return(models.User{Id: 3, Password: hash}, nil).Times(2)
+
+	assert.ErrorIs(t, s.ChangePassword(3, "wrong", "newpassword", "sid"), ErrWrongPassword)
+
+	auth.EXPECT().UpdatePasswordHash(3, gomock.Any()).Return(nil)
+	sessions.EXPECT().GetSessions(3).Return([]models.Session{{Id: "sid"}, {Id: "other"}}, nil)
+	tokens.EXPECT().RevokeFamily("other").Return(nil)
+	assert.NoError(t, s.ChangePassword(3, "password", "newpassword", "sid"))
+}
+
+func TestUserService_DeleteAccount(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mockRepository.NewMockUser(c)
+	index := repository.NewMemorySearchIndex()
+	s := NewUserService(users, mockRepository.NewMockAuthorization(c), mockRepository.NewMockToken(c),
+		mockRepository.NewMockSession(c), index)
+	assert.Equal(t, DeletePolicyAnonymize, s.deletePolicy)
+
+	users.EXPECT().DeleteUser(3, true).Return(repository.DeletedContent{}, nil)
+	assert.NoError(t, s.DeleteAccount(3))
+
+	s.deletePolicy = DeletePolicyCascade
+	users.EXPECT().DeleteUser(4, false).Return(repository.DeletedContent{}, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, s.DeleteAccount(4), ErrUserNotFound)
+
+	// the deleted content leaves the search, the content of others stays
+	for _, document := range []models.SearchDocument{
+		{Kind: models.SearchKindPost, RefId: 1, PostId: 1, Title: "Golang generics"},
+		{Kind: models.SearchKindComment, RefId: 7, PostId: 1, Body: "generics on my post"},
+		{Kind: models.SearchKindComment, RefId: 8, PostId: 2, Body: "generics on another post"},
+		{Kind: models.SearchKindComment, RefId: 9, PostId: 2, Body: "generics by someone else"},
+	} {
+		assert.NoError(t, index.Index(document))
+	}
+	users.EXPECT().DeleteUser(3, false).Return(repository.DeletedContent{Posts: []int{1}, Comments: []int{8}}, nil)
+	assert.NoError(t, s.DeleteAccount(3))
+	results, err := index.Search("generics", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, 9, results[0].RefId)
+	}
+}